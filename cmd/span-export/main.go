@@ -1,21 +1,47 @@
 // span-export creates various destination formats, mostly for SOLR.
 //
+// Tombstones, records deleted at the source, are not exported. With -deletes,
+// SOLR delete-by-id commands are written for them, one per line, e.g.
+// {"delete":{"id":"ai-49-..."}}. A tombstone without finc.id is an error.
+//
+// Provenance blocks are dropped, unless -with-provenance is set.
+//
+// The SOLR document layout of solr5vu3 is defined in
+// assets/solr/solr5vu3.json. Other layouts can be used with -solr-mapping,
+// e.g. to add a format facet for a new ISIL without a code change.
+//
+// MARC21 is available as MARCXML (-o marcxml, a collection with one record
+// element per line) and as ISO 2709 (-o marc21). Citation formats are RIS (-o
+// ris), BibTeX (-o bibtex) and CSL-JSON (-o csl-json, one item per line).
+// OpenURL context objects for link resolvers are written as id and query
+// string, separated by tab (-o openurl), or as COinS span (-o coins).
+//
+// With -o es-bulk, documents are written as Elasticsearch or OpenSearch bulk
+// API action and source line pairs, deleted records as delete actions. An
+// index mapping is printed with -es-mapping.
+//
 // >> drop: access_facet;
 // >> recordtype => record_format
 package main
 
 import (
+	"bufio"
 	"flag"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"runtime"
 	"runtime/pprof"
 	"sort"
 	"strings"
+	"sync"
 
 	"github.com/miku/span"
+	"github.com/miku/span/formats/bibtex"
 	"github.com/miku/span/formats/finc"
+	"github.com/miku/span/formats/marc"
+	"github.com/miku/span/formats/ris"
 	"github.com/miku/span/parallel"
 
 	json "github.com/segmentio/encoding/json"
@@ -31,12 +57,66 @@ var (
 	format         = flag.String("o", "solr5vu3", "output format")
 	listFormats    = flag.Bool("list", false, "list output formats")
 	withFullrecord = flag.Bool("with-fullrecord", false, "populate fullrecord field with originating intermediate schema record")
+	withProvenance = flag.Bool("with-provenance", false, "keep provenance, written by span-import -provenance, in provenance_str")
+	deletesFile    = flag.String("deletes", "", "write SOLR delete commands for deleted records to this file")
+	esIndex        = flag.String("es-index", finc.DefaultElasticsearchIndex, "index name for es-bulk")
+	esIDField      = flag.String("es-id-field", finc.DefaultElasticsearchIDField, "intermediate schema field used as document id for es-bulk")
+	esOpType       = flag.String("es-op-type", "index", "bulk operation for es-bulk: index or create")
+	esMapping      = flag.Bool("es-mapping", false, "print an index mapping for es-bulk and exit")
+	solrMapping    = flag.String("solr-mapping", "", "write SOLR documents as described by this mapping file, see assets/solr, overrides -o")
 )
 
+// DeleteCommand is a SOLR JSON delete-by-id command.
+type DeleteCommand struct {
+	Delete struct {
+		ID string `json:"id"`
+	} `json:"delete"`
+}
+
+// deleteWriter serializes delete commands from concurrent workers.
+type deleteWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+	n  int
+}
+
+// WriteDelete writes a delete command for a given id.
+func (dw *deleteWriter) WriteDelete(id string) error {
+	var cmd DeleteCommand
+	cmd.Delete.ID = id
+	b, err := json.Marshal(cmd)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	dw.mu.Lock()
+	defer dw.mu.Unlock()
+	dw.n++
+	_, err = dw.w.Write(b)
+	return err
+}
+
+// Count counts a delete, that has been written elsewhere.
+func (dw *deleteWriter) Count() {
+	dw.mu.Lock()
+	defer dw.mu.Unlock()
+	dw.n++
+}
+
 // Exporters holds available export formats
 var Exporters = map[string]func() finc.Exporter{
-	"solr5vu3": func() finc.Exporter { return new(finc.Solr5Vufind3) },
+	"solr5vu3": func() finc.Exporter { return finc.NewSolrExporter(finc.Solr5Vufind3Mapping) },
 	"formeta":  func() finc.Exporter { return new(finc.Formeta) },
+	"marcxml":  func() finc.Exporter { return new(marc.Exporter) },
+	"marc21":   func() finc.Exporter { return &marc.Exporter{Binary: true} },
+	"ris":      func() finc.Exporter { return new(ris.Exporter) },
+	"bibtex":   func() finc.Exporter { return new(bibtex.Exporter) },
+	"csl-json": func() finc.Exporter { return new(finc.CSLJSON) },
+	"openurl":  func() finc.Exporter { return new(finc.OpenURLExporter) },
+	"coins":    func() finc.Exporter { return &finc.OpenURLExporter{COinS: true} },
+	"es-bulk": func() finc.Exporter {
+		return &finc.ElasticsearchBulk{Index: *esIndex, IDField: *esIDField, OpType: *esOpType}
+	},
 }
 
 func main() {
@@ -58,6 +138,15 @@ func main() {
 		os.Exit(0)
 	}
 
+	if *esMapping {
+		b, err := finc.ElasticsearchMapping()
+		if err != nil {
+			log.Fatal(err)
+		}
+		os.Stdout.Write(b)
+		os.Exit(0)
+	}
+
 	if *cpuprofile != "" {
 		f, err := os.Create(*cpuprofile)
 		if err != nil {
@@ -78,6 +167,18 @@ func main() {
 	if !ok {
 		log.Fatalf("unknown export schema: %s", *format)
 	}
+	if *solrMapping != "" {
+		f, err := os.Open(*solrMapping)
+		if err != nil {
+			log.Fatal(err)
+		}
+		m, err := finc.ReadSolrMapping(f)
+		f.Close()
+		if err != nil {
+			log.Fatalf("%s: %v", *solrMapping, err)
+		}
+		exportSchemaFunc = func() finc.Exporter { return finc.NewSolrExporter(m) }
+	}
 
 	var reader io.Reader = os.Stdin
 
@@ -94,6 +195,20 @@ func main() {
 		reader = io.MultiReader(files...)
 	}
 
+	var (
+		deletes = &deleteWriter{w: ioutil.Discard}
+		bw      *bufio.Writer
+	)
+	if *deletesFile != "" {
+		f, err := os.Create(*deletesFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		bw = bufio.NewWriter(f)
+		deletes.w = bw
+	}
+
 	p := parallel.NewProcessor(reader, os.Stdout, func(_ int64, b []byte) ([]byte, error) {
 		is := finc.IntermediateSchema{}
 
@@ -102,16 +217,41 @@ func main() {
 			log.Printf("failed to unmarshal: %s", string(b))
 			return b, err
 		}
+		if err := is.SetVersionDate(); err != nil {
+			return nil, err
+		}
 
 		// Get export format.
 		schema := exportSchemaFunc()
 
+		if is.Deleted {
+			// An empty id would not delete anything.
+			if is.ID == "" {
+				return nil, fmt.Errorf("cannot delete record without finc.id: source %q, record %q", is.SourceID, is.RecordID)
+			}
+			if te, ok := schema.(finc.TombstoneExporter); ok {
+				bb, err := te.ExportTombstone(is)
+				if err != nil {
+					return nil, err
+				}
+				deletes.Count()
+				return append(bb, '\n'), nil
+			}
+			return nil, deletes.WriteDelete(is.ID)
+		}
+		if !*withProvenance {
+			is.Provenance = nil
+		}
+
 		bb, err := schema.Export(is, *withFullrecord)
 		if err != nil {
 			log.Printf("failed to convert: %v", is)
 			return bb, err
 		}
 
+		if sd, ok := schema.(finc.SelfDelimiter); ok && sd.SelfDelimiting() {
+			return bb, nil
+		}
 		bb = append(bb, '\n')
 		return bb, nil
 	})
@@ -119,9 +259,29 @@ func main() {
 	p.NumWorkers = *numWorkers
 	p.BatchSize = *size
 
+	// Some formats wrap all records, e.g. in a MARCXML collection.
+	framer, _ := exportSchemaFunc().(finc.Framer)
+	if framer != nil {
+		if _, err := os.Stdout.Write(framer.Header()); err != nil {
+			log.Fatal(err)
+		}
+	}
 	if err := p.Run(); err != nil {
 		log.Fatal(err)
 	}
+	if framer != nil {
+		if _, err := os.Stdout.Write(framer.Footer()); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if bw != nil {
+		if err := bw.Flush(); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if deletes.n > 0 {
+		log.Printf("%d deleted records", deletes.n)
+	}
 	if *memProfile != "" {
 		f, err := os.Create(*memProfile)
 		if err != nil {
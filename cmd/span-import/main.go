@@ -3,198 +3,547 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"encoding"
+	"encoding/xml"
 	"flag"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
 	"os"
+	"reflect"
 	"runtime"
 	"runtime/pprof"
-	"sort"
+	"sync"
+	"time"
 
+	gzip "github.com/klauspost/pgzip"
 	"github.com/lytics/logrus"
 	"github.com/miku/span"
-	"github.com/miku/span/formats/ceeol"
-	"github.com/miku/span/formats/crossref"
-	"github.com/miku/span/formats/dblp"
-	"github.com/miku/span/formats/degruyter"
-	"github.com/miku/span/formats/doaj"
-	"github.com/miku/span/formats/dummy"
-	"github.com/miku/span/formats/elsevier"
+	"github.com/miku/span/formats"
+	_ "github.com/miku/span/formats/all"
 	"github.com/miku/span/formats/finc"
-	"github.com/miku/span/formats/genderopen"
-	"github.com/miku/span/formats/genios"
-	"github.com/miku/span/formats/hhbd"
-	"github.com/miku/span/formats/highwire"
-	"github.com/miku/span/formats/ieee"
-	"github.com/miku/span/formats/imslp"
-	"github.com/miku/span/formats/ios"
-	"github.com/miku/span/formats/jstor"
-	"github.com/miku/span/formats/mediarep"
-	"github.com/miku/span/formats/olms"
-	"github.com/miku/span/formats/ssoar"
-	"github.com/miku/span/formats/thieme"
-	"github.com/miku/span/formats/zvdd"
 	"github.com/miku/span/parallel"
-	"github.com/miku/xmlstream"
 	"github.com/segmentio/encoding/json"
 	"golang.org/x/net/html/charset"
 )
 
 var (
-	name        = flag.String("i", "", "input format name")
-	list        = flag.Bool("list", false, "list input formats")
-	numWorkers  = flag.Int("w", runtime.NumCPU(), "number of workers")
-	batchSize   = flag.Int("b", 10000, "batch size")
-	showVersion = flag.Bool("v", false, "prints current program version")
-	cpuProfile  = flag.String("cpuprofile", "", "write cpu profile to file")
-	memProfile  = flag.String("memprofile", "", "write heap profile to file (go tool pprof -png --alloc_objects program mem.pprof > mem.png)")
-	logfile     = flag.String("logfile", "", "path to logfile to append to, otherwise stderr")
-	verbose     = flag.Bool("verbose", false, "be verbose")
+	name          = flag.String("i", "", `input format name, use "auto" to detect format`)
+	list          = flag.Bool("list", false, "list input formats")
+	numWorkers    = flag.Int("w", runtime.NumCPU(), "number of workers")
+	batchSize     = flag.Int("b", 10000, "batch size")
+	xmlBatchSize  = flag.Int("xb", 1000, "number of XML elements per batch")
+	preserveOrder = flag.Bool("order", false, "write records of XML formats in input order, otherwise in completion order of parallel batches")
+	showVersion   = flag.Bool("v", false, "prints current program version")
+	cpuProfile    = flag.String("cpuprofile", "", "write cpu profile to file")
+	memProfile    = flag.String("memprofile", "", "write heap profile to file (go tool pprof -png --alloc_objects program mem.pprof > mem.png)")
+	logfile       = flag.String("logfile", "", "path to logfile to append to, otherwise stderr")
+	verbose       = flag.Bool("verbose", false, "be verbose")
+	statsFile     = flag.String("stats", "", "write JSON summary of converted, skipped and rejected records to file")
+	maxErrors     = flag.Int("max-errors", 0, "number of failed records to tolerate before aborting")
+	rejectsFile   = flag.String("rejects", "", "write failed records to this file as newline delimited JSON")
+	addProvenance = flag.Bool("provenance", false, "record input file, position, format, mapping and span version in each record")
+	detectLang    = flag.Bool("detect-lang", false, "fill in missing languages from title and abstract, marked in x.inferred")
+	langMinConf   = flag.Float64("detect-lang-min", finc.DefaultMinConfidence, "minimum confidence of a detected language, between 0 and 1")
+	langSources   = flag.String("detect-lang-sources", "", "comma separated source ids to detect languages for, default all")
+	validate      = flag.Bool("validate", false, "validate converted records against the intermediate schema 1.0, see span-validate")
+	mappingFile   = flag.String("mapping", "", "mapping or source settings with a source id, required for marcxml, marc21, jats, ris, bibtex, datacite, datacite-xml, openalex, pubmed, mods, oai_dc")
 )
 
-// Factory creates things.
-type Factory func() interface{}
+// validator checks converted records, if set.
+var validator *finc.Validator
 
-// FormatMap maps format name to pointer to format struct. TODO(miku): That
-// looks just wrong.
-var FormatMap = map[string]Factory{
-	"ceeol":         func() interface{} { return new(ceeol.Article) },
-	"ceeol-marcxml": func() interface{} { return new(ceeol.Record) },
-	"crossref":      func() interface{} { return new(crossref.Document) },
-	"dblp":          func() interface{} { return new(dblp.Article) },
-	"degruyter":     func() interface{} { return new(degruyter.Article) },
-	"doaj":          func() interface{} { return new(doaj.ArticleV1) },
-	"doaj-legacy":   func() interface{} { return new(doaj.Response) },
-	"doaj-oai":      func() interface{} { return new(doaj.Record) },
-	"dummy":         func() interface{} { return new(dummy.Example) },
-	"genderopen":    func() interface{} { return new(genderopen.Record) },
-	"genios":        func() interface{} { return new(genios.Document) },
-	"hhbd":          func() interface{} { return new(hhbd.Record) },
-	"highwire":      func() interface{} { return new(highwire.Record) },
-	"ieee":          func() interface{} { return new(ieee.Publication) },
-	"imslp":         func() interface{} { return new(imslp.Data) },
-	"ios":           func() interface{} { return new(ios.Article) },
-	"jstor":         func() interface{} { return new(jstor.Article) },
-	"mediarep-dim":  func() interface{} { return new(mediarep.Dim) },
-	"olms":          func() interface{} { return new(olms.Record) },
-	"olms-mets":     func() interface{} { return new(olms.MetsRecord) },
-	"ssoar":         func() interface{} { return new(ssoar.Record) },
-	"thieme-nlm":    func() interface{} { return new(thieme.Record) },
-	"zvdd":          func() interface{} { return new(zvdd.DublicCoreRecord) },
-	"zvdd-mets":     func() interface{} { return new(zvdd.MetsRecord) },
+// detector fills in missing languages, if set.
+var detector *finc.LanguageDetector
+
+// provenance is the part of the provenance, that is the same for all records,
+// only set with -provenance.
+var provenance *finc.Provenance
+
+// input is a named input stream.
+type input struct {
+	name string // empty for standard input
+	r    io.Reader
+}
+
+// recordProvenance returns the provenance of a record at a given byte offset
+// or line of an input, or nil, if provenance is not recorded.
+func recordProvenance(in input, offset, line int64) *finc.Provenance {
+	if provenance == nil {
+		return nil
+	}
+	p := *provenance
+	p.Filename, p.Offset, p.Line = in.name, offset, line
+	p.Converted = time.Now().UTC().Truncate(time.Second)
+	return &p
+}
+
+// convert runs the conversion of a single decoded record and keeps track of
+// skipped and failed records. It returns the serialized intermediate schema
+// or nil, if the record was skipped or rejected. Tombstones for deleted
+// records are passed through. The raw function is only called for rejected
+// records, the provenance may be nil.
+func convert(v interface{}, stats *Stats, raw func() []byte, prov *finc.Provenance) ([]byte, error) {
+	switch converter := v.(type) {
+	case formats.IntermediateSchemaLister:
+		outputs, err := converter.ToIntermediateSchemaList()
+		if err != nil {
+			var output *finc.IntermediateSchema
+			if len(outputs) > 0 {
+				output = outputs[0]
+			}
+			return encode(output, err, stats, raw, prov)
+		}
+		var result []byte
+		for _, output := range outputs {
+			b, err := encode(output, nil, stats, raw, prov)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, b...)
+		}
+		return result, nil
+	case formats.IntermediateSchemaer:
+		output, err := converter.ToIntermediateSchema()
+		return encode(output, err, stats, raw, prov)
+	default:
+		return nil, fmt.Errorf("cannot convert to intermediate schema: %T", v)
+	}
+}
+
+// encode serializes the result of a conversion and updates stats.
+func encode(output *finc.IntermediateSchema, err error, stats *Stats, raw func() []byte, prov *finc.Provenance) ([]byte, error) {
+	var sid string
+	if output != nil {
+		sid = output.SourceID
+	}
+	if skip, ok := err.(span.Skip); ok {
+		if *verbose {
+			log.Printf("%v", err)
+		}
+		stats.Skip(sid, skip)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, stats.Reject(sid, StageConvert, err, raw())
+	}
+	if detector != nil && detector.Enrich(output) {
+		stats.Enrich(output.SourceID)
+	}
+	if prov != nil {
+		output.Provenance = prov
+	}
+	b, err := json.Marshal(output)
+	if err != nil {
+		return nil, stats.Reject(sid, StageEncode, err, raw())
+	}
+	if validator != nil {
+		violations := validator.Validate(b)
+		if len(violations) > 0 && *verbose {
+			for _, v := range violations {
+				log.Printf("%s: %s", output.RecordID, v)
+			}
+		}
+		stats.Validate(violations)
+	}
+	if output.Deleted {
+		stats.Delete(output.SourceID)
+	} else {
+		stats.Convert(output.SourceID)
+	}
+	b = append(b, '\n')
+	return b, nil
+}
+
+// tokens is a single XML element as a sequence of tokens, it implements
+// xml.TokenReader.
+type tokens struct {
+	t      []xml.Token
+	i      int
+	offset int64 // of the start element in the input
+}
+
+// Token returns the next token or io.EOF.
+func (t *tokens) Token() (xml.Token, error) {
+	if t.i == len(t.t) {
+		return nil, io.EOF
+	}
+	t.i++
+	return t.t[t.i-1], nil
+}
+
+// Bytes serializes the tokens, e.g. for a rejects file.
+func (t *tokens) Bytes() []byte {
+	var buf bytes.Buffer
+	enc := xml.NewEncoder(&buf)
+	for _, tok := range t.t {
+		if err := enc.EncodeToken(tok); err != nil {
+			break
+		}
+	}
+	enc.Flush()
+	return buf.Bytes()
+}
+
+// elementName returns the XML element name of a struct, like xmlstream does.
+func elementName(v interface{}) string {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	t := rv.Type()
+	name := t.Name()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Name == "XMLName" || field.Type.String() == "xml.Name" {
+			if tag := field.Tag.Get("xml"); tag != "" {
+				name = tag
+			}
+		}
+	}
+	if name != t.Name() {
+		return name
+	}
+	// The name may come with an embedded record, e.g. marc.Record.
+	if field, ok := t.FieldByName("XMLName"); ok {
+		if tag := field.Tag.Get("xml"); tag != "" {
+			name = tag
+		}
+	}
+	return name
+}
+
+// isElement checks, whether an element has one of the given local names.
+func isElement(se xml.StartElement, names []string) bool {
+	for _, name := range names {
+		if se.Name.Local == name {
+			return true
+		}
+	}
+	return false
 }
 
-// IntermediateSchemaer wrap a basic conversion method.
-type IntermediateSchemaer interface {
-	ToIntermediateSchema() (*finc.IntermediateSchema, error)
+// xmlBatch is a sequence of XML elements.
+type xmlBatch struct {
+	seq      int
+	elements []*tokens
 }
 
-// processXML converts XML based formats, given a format name. It reads XML as
+// xmlResult holds the serialized intermediate schema records of a batch.
+type xmlResult struct {
+	seq int
+	b   []byte
+	err error
+}
+
+// processXML converts XML based formats, given a format. It reads XML as
 // stream and converts record them to an intermediate schema (at the moment).
-func processXML(r io.Reader, w io.Writer, name string) error {
-	if _, ok := FormatMap[name]; !ok {
-		return fmt.Errorf("unknown format name: %s", name)
+// A single goroutine tokenizes the input and extracts the tokens of each
+// element, decoding, conversion and serialization happen in a pool of
+// workers.
+func processXML(in input, w io.Writer, f formats.Format, stats *Stats) error {
+	names := f.Elements
+	if len(names) == 0 {
+		names = []string{elementName(f.New())}
 	}
-	obj := FormatMap[name]()
-	scanner := xmlstream.NewScanner(bufio.NewReader(r), obj)
+	dec := xml.NewDecoder(bufio.NewReader(in.r))
 	// errors like invalid character entities happen, also ISO-8859, ...
-	scanner.Decoder.Strict = false
-	scanner.Decoder.CharsetReader = charset.NewReaderLabel
-	for scanner.Scan() {
-		tag := scanner.Element()
-		converter, ok := tag.(IntermediateSchemaer)
-		if !ok {
-			return fmt.Errorf("cannot convert to intermediate schema: %T", tag)
+	dec.Strict = false
+	dec.CharsetReader = charset.NewReaderLabel
+	var (
+		queue   = make(chan xmlBatch)
+		results = make(chan xmlResult)
+		done    = make(chan struct{})
+		scanErr error
+		wg      sync.WaitGroup
+	)
+	// Scanner, closes the queue when input is exhausted or on cancellation.
+	go func() {
+		defer close(queue)
+		var (
+			seq   int
+			batch []*tokens
+		)
+		send := func() bool {
+			select {
+			case queue <- xmlBatch{seq: seq, elements: batch}:
+				seq++
+				batch = nil
+				return true
+			case <-done:
+				return false
+			}
 		}
-		output, err := converter.ToIntermediateSchema()
-		if err != nil {
-			if _, ok := err.(span.Skip); ok {
-				if *verbose {
-					log.Printf("%v", err)
+		// next returns the tokens of the next element or nil at the end.
+		next := func() (*tokens, error) {
+			for {
+				offset := dec.InputOffset()
+				tok, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				se, ok := tok.(xml.StartElement)
+				if !ok || !isElement(se, names) {
+					continue
+				}
+				var (
+					element = &tokens{t: []xml.Token{se.Copy()}, offset: offset}
+					depth   = 1
+				)
+				for depth > 0 {
+					tok, err := dec.Token()
+					if err != nil {
+						return nil, err
+					}
+					switch tok.(type) {
+					case xml.StartElement:
+						depth++
+					case xml.EndElement:
+						depth--
+					}
+					element.t = append(element.t, xml.CopyToken(tok))
 				}
-				continue
+				return element, nil
 			}
-			return err
 		}
-		if err := json.NewEncoder(w).Encode(output); err != nil {
-			return err
+		for {
+			element, err := next()
+			if err != nil {
+				if err != io.EOF {
+					scanErr = err
+				}
+				break
+			}
+			batch = append(batch, element)
+			if len(batch) == *xmlBatchSize && !send() {
+				return
+			}
+		}
+		if len(batch) > 0 {
+			send()
 		}
+	}()
+	// Workers, decode and convert a batch at a time.
+	for i := 0; i < *numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range queue {
+				var (
+					buf bytes.Buffer
+					err error
+				)
+				for _, element := range batch.elements {
+					var (
+						v    = f.New()
+						d    = xml.NewTokenDecoder(element)
+						b    []byte
+						derr error
+					)
+					d.Strict = false
+					if derr = d.Decode(v); derr != nil {
+						err = stats.Reject("", StageDecode, derr, element.Bytes())
+					} else {
+						b, err = convert(v, stats, element.Bytes, recordProvenance(in, element.offset, 0))
+					}
+					if err != nil {
+						break
+					}
+					buf.Write(b)
+				}
+				select {
+				case results <- xmlResult{seq: batch.seq, b: buf.Bytes(), err: err}:
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	// Writer, optionally restores input order.
+	var (
+		next    int
+		pending = make(map[int][]byte)
+	)
+	// fail stops scanner and workers and waits for them, before returning.
+	fail := func(err error) error {
+		close(done)
+		for range results {
+		}
+		return err
 	}
-	return scanner.Err()
+	for result := range results {
+		if result.err != nil {
+			return fail(result.err)
+		}
+		if !*preserveOrder {
+			if _, err := w.Write(result.b); err != nil {
+				return fail(err)
+			}
+			continue
+		}
+		pending[result.seq] = result.b
+		for {
+			b, ok := pending[next]
+			if !ok {
+				break
+			}
+			if _, err := w.Write(b); err != nil {
+				return fail(err)
+			}
+			delete(pending, next)
+			next++
+		}
+	}
+	return scanErr
 }
 
 // processJSON convert JSON based formats. Input is interpreted as newline delimited JSON.
-func processJSON(r io.Reader, w io.Writer, name string) error {
-	if _, ok := FormatMap[name]; !ok {
-		return fmt.Errorf("unknown format name: %s", name)
-	}
-	p := parallel.NewProcessor(r, w, func(_ int64, b []byte) ([]byte, error) {
-		v := FormatMap[name]()
+func processJSON(in input, w io.Writer, f formats.Format, stats *Stats) error {
+	p := parallel.NewProcessor(in.r, w, func(lineno int64, b []byte) ([]byte, error) {
+		raw := func() []byte { return bytes.TrimSpace(b) }
+		v := f.New()
 		if err := json.Unmarshal(b, v); err != nil {
-			return nil, err
+			return nil, stats.Reject("", StageDecode, err, raw())
 		}
-		converter, ok := v.(IntermediateSchemaer)
+		return convert(v, stats, raw, recordProvenance(in, 0, lineno+1))
+	})
+	p.BatchSize = *batchSize
+	return p.RunWorkers(*numWorkers)
+}
+
+// processDelimited converts binary records, terminated by a separator byte.
+func processDelimited(in input, w io.Writer, f formats.Format, stats *Stats) error {
+	p := parallel.NewProcessor(in.r, w, func(lineno int64, b []byte) ([]byte, error) {
+		raw := func() []byte { return b }
+		v := f.New()
+		unmarshaler, ok := v.(encoding.BinaryUnmarshaler)
 		if !ok {
-			return nil, fmt.Errorf("cannot convert to intermediate schema: %T", v)
+			return nil, fmt.Errorf("cannot unmarshal binary: %T", v)
 		}
-		output, err := converter.ToIntermediateSchema()
-		if _, ok := err.(span.Skip); ok {
-			if *verbose {
-				log.Printf("%v", err)
-			}
-			return nil, nil
+		if err := unmarshaler.UnmarshalBinary(b); err != nil {
+			return nil, stats.Reject("", StageDecode, err, raw())
 		}
-		if err != nil {
-			return nil, err
-		}
-		bb, err := json.Marshal(output)
-		if err != nil {
-			return nil, err
-		}
-		bb = append(bb, '\n')
-		return bb, nil
+		// The line is the record number here.
+		return convert(v, stats, raw, recordProvenance(in, 0, lineno+1))
 	})
 	p.BatchSize = *batchSize
+	p.RecordSeparator = f.Separator
 	return p.RunWorkers(*numWorkers)
 }
 
 // processText processes a single record from raw bytes.
-func processText(r io.Reader, w io.Writer, name string) error {
-	if _, ok := FormatMap[name]; !ok {
-		return fmt.Errorf("unknown format name: %s", name)
-	}
-	// Get the format.
-	data := FormatMap[name]()
+func processText(in input, w io.Writer, f formats.Format, stats *Stats) error {
+	data := f.New()
 
 	// We need an unmarshaller first.
 	unmarshaler, ok := data.(encoding.TextUnmarshaler)
 	if !ok {
 		return fmt.Errorf("cannot unmarshal text: %T", data)
 	}
-	b, err := ioutil.ReadAll(r)
+	b, err := ioutil.ReadAll(in.r)
 	if err != nil {
 		return err
 	}
+	raw := func() []byte { return b }
 	if err := unmarshaler.UnmarshalText(b); err != nil {
-		return err
+		return stats.Reject("", StageDecode, err, raw())
 	}
 
 	// Now that data is populated we can convert.
-	converter, ok := data.(IntermediateSchemaer)
-	if !ok {
-		return fmt.Errorf("cannot convert to intermediate schema: %T", data)
-	}
-	output, err := converter.ToIntermediateSchema()
-	if _, ok := err.(span.Skip); ok {
-		return nil
-	}
+	result, err := convert(data, stats, raw, recordProvenance(in, 0, 0))
 	if err != nil {
 		return err
 	}
-	return json.NewEncoder(w).Encode(output)
+	_, err = w.Write(result)
+	return err
+}
+
+// processBatch converts a shipment, the format streams the documents.
+// Malformed records are rejected, conversion continues with the next one.
+func processBatch(in input, w io.Writer, f formats.Format, stats *Stats) error {
+	noRaw := func() []byte { return nil }
+	return f.Batch(in.r, func(doc *finc.IntermediateSchema, err error) error {
+		if _, ok := err.(*formats.RecordError); ok {
+			var sid string
+			if doc != nil {
+				sid = doc.SourceID
+			}
+			return stats.Reject(sid, StageDecode, err, nil)
+		}
+		b, err := encode(doc, err, stats, noRaw, recordProvenance(in, 0, 0))
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(b)
+		return err
+	})
+}
+
+// processStream converts records one by one, as the format decoder splits
+// them. Malformed records are rejected, decoding continues with the next one.
+func processStream(in input, w io.Writer, f formats.Format, stats *Stats) error {
+	dec := f.NewDecoder(in.r)
+	for {
+		v := f.New()
+		err := dec.Decode(v)
+		if err == io.EOF {
+			return nil
+		}
+		if _, ok := err.(*formats.RecordError); ok {
+			if err := stats.Reject("", StageDecode, err, dec.Raw()); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		var line int64
+		if ld, ok := dec.(formats.LineDecoder); ok {
+			line = int64(ld.Line())
+		}
+		b, err := convert(v, stats, dec.Raw, recordProvenance(in, 0, line))
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+}
+
+// decompress returns a reader for gzip compressed input, like snapshot
+// partitions, or the input itself.
+func decompress(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(2)
+	if err != nil || magic[0] != 0x1f || magic[1] != 0x8b {
+		return br, nil
+	}
+	return gzip.NewReader(br)
+}
+
+// process dispatches on the framing of a registered format.
+func process(in input, w io.Writer, f formats.Format, stats *Stats) error {
+	switch f.Framing {
+	case formats.FramingXML:
+		return processXML(in, w, f, stats)
+	case formats.FramingNDJSON:
+		return processJSON(in, w, f, stats)
+	case formats.FramingText:
+		return processText(in, w, f, stats)
+	case formats.FramingTar:
+		return processBatch(in, w, f, stats)
+	case formats.FramingDelimited:
+		return processDelimited(in, w, f, stats)
+	case formats.FramingStream:
+		return processStream(in, w, f, stats)
+	default:
+		return fmt.Errorf("unsupported framing %v for format %s", f.Framing, f.Name)
+	}
 }
 
 func main() {
@@ -214,12 +563,7 @@ func main() {
 		defer pprof.StopCPUProfile()
 	}
 	if *list {
-		var keys []string
-		for k := range FormatMap {
-			keys = append(keys, k)
-		}
-		sort.Strings(keys)
-		for _, k := range keys {
+		for _, k := range formats.Names() {
 			fmt.Println(k)
 		}
 		os.Exit(0)
@@ -237,77 +581,144 @@ func main() {
 	}
 	w := bufio.NewWriter(os.Stdout)
 	defer w.Flush()
-	var reader io.Reader = os.Stdin
-	if flag.NArg() > 0 {
-		var files []io.Reader
+	// Inputs are processed one after another, so records can be traced back
+	// to their file.
+	var inputs []input
+	if flag.NArg() == 0 {
+		r, err := decompress(os.Stdin)
+		if err != nil {
+			log.Fatal(err)
+		}
+		inputs = append(inputs, input{r: r})
+	} else {
 		for _, filename := range flag.Args() {
 			f, err := os.Open(filename)
 			if err != nil {
 				log.Fatal(err)
 			}
 			defer f.Close()
-			files = append(files, f)
-		}
-		reader = io.MultiReader(files...)
-	}
-	switch *name {
-	// XXX: Configure this in one place.
-	case
-		"ceeol",
-		"ceeol-marcxml",
-		"dblp",
-		"degruyter",
-		"doaj-oai",
-		"genderopen",
-		"genios",
-		"hhbd",
-		"highwire",
-		"ieee",
-		"ios",
-		"jstor",
-		"mediarep-dim",
-		"olms",
-		"olms-mets",
-		"ssoar",
-		"thieme-nlm",
-		"thieme-tm",
-		"zvdd",
-		"zvdd-mets":
-		if err := processXML(reader, w, *name); err != nil {
-			log.Fatal(err)
+			r, err := decompress(f)
+			if err != nil {
+				log.Fatalf("%s: %v", filename, err)
+			}
+			inputs = append(inputs, input{name: filename, r: r})
 		}
-	case
-		"crossref",
-		"doaj",
-		"doaj-api",
-		"dummy":
-		if err := processJSON(reader, w, *name); err != nil {
+	}
+	if *name == "" {
+		log.Fatalf("input format required")
+	}
+	var (
+		f  formats.Format
+		ok bool
+	)
+	if *name == "auto" {
+		br := bufio.NewReaderSize(inputs[0].r, formats.SniffLen)
+		p, err := br.Peek(formats.SniffLen)
+		if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
 			log.Fatal(err)
 		}
-	case "imslp":
-		if err := processText(reader, w, *name); err != nil {
+		var reason string
+		if f, reason, err = formats.Detect(p); err != nil {
 			log.Fatal(err)
 		}
-	case "elsevier-tar":
-		shipment, err := elsevier.NewShipment(reader)
+		log.Printf("auto: detected %s: %s", f.Name, reason)
+		inputs[0].r = br
+	} else if f, ok = formats.Lookup(*name); !ok {
+		log.Fatalf("unknown format: %s", *name)
+	}
+	if f.RequiresMapping && *mappingFile == "" {
+		log.Fatalf("format %s requires -mapping with a source id", f.Name)
+	}
+	if *mappingFile != "" {
+		if f.Configure == nil {
+			log.Fatalf("format %s cannot be configured", f.Name)
+		}
+		mf, err := os.Open(*mappingFile)
 		if err != nil {
 			log.Fatal(err)
 		}
-		docs, err := shipment.BatchConvert()
+		if f.New, err = f.Configure(mf); err != nil {
+			log.Fatal(err)
+		}
+		mf.Close()
+	}
+	var (
+		rejects      io.Writer
+		closeRejects = func() error { return nil }
+	)
+	if *rejectsFile != "" {
+		rf, err := os.Create(*rejectsFile)
 		if err != nil {
 			log.Fatal(err)
 		}
-		encoder := json.NewEncoder(w)
-		for _, doc := range docs {
-			if encoder.Encode(doc); err != nil {
-				log.Fatal(err)
+		bw := bufio.NewWriter(rf)
+		rejects = bw
+		closeRejects = func() error {
+			if err := bw.Flush(); err != nil {
+				rf.Close()
+				return err
 			}
+			return rf.Close()
 		}
-	default:
-		if *name == "" {
-			log.Fatalf("input format required")
+	}
+	if *validate {
+		var err error
+		if validator, err = finc.NewValidator(); err != nil {
+			log.Fatal(err)
 		}
-		log.Fatalf("unknown format: %s", *name)
+	}
+	if *detectLang {
+		detector = finc.NewLanguageDetector(*langMinConf, *langSources)
+	}
+	if *addProvenance {
+		provenance = &finc.Provenance{
+			Format:  f.Name,
+			Mapping: *mappingFile,
+			Version: span.AppVersion,
+		}
+	}
+	stats := NewStats(f.Name, *maxErrors, rejects)
+	var err error
+	for _, in := range inputs {
+		if err = process(in, w, f, stats); err != nil {
+			if in.name != "" {
+				err = fmt.Errorf("%s: %w", in.name, err)
+			}
+			break
+		}
+	}
+	// The log.Fatal calls below skip deferred calls, so output and rejects
+	// are written out here, the rejects matter most for aborted runs.
+	if ferr := w.Flush(); ferr != nil && err == nil {
+		err = ferr
+	}
+	if cerr := closeRejects(); cerr != nil && err == nil {
+		err = cerr
+	}
+	// Write the summary in any case, it is most useful for aborted runs.
+	if *statsFile != "" {
+		sf, serr := os.Create(*statsFile)
+		if serr != nil {
+			log.Fatal(serr)
+		}
+		if _, serr := stats.WriteTo(sf); serr != nil {
+			log.Fatal(serr)
+		}
+		if serr := sf.Close(); serr != nil {
+			log.Fatal(serr)
+		}
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if stats.Errors > 0 {
+		log.Printf("%d record(s) rejected", stats.Errors)
+	}
+	if stats.Enriched > 0 {
+		log.Printf("%d record(s) with detected languages, see -stats for details", stats.Enriched)
+	}
+	if stats.Validation != nil && stats.Validation.Invalid > 0 {
+		log.Printf("%d record(s) with violations, see -stats or span-validate", stats.Validation.Invalid)
 	}
 	if *memProfile != "" {
 		f, err := os.Create(*memProfile)
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/miku/span"
+	"github.com/miku/span/formats"
+	"github.com/miku/span/formats/finc"
+	"github.com/segmentio/encoding/json"
+)
+
+// testRecord is a minimal record, that can be read from XML and JSON.
+type testRecord struct {
+	XMLName xml.Name `xml:"rec" json:"-"`
+	ID      string   `xml:"id,attr" json:"id"`
+	Fail    bool     `xml:"fail,attr" json:"fail"`
+}
+
+// ToIntermediateSchema fails for records marked as failing, with a partially
+// converted document.
+func (r *testRecord) ToIntermediateSchema() (*finc.IntermediateSchema, error) {
+	output := finc.NewIntermediateSchema()
+	output.SourceID = "1"
+	output.RecordID = r.ID
+	if r.Fail {
+		return output, errors.New("failing record")
+	}
+	output.ID = span.GenFincID("1", r.ID)
+	return output, nil
+}
+
+var (
+	testXMLFormat = formats.Format{
+		Name:    "test-xml",
+		Framing: formats.FramingXML,
+		New:     func() interface{} { return new(testRecord) },
+	}
+	testJSONFormat = formats.Format{
+		Name:    "test-json",
+		Framing: formats.FramingNDJSON,
+		New:     func() interface{} { return new(testRecord) },
+	}
+)
+
+// testInput returns n records as XML, the records with the given numbers
+// fail to convert.
+func testInput(n int, failing ...int) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("<collection>\n")
+	for i := 0; i < n; i++ {
+		var fail bool
+		for _, j := range failing {
+			fail = fail || i == j
+		}
+		fmt.Fprintf(&buf, "<rec id=\"%d\" fail=\"%v\"></rec>\n", i, fail)
+	}
+	buf.WriteString("</collection>\n")
+	return buf.Bytes()
+}
+
+// testInputJSON returns n records as newline delimited JSON.
+func testInputJSON(n int) []byte {
+	var buf bytes.Buffer
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&buf, "{\"id\": \"%d\"}\n", i)
+	}
+	return buf.Bytes()
+}
+
+// setFlags sets flags used by the processors and returns a function, that
+// restores them.
+func setFlags(workers, xmlBatch int, order bool) func() {
+	w, xb, o := *numWorkers, *xmlBatchSize, *preserveOrder
+	*numWorkers, *xmlBatchSize, *preserveOrder = workers, xmlBatch, order
+	return func() {
+		*numWorkers, *xmlBatchSize, *preserveOrder = w, xb, o
+	}
+}
+
+// recordIDs returns the record ids of the converted records.
+func recordIDs(t *testing.T, b []byte) (ids []string) {
+	for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+		if line == "" {
+			continue
+		}
+		var is finc.IntermediateSchema
+		if err := json.Unmarshal([]byte(line), &is); err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+		ids = append(ids, is.RecordID)
+	}
+	return ids
+}
+
+func TestProcessXML(t *testing.T) {
+	const n = 1000
+	var want []string
+	for i := 0; i < n; i++ {
+		want = append(want, fmt.Sprintf("%d", i))
+	}
+	var cases = []struct {
+		about     string
+		order     bool
+		failing   []int
+		maxErrors int
+		err       bool
+		errors    int
+	}{
+		{about: "unordered"},
+		{about: "ordered", order: true},
+		{about: "failing record", failing: []int{500}, err: true, errors: 1},
+		{about: "failing record, ordered", order: true, failing: []int{500}, err: true, errors: 1},
+		{about: "tolerated failures", failing: []int{0, 500, 999}, maxErrors: 3, errors: 3},
+		{about: "tolerated failures, ordered", order: true, failing: []int{0, 500, 999}, maxErrors: 3, errors: 3},
+	}
+	for _, c := range cases {
+		restore := setFlags(4, 7, c.order)
+		var (
+			buf   bytes.Buffer
+			stats = NewStats(testXMLFormat.Name, c.maxErrors, nil)
+			in    = input{r: bytes.NewReader(testInput(n, c.failing...))}
+		)
+		err := processXML(in, &buf, testXMLFormat, stats)
+		restore()
+		if (err != nil) != c.err {
+			t.Fatalf("%s: got %v, want error %v", c.about, err, c.err)
+		}
+		if stats.Errors != c.errors || stats.ErrorsBySource["1"] != c.errors {
+			t.Errorf("%s: got %d errors, %v by source, want %d", c.about, stats.Errors, stats.ErrorsBySource, c.errors)
+		}
+		if c.err {
+			if !strings.Contains(err.Error(), "failing record") {
+				t.Errorf("%s: got %v, want error of the failing record", c.about, err)
+			}
+			continue
+		}
+		var expected []string
+		for _, id := range want {
+			var fail bool
+			for _, j := range c.failing {
+				fail = fail || id == fmt.Sprintf("%d", j)
+			}
+			if !fail {
+				expected = append(expected, id)
+			}
+		}
+		got := recordIDs(t, buf.Bytes())
+		if !c.order {
+			// Batches are written as they complete.
+			sort.Slice(got, func(i, j int) bool { return less(got[i], got[j]) })
+		}
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("%s: got %d records, want %d in input order", c.about, len(got), len(expected))
+		}
+	}
+}
+
+// less compares numeric record ids.
+func less(a, b string) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return a < b
+}
+
+func benchmarkProcess(b *testing.B, f formats.Format, data []byte, order bool) {
+	restore := setFlags(4, 1000, order)
+	defer restore()
+	for i := 0; i < b.N; i++ {
+		in := input{r: bytes.NewReader(data)}
+		if err := process(in, io.Discard, f, NewStats(f.Name, 0, nil)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkProcessXML(b *testing.B) {
+	benchmarkProcess(b, testXMLFormat, testInput(100000), false)
+}
+
+func BenchmarkProcessXMLOrdered(b *testing.B) {
+	benchmarkProcess(b, testXMLFormat, testInput(100000), true)
+}
+
+func BenchmarkProcessJSON(b *testing.B) {
+	benchmarkProcess(b, testJSONFormat, testInputJSON(100000), false)
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/miku/span"
+	"github.com/miku/span/formats/finc"
+	"github.com/segmentio/encoding/json"
+)
+
+// Stages, where a record can fail.
+const (
+	StageDecode  = "decode"
+	StageConvert = "convert"
+	StageEncode  = "encode"
+)
+
+// Stats keeps track of converted, skipped and rejected records. Safe for
+// concurrent use.
+type Stats struct {
+	mu sync.Mutex
+
+	Format    string    `json:"format"`
+	Started   time.Time `json:"started"`
+	Finished  time.Time `json:"finished"`
+	Converted int       `json:"converted"`
+	Deleted   int       `json:"deleted"`
+	Skipped   int       `json:"skipped"`
+	Errors    int       `json:"errors"`
+	MaxErrors int       `json:"max_errors"`
+	Enriched  int       `json:"enriched"`
+	// Aborted is set, once more than MaxErrors records failed. Records,
+	// that fail after that, are not counted anymore.
+	Aborted bool `json:"aborted"`
+
+	ConvertedBySource map[string]int `json:"converted_by_source"`
+	SkipsByReason     map[string]int `json:"skips_by_reason"`
+	SkipsBySource     map[string]int `json:"skips_by_source"`
+	ErrorsByStage     map[string]int `json:"errors_by_stage"`
+	ErrorsBySource    map[string]int `json:"errors_by_source"`
+	EnrichedBySource  map[string]int `json:"enriched_by_source"`
+
+	// Validation is only set with -validate.
+	Validation *finc.Report `json:"validation,omitempty"`
+
+	rejects io.Writer
+}
+
+// Reject is a record, that could not be converted.
+type Reject struct {
+	Format string `json:"format"`
+	Stage  string `json:"stage"`
+	Error  string `json:"error"`
+	Record string `json:"record,omitempty"`
+}
+
+// NewStats sets up accounting for a given format. Up to maxErrors failed
+// records are tolerated and written to rejects, which may be nil.
+func NewStats(format string, maxErrors int, rejects io.Writer) *Stats {
+	return &Stats{
+		Format:            format,
+		Started:           time.Now(),
+		MaxErrors:         maxErrors,
+		ConvertedBySource: make(map[string]int),
+		SkipsByReason:     make(map[string]int),
+		SkipsBySource:     make(map[string]int),
+		ErrorsByStage:     make(map[string]int),
+		ErrorsBySource:    make(map[string]int),
+		EnrichedBySource:  make(map[string]int),
+		rejects:           rejects,
+	}
+}
+
+// Convert records a successfully converted record.
+func (s *Stats) Convert(sid string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Converted++
+	s.ConvertedBySource[sourceKey(sid)]++
+}
+
+// Delete records a tombstone, which counts as converted, too.
+func (s *Stats) Delete(sid string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Converted++
+	s.Deleted++
+	s.ConvertedBySource[sourceKey(sid)]++
+}
+
+// Skip records a skipped record. The source id may be empty, if the format
+// did not produce an output.
+func (s *Stats) Skip(sid string, skip span.Skip) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Skipped++
+	code := skip.Code
+	if code == "" {
+		code = span.SkipUnknown
+	}
+	s.SkipsByReason[string(code)]++
+	s.SkipsBySource[sourceKey(sid)]++
+}
+
+// Enrich records a converted record, whose languages were detected.
+func (s *Stats) Enrich(sid string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Enriched++
+	s.EnrichedBySource[sourceKey(sid)]++
+}
+
+// Validate records the violations of a converted record.
+func (s *Stats) Validate(violations []finc.Violation) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Validation == nil {
+		s.Validation = &finc.Report{}
+	}
+	s.Validation.Add(violations)
+}
+
+// Reject records a failed record and writes it to the rejects file. It
+// returns the original error, if the number of tolerated errors is exceeded;
+// the record, that exceeds it, is still written, later ones are dropped, as
+// the run is aborted. The source id is empty for records, that could not be
+// decoded.
+func (s *Stats) Reject(sid, stage string, err error, record []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Aborted {
+		return fmt.Errorf("%s: %w", stage, err)
+	}
+	s.Errors++
+	s.ErrorsByStage[stage]++
+	s.ErrorsBySource[sourceKey(sid)]++
+	var result error
+	if s.Errors > s.MaxErrors {
+		s.Aborted = true
+		result = fmt.Errorf("%s: %w", stage, err)
+	}
+	if s.rejects == nil {
+		return result
+	}
+	b, merr := json.Marshal(Reject{
+		Format: s.Format,
+		Stage:  stage,
+		Error:  err.Error(),
+		Record: string(record),
+	})
+	if merr != nil {
+		return merr
+	}
+	b = append(b, '\n')
+	if _, werr := s.rejects.Write(b); werr != nil {
+		return werr
+	}
+	return result
+}
+
+// WriteTo writes the summary as JSON.
+func (s *Stats) WriteTo(w io.Writer) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Finished = time.Now()
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	b = append(b, '\n')
+	n, err := w.Write(b)
+	return int64(n), err
+}
+
+// sourceKey groups records without source id.
+func sourceKey(sid string) string {
+	if sid == "" {
+		return "unknown"
+	}
+	return sid
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/segmentio/encoding/json"
+)
+
+func TestStatsReject(t *testing.T) {
+	var (
+		rejects bytes.Buffer
+		stats   = NewStats("test", 2, &rejects)
+		failed  = errors.New("failed")
+	)
+	if err := stats.Reject("", StageDecode, failed, []byte("<x>")); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if err := stats.Reject("49", StageConvert, failed, nil); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if err := stats.Reject("49", StageConvert, failed, nil); !errors.Is(err, failed) {
+		t.Fatalf("got %v, want %v", err, failed)
+	}
+	// Records failing after the abort are not counted.
+	if err := stats.Reject("49", StageConvert, failed, nil); !errors.Is(err, failed) {
+		t.Fatalf("got %v, want %v", err, failed)
+	}
+	var buf bytes.Buffer
+	if _, err := stats.WriteTo(&buf); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	var summary struct {
+		Errors         int            `json:"errors"`
+		Aborted        bool           `json:"aborted"`
+		ErrorsByStage  map[string]int `json:"errors_by_stage"`
+		ErrorsBySource map[string]int `json:"errors_by_source"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &summary); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if summary.Errors != 3 || !summary.Aborted {
+		t.Errorf("got %d errors, aborted %v, want 3, aborted", summary.Errors, summary.Aborted)
+	}
+	if want := map[string]int{"decode": 1, "convert": 2}; !reflect.DeepEqual(summary.ErrorsByStage, want) {
+		t.Errorf("got %v, want %v", summary.ErrorsByStage, want)
+	}
+	if want := map[string]int{"unknown": 1, "49": 2}; !reflect.DeepEqual(summary.ErrorsBySource, want) {
+		t.Errorf("got %v, want %v", summary.ErrorsBySource, want)
+	}
+	// The record exceeding the limit is kept, too.
+	if n := bytes.Count(rejects.Bytes(), []byte("\n")); n != 3 {
+		t.Errorf("got %d rejects, want 3", n)
+	}
+}
@@ -0,0 +1,80 @@
+// Migrate intermediate schema files between versions, e.g. from 0.9 to 1.0
+// or back. Documents without version are taken as 0.9.
+//
+//	$ span-migrate -to 1.0 file.is > file-1.0.is
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/segmentio/encoding/json"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/miku/span"
+	"github.com/miku/span/formats/finc"
+	"github.com/miku/span/parallel"
+)
+
+func main() {
+	showVersion := flag.Bool("v", false, "prints current program version")
+	to := flag.String("to", finc.Version10, fmt.Sprintf("target version, one of: %s",
+		strings.Join(finc.Versions, ", ")))
+	size := flag.Int("b", 20000, "batch size")
+	numWorkers := flag.Int("w", runtime.NumCPU(), "number of workers")
+
+	flag.Parse()
+
+	if *showVersion {
+		fmt.Println(span.AppVersion)
+		os.Exit(0)
+	}
+
+	var reader io.Reader = os.Stdin
+
+	if flag.NArg() > 0 {
+		var files []io.Reader
+		for _, filename := range flag.Args() {
+			f, err := os.Open(filename)
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer f.Close()
+			files = append(files, f)
+		}
+		reader = io.MultiReader(files...)
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+
+	p := parallel.NewProcessor(bufio.NewReader(reader), w, func(_ int64, b []byte) ([]byte, error) {
+		is := finc.IntermediateSchema{}
+
+		if err := json.Unmarshal(b, &finc.Versioned{IntermediateSchema: &is}); err != nil {
+			log.Printf("failed to unmarshal: %s", string(b))
+			return b, err
+		}
+		if err := finc.Migrate(&is, *to); err != nil {
+			return nil, fmt.Errorf("%s: %w", is.RecordID, err)
+		}
+		bb, err := json.Marshal(finc.Versioned{IntermediateSchema: &is})
+		if err != nil {
+			return bb, err
+		}
+		bb = append(bb, '\n')
+		return bb, nil
+	})
+
+	p.NumWorkers = *numWorkers
+	p.BatchSize = *size
+
+	if err := p.Run(); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,225 @@
+// span-oai-harvest harvests raw records from an OAI-PMH endpoint, in windows
+// of a day, week or month, and caches each window in a compressed file.
+// Records are written as a stream of record elements, each carrying the
+// namespaces declared in the response, ready for span-import, e.g. with "-i
+// auto" or an explicit OAI based format.
+//
+// Example usage:
+//
+//	$ span-oai-harvest \
+//	      -u https://oai.example.org/oai \  # endpoint
+//	      -prefix marc21 \                  # metadata prefix
+//	      -set journals \                   # set (optional)
+//	      -i m \                            # interval (monthly)
+//	      -s 2023-01-01 \                   # start
+//	      -e 2023-12-31 \                   # end (leave out for default: yesterday)
+//	      -c /data/finc/oai/ | span-import -i marcxml -mapping source.json
+//
+// Cached windows live under a directory derived from endpoint, prefix and set,
+// so different harvests do not clash. Windows, that are already cached, are
+// not requested again; remove a file to harvest a window again.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/miku/span"
+	"github.com/miku/span/atomic"
+	"github.com/miku/span/dateutil"
+	"github.com/miku/span/oai"
+	"github.com/miku/span/xflag"
+
+	gzip "github.com/klauspost/pgzip"
+)
+
+var (
+	cacheDir       = flag.String("c", path.Join(xdg.CacheHome, "span/oai-harvest"), "cache directory")
+	endpoint       = flag.String("u", "", "OAI-PMH endpoint URL")
+	metadataPrefix = flag.String("prefix", "oai_dc", "metadata prefix")
+	set            = flag.String("set", "", "set spec (optional)")
+	granularity    = flag.String("g", "day", "datestamp granularity: day or second")
+	userAgent      = flag.String("ua", fmt.Sprintf("span-oai-harvest/%s (https://github.com/miku/span)", span.AppVersion), "user agent string")
+	intervals      = flag.String("i", "m", "intervals: d=daily, w=weekly, m=monthly")
+	timeout        = flag.Duration("t", 60*time.Second, "connection timeout")
+	maxRetries     = flag.Int("x", 10, "max retries")
+	backoff        = flag.Duration("backoff", 2*time.Second, "initial wait time between retries, doubled on each retry")
+	skipDeleted    = flag.Bool("skip-deleted", false, "do not write records with deleted status")
+	verbose        = flag.Bool("verbose", false, "be verbose")
+	debug          = flag.Bool("debug", false, "print out intervals")
+	outputFile     = flag.String("o", "", "output filename (stdout, otherwise)")
+	quiet          = flag.Bool("q", false, "do not emit any output, do not write to a file, just sync")
+
+	harvestStart xflag.Date = xflag.Date{Time: dateutil.MustParse("2000-01-01")}
+	harvestEnd   xflag.Date = xflag.Date{Time: time.Now().Add(-24 * time.Hour)}
+
+	unsafeChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+)
+
+// cacheSubdir derives a directory name from endpoint, prefix and set, e.g.
+// "oai.example.org-oai/marc21/journals".
+func cacheSubdir(endpoint, prefix, set string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("invalid endpoint: %s", endpoint)
+	}
+	name := strings.Trim(unsafeChars.ReplaceAllString(u.Host+u.Path, "-"), "-")
+	if set == "" {
+		set = "all"
+	}
+	return path.Join(name,
+		unsafeChars.ReplaceAllString(prefix, "-"),
+		unsafeChars.ReplaceAllString(set, "-")), nil
+}
+
+func cleanup(dir string) error {
+	return filepath.Walk(dir, func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !strings.Contains(path, "-tmp-") {
+			return nil
+		}
+		return os.Remove(path)
+	})
+}
+
+func main() {
+	flag.Var(&harvestStart, "s", "start date for harvest")
+	flag.Var(&harvestEnd, "e", "end date for harvest")
+	flag.Parse()
+	var ivs []dateutil.Interval
+	switch *intervals {
+	case "d", "D", "daily":
+		ivs = dateutil.Daily(harvestStart.Time, harvestEnd.Time)
+	case "w", "W", "weekly":
+		ivs = dateutil.Weekly(harvestStart.Time, harvestEnd.Time)
+	case "m", "M", "monthly":
+		ivs = dateutil.Monthly(harvestStart.Time, harvestEnd.Time)
+	default:
+		log.Fatal("invalid interval")
+	}
+	if *debug {
+		for _, iv := range ivs {
+			fmt.Println(iv)
+		}
+		os.Exit(0)
+	}
+	if *endpoint == "" {
+		log.Fatal("endpoint required, use -u")
+	}
+	subdir, err := cacheSubdir(*endpoint, *metadataPrefix, *set)
+	if err != nil {
+		log.Fatal(err)
+	}
+	dir := path.Join(*cacheDir, subdir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Fatalf("mkdir: %v", err)
+	}
+	harvester := &oai.Harvester{
+		Endpoint:       *endpoint,
+		MetadataPrefix: *metadataPrefix,
+		Set:            *set,
+		UserAgent:      *userAgent,
+		Client:         &http.Client{Timeout: *timeout},
+		MaxRetries:     *maxRetries,
+		Backoff:        *backoff,
+		SkipDeleted:    *skipDeleted,
+		Verbose:        *verbose,
+	}
+	switch *granularity {
+	case "day":
+		harvester.Granularity = oai.DefaultGranularity
+	case "second":
+		harvester.Granularity = "2006-01-02T15:04:05Z"
+	default:
+		log.Fatalf("invalid granularity: %s", *granularity)
+	}
+	var w io.Writer = os.Stdout
+	if *outputFile != "" {
+		f, err := atomic.New(*outputFile, 0644)
+		if err != nil {
+			log.Fatalf("file: %v", err)
+		}
+		defer f.Close()
+		w = f
+	}
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt)
+	go func() {
+		<-c
+		if err := cleanup(dir); err != nil {
+			log.Fatalf("cleanup: %v", err)
+		}
+		os.Exit(1)
+	}()
+	for _, iv := range ivs {
+		cachePath := path.Join(dir, fmt.Sprintf("%s-%s.xml.gz",
+			iv.Start.Format("2006-01-02"),
+			iv.End.Format("2006-01-02")))
+		if *verbose {
+			log.Printf("cache path: %v", cachePath)
+		}
+		if _, err := os.Stat(cachePath); os.IsNotExist(err) {
+			cacheFile, err := atomic.New(cachePath, 0644)
+			if err != nil {
+				log.Fatal(err)
+			}
+			stats, err := harvester.Harvest(cacheFile, iv.Start, iv.End)
+			if err != nil {
+				cacheFile.Abort()
+				log.Fatal(err)
+			}
+			if err := cacheFile.Close(); err != nil {
+				log.Fatal(err)
+			}
+			compressed, err := atomic.CompressType(cachePath, "gzip")
+			if err != nil {
+				log.Fatal(err)
+			}
+			if err := atomic.Move(compressed, cachePath); err != nil {
+				log.Fatal(err)
+			}
+			log.Printf("harvested %d records (%d deleted) in %d requests to %s",
+				stats.Records, stats.Deleted, stats.Requests, cachePath)
+		} else {
+			log.Printf("already harvested: %s", cachePath)
+		}
+		if *quiet {
+			continue
+		}
+		f, err := os.Open(cachePath)
+		if err != nil {
+			log.Fatalf("open: %v", err)
+		}
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			log.Fatalf("gzip: %v", err)
+		}
+		if _, err := io.Copy(w, zr); err != nil {
+			log.Fatalf("copy: %v", err)
+		}
+		if err := zr.Close(); err != nil {
+			log.Fatalf("gzip close: %v", err)
+		}
+		if err := f.Close(); err != nil {
+			log.Fatalf("close: %v", err)
+		}
+	}
+}
@@ -209,10 +209,13 @@ func main() {
 		if err := json.Unmarshal(b, &is); err != nil {
 			return b, err
 		}
+		if err := is.SetVersionDate(); err != nil {
+			return b, err
+		}
 		tagged := tagger.Tag(is)
 		// We can save some space in the index, when we drop records w/o any
-		// isil attached.
-		if *dropDangling && len(tagged.Labels) == 0 {
+		// isil attached. Tombstones never have labels, but must reach export.
+		if *dropDangling && len(tagged.Labels) == 0 && !tagged.Deleted {
 			return nil, nil
 		}
 		// Deduplicate against a SOLR.
@@ -230,7 +233,7 @@ func main() {
 				}
 			}
 		}
-		bb, err := json.Marshal(tagged)
+		bb, err := finc.Marshal(&tagged)
 		if err != nil {
 			return bb, err
 		}
@@ -0,0 +1,116 @@
+// Validate intermediate schema files against the JSON schema of their version
+// and a few semantic rules, like ISSN check digits, ISO 639-3 languages or
+// finc.id derivation. Documents in version 0.9 are checked as 1.0, since the
+// released 0.9 schema does not list all fields span writes. Violations are
+// written per record as newline delimited JSON, followed by an aggregate
+// report on stderr. Exits with status 1, if any record is invalid.
+//
+//	$ span-validate file.is
+//	{"line":12,"finc.id":"ai-1-...","violations":[{"rule":"issn","field":"rft.issn[0]",...}]}
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sync"
+
+	"github.com/segmentio/encoding/json"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/miku/span"
+	"github.com/miku/span/formats/finc"
+	"github.com/miku/span/parallel"
+
+	// Formats register, which values their finc.id is derived from.
+	_ "github.com/miku/span/formats/all"
+)
+
+// Result lists the violations of a single record.
+type Result struct {
+	Line       int64            `json:"line"`
+	ID         string           `json:"finc.id,omitempty"`
+	RecordID   string           `json:"finc.record_id,omitempty"`
+	Violations []finc.Violation `json:"violations"`
+}
+
+func main() {
+	showVersion := flag.Bool("v", false, "prints current program version")
+	quiet := flag.Bool("q", false, "only write the aggregate report")
+	size := flag.Int("b", 20000, "batch size")
+	numWorkers := flag.Int("w", runtime.NumCPU(), "number of workers")
+
+	flag.Parse()
+
+	if *showVersion {
+		fmt.Println(span.AppVersion)
+		os.Exit(0)
+	}
+
+	var reader io.Reader = os.Stdin
+
+	if flag.NArg() > 0 {
+		var files []io.Reader
+		for _, filename := range flag.Args() {
+			f, err := os.Open(filename)
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer f.Close()
+			files = append(files, f)
+		}
+		reader = io.MultiReader(files...)
+	}
+
+	validator, err := finc.NewValidator()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var (
+		mu     sync.Mutex
+		report finc.Report
+		w      = bufio.NewWriter(os.Stdout)
+	)
+
+	p := parallel.NewProcessor(bufio.NewReader(reader), w, func(lineno int64, b []byte) ([]byte, error) {
+		violations := validator.Validate(b)
+		mu.Lock()
+		report.Add(violations)
+		mu.Unlock()
+		if len(violations) == 0 || *quiet {
+			return nil, nil
+		}
+		result := Result{Line: lineno + 1, Violations: violations}
+		// Identifiers are best effort, the record might not even be JSON.
+		_ = json.Unmarshal(b, &result)
+		result.Violations = violations
+		bb, err := json.Marshal(result)
+		if err != nil {
+			return nil, err
+		}
+		bb = append(bb, '\n')
+		return bb, nil
+	})
+
+	p.NumWorkers = *numWorkers
+	p.BatchSize = *size
+
+	if err := p.Run(); err != nil {
+		log.Fatal(err)
+	}
+	if err := w.Flush(); err != nil {
+		log.Fatal(err)
+	}
+	enc := json.NewEncoder(os.Stderr)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		log.Fatal(err)
+	}
+	if report.Invalid > 0 {
+		os.Exit(1)
+	}
+}
@@ -25,6 +25,7 @@ package span
 import (
 	"encoding/base64"
 	"fmt"
+	"strings"
 )
 
 const (
@@ -36,14 +37,65 @@ const (
 	KeyLengthLimit = 250
 )
 
-// Skip marks records to skip.
+// SkipCode classifies the reason for skipping a record, so skipped records can
+// be grouped and counted.
+type SkipCode string
+
+// Skip codes used across formats.
+const (
+	SkipUnknown               SkipCode = "UNKNOWN"
+	SkipIDTooLong             SkipCode = "ID_TOO_LONG"
+	SkipMissingID             SkipCode = "NO_ID"
+	SkipMissingDOI            SkipCode = "NO_DOI"
+	SkipMissingDate           SkipCode = "NO_DATE"
+	SkipInvalidDate           SkipCode = "INVALID_DATE"
+	SkipTooFuturistic         SkipCode = "TOO_FUTURISTIC"
+	SkipJournalIssue          SkipCode = "JOURNAL_ISSUE"
+	SkipMissingTitle          SkipCode = "NO_TITLE"
+	SkipMissingArticleTitle   SkipCode = "NO_ATITLE"
+	SkipBlockedArticleTitle   SkipCode = "BLOCKED_ATITLE"
+	SkipTitleTooLong          SkipCode = "TOO_LONG_TITLE"
+	SkipMissingJournalTitle   SkipCode = "NO_JTITLE"
+	SkipMissingPublisher      SkipCode = "NO_PUBLISHER"
+	SkipMissingArticle        SkipCode = "NO_ARTICLE"
+	SkipBlacklistedCollection SkipCode = "BLACKLISTED_COLLECTION"
+	SkipSuppressedFormat      SkipCode = "SUPPRESSED_FORMAT"
+	SkipExtraContent          SkipCode = "EXTRA_CONTENT"
+	SkipEmbargo               SkipCode = "EMBARGO"
+	SkipMissingIssue          SkipCode = "NO_ISSUE"
+	SkipMissingDataset        SkipCode = "NO_DATASET"
+)
+
+// Skip marks records to skip. Code is used for grouping, the other fields
+// are optional context.
 type Skip struct {
-	Reason string
+	Code     SkipCode
+	RecordID string // identifier of the offending record, if known
+	Field    string // name of the offending field, e.g. "date"
+	Value    string // offending value
+	Reason   string // free-form details
 }
 
-// Error returns the reason for skipping.
+// Error returns the reason for skipping, e.g. "[skip] NO_DATE record=123
+// field=date value=\"\"".
 func (s Skip) Error() string {
-	return fmt.Sprintf("[skip] %s", s.Reason)
+	var buf strings.Builder
+	buf.WriteString("[skip] ")
+	if s.Code == "" {
+		buf.WriteString(string(SkipUnknown))
+	} else {
+		buf.WriteString(string(s.Code))
+	}
+	if s.RecordID != "" {
+		fmt.Fprintf(&buf, " record=%s", s.RecordID)
+	}
+	if s.Field != "" {
+		fmt.Fprintf(&buf, " field=%s value=%q", s.Field, s.Value)
+	}
+	if s.Reason != "" {
+		fmt.Fprintf(&buf, ": %s", s.Reason)
+	}
+	return buf.String()
 }
 
 // GenFincID returns a finc.id string consisting of an arbitraty prefix (e.g.
@@ -20,3 +20,24 @@ func TestGenFincID(t *testing.T) {
 		}
 	}
 }
+
+func TestSkipError(t *testing.T) {
+	var cases = []struct {
+		skip     Skip
+		expected string
+	}{
+		{Skip{}, "[skip] UNKNOWN"},
+		{Skip{Reason: "legacy"}, "[skip] UNKNOWN: legacy"},
+		{Skip{Code: SkipIDTooLong, RecordID: "ai-49-x"}, "[skip] ID_TOO_LONG record=ai-49-x"},
+		{
+			Skip{Code: SkipInvalidDate, RecordID: "1", Field: "date", Value: "19xx", Reason: "cannot parse"},
+			`[skip] INVALID_DATE record=1 field=date value="19xx": cannot parse`,
+		},
+	}
+	for _, c := range cases {
+		result := c.skip.Error()
+		if result != c.expected {
+			t.Errorf("want %v, got %v", c.expected, result)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+// Package all registers all input formats. Import it for side effects:
+//
+//	import _ "github.com/miku/span/formats/all"
+package all
+
+import (
+	_ "github.com/miku/span/formats/bibtex"
+	_ "github.com/miku/span/formats/ceeol"
+	_ "github.com/miku/span/formats/crossref"
+	_ "github.com/miku/span/formats/crosswalk"
+	_ "github.com/miku/span/formats/datacite"
+	_ "github.com/miku/span/formats/dblp"
+	_ "github.com/miku/span/formats/degruyter"
+	_ "github.com/miku/span/formats/doaj"
+	_ "github.com/miku/span/formats/dummy"
+	_ "github.com/miku/span/formats/elsevier"
+	_ "github.com/miku/span/formats/genderopen"
+	_ "github.com/miku/span/formats/genios"
+	_ "github.com/miku/span/formats/hhbd"
+	_ "github.com/miku/span/formats/highwire"
+	_ "github.com/miku/span/formats/ieee"
+	_ "github.com/miku/span/formats/imslp"
+	_ "github.com/miku/span/formats/ios"
+	_ "github.com/miku/span/formats/jats"
+	_ "github.com/miku/span/formats/jstor"
+	_ "github.com/miku/span/formats/marc"
+	_ "github.com/miku/span/formats/mediarep"
+	_ "github.com/miku/span/formats/olms"
+	_ "github.com/miku/span/formats/openalex"
+	_ "github.com/miku/span/formats/pubmed"
+	_ "github.com/miku/span/formats/ris"
+	_ "github.com/miku/span/formats/ssoar"
+	_ "github.com/miku/span/formats/thieme"
+	_ "github.com/miku/span/formats/zvdd"
+)
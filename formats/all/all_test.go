@@ -0,0 +1,204 @@
+package all
+
+import (
+	"encoding"
+	"testing"
+
+	"github.com/miku/span/formats"
+)
+
+func TestRegisteredFormats(t *testing.T) {
+	if len(formats.Names()) == 0 {
+		t.Fatalf("no formats registered")
+	}
+	for _, f := range formats.All() {
+		switch f.Framing {
+		case formats.FramingTar:
+			if f.Batch == nil {
+				t.Errorf("%s: missing batch function", f.Name)
+			}
+		case formats.FramingXML, formats.FramingNDJSON, formats.FramingText, formats.FramingDelimited, formats.FramingStream:
+			v := f.New()
+			_, ok := v.(formats.IntermediateSchemaer)
+			if _, list := v.(formats.IntermediateSchemaLister); !ok && !list {
+				t.Errorf("%s: cannot convert to intermediate schema: %T", f.Name, v)
+			}
+			if f.Framing == formats.FramingText {
+				if _, ok := v.(encoding.TextUnmarshaler); !ok {
+					t.Errorf("%s: cannot unmarshal text: %T", f.Name, v)
+				}
+			}
+			if f.Framing == formats.FramingDelimited {
+				if _, ok := v.(encoding.BinaryUnmarshaler); !ok {
+					t.Errorf("%s: cannot unmarshal binary: %T", f.Name, v)
+				}
+				if f.Separator == 0 {
+					t.Errorf("%s: missing separator", f.Name)
+				}
+			}
+			if f.Framing == formats.FramingStream && f.NewDecoder == nil {
+				t.Errorf("%s: missing decoder", f.Name)
+			}
+		default:
+			t.Errorf("%s: unknown framing: %v", f.Name, f.Framing)
+		}
+	}
+}
+
+func TestDetect(t *testing.T) {
+	var cases = []struct {
+		about  string
+		input  string
+		result string
+		err    bool
+	}{
+		{
+			about:  "crossref api document",
+			input:  `{"publisher": "Nature", "DOI": "10.1038/jid.2009.293", "member": "339"}`,
+			result: "crossref",
+		},
+		{
+			about:  "doaj api article",
+			input:  `{"bibjson": {"title": "Hello"}, "id": "123"}`,
+			result: "doaj",
+		},
+		{
+			about:  "genios",
+			input:  `<GENIOS Profile="x"><Document ID="1" DB="XZWF"><Title>Hello</Title></Document></GENIOS>`,
+			result: "genios",
+		},
+		{
+			about: "truncated mediarep dim",
+			input: `<OAI-PMH xmlns="http://www.openarchives.org/OAI/2.0/"><ListRecords><record>
+				<header><identifier>oai:localhost:doc/2019</identifier></header>
+				<metadata><dim:dim xmlns:dim="http://www.dspace.org/xmlns/dspace/dim"><dim:field>Hel`,
+			result: "mediarep-dim",
+		},
+		{
+			about:  "generic jats article",
+			input:  `<article dtd-version="1.2"><front><journal-meta><journal-id journal-id-type="publisher-id">x</journal-id></journal-meta></front></article>`,
+			result: "jats",
+		},
+		{
+			about:  "jstor article",
+			input:  `<article><front><journal-meta><journal-id journal-id-type="jstor">x</journal-id></journal-meta></front></article>`,
+			result: "jstor",
+		},
+		{
+			about:  "ris",
+			input:  "\ufeffTY  - JOUR\r\nTI  - Hello\r\nER  - \r\n",
+			result: "ris",
+		},
+		{
+			about:  "bibtex",
+			input:  "% exported\n@string{acm = \"ACM\"}\n@Article{doe2019,\n  title = {Hello}\n}",
+			result: "bibtex",
+		},
+		{
+			about:  "datacite api",
+			input:  `{"id": "10.5281/zenodo.1", "type": "dois", "attributes": {"doi": "10.5281/zenodo.1"}}`,
+			result: "datacite",
+		},
+		{
+			about:  "datacite xml",
+			input:  `<resource xmlns="http://datacite.org/schema/kernel-4"><identifier identifierType="DOI">10.5281/zenodo.1</identifier></resource>`,
+			result: "datacite-xml",
+		},
+		{
+			about:  "openalex work",
+			input:  `{"id": "https://openalex.org/W2741809807", "doi": "https://doi.org/10.7717/peerj.4375", "title": "The state of OA"}`,
+			result: "openalex",
+		},
+		{
+			about:  "ceeol article",
+			input:  `<Articles><Article><UniqueID>1</UniqueID><ArticleTitle>Hello</ArticleTitle></Article></Articles>`,
+			result: "ceeol",
+		},
+		{
+			about:  "pubmed update file",
+			input:  `<?xml version="1.0" ?><!DOCTYPE PubmedArticleSet PUBLIC "-//NLM//DTD PubMedArticle, 1st January 2024//EN" "https://dtd.nlm.nih.gov/ncbi/pubmed/out/pubmed_240101.dtd"><PubmedArticleSet><PubmedArticle><MedlineCitation><PMID>1</PMID><Article><ArticleTitle>Hello</ArticleTitle></Article>`,
+			result: "pubmed",
+		},
+		{
+			about: "mods in oai envelope",
+			input: `<record><header><identifier>oai:example.org:1</identifier></header>
+				<metadata><mods xmlns="http://www.loc.gov/mods/v3"><titleInfo><title>Hello</title></titleInfo></mods></metadata></record>`,
+			result: "mods",
+		},
+		{
+			about: "oai_dc from unknown repository",
+			input: `<record><header><identifier>oai:example.org:1</identifier></header>
+				<metadata><oai_dc:dc xmlns:oai_dc="http://www.openarchives.org/OAI/2.0/oai_dc/"><dc:title>Hello</dc:title></oai_dc:dc></metadata></record>`,
+			result: "oai_dc",
+		},
+		{
+			about: "thieme article in oai envelope",
+			input: `<record><header><identifier>10.1055-s-0029-1195170</identifier></header>
+				<metadata><article><front><journal-meta><journal-id>10.1055/s-00000011</journal-id></journal-meta></front></article></metadata></record>`,
+			result: "thieme-nlm",
+		},
+		{
+			about: "jats article citing a thieme doi",
+			input: `<record><header><identifier>oai:example.org:1</identifier></header>
+				<metadata><article><front><journal-meta><journal-id>x</journal-id></journal-meta></front>
+				<back><ref-list><ref><pub-id pub-id-type="doi">10.1055/s-0029-1195170</pub-id></ref></ref-list></back></article></metadata></record>`,
+			result: "jats",
+		},
+		{
+			about: "ssoar marcxml",
+			input: `<record><metadata><marc:record xmlns:marc="http://www.loc.gov/MARC21/slim">
+				<marc:datafield tag="856" ind1="4" ind2="0"><marc:subfield code="u">http://www.ssoar.info/ssoar/handle/document/1</marc:subfield></marc:datafield></marc:record></metadata></record>`,
+			result: "ssoar",
+		},
+		{
+			about: "ceeol marcxml",
+			input: `<marc:record xmlns:marc="http://www.loc.gov/MARC21/slim">
+				<marc:datafield tag="856" ind1="4" ind2="0"><marc:subfield code="u">https://www.ceeol.com/search/book-detail?id=279462</marc:subfield></marc:datafield></marc:record>`,
+			result: "ceeol-marcxml",
+		},
+		{
+			about: "marcxml mentioning ssoar and ceeol",
+			input: `<marc:record xmlns:marc="http://www.loc.gov/MARC21/slim">
+				<marc:datafield tag="245" ind1="1" ind2="0"><marc:subfield code="a">Open access in SSOAR and CEEOL</marc:subfield></marc:datafield>
+				<marc:datafield tag="500" ind1=" " ind2=" "><marc:subfield code="a">Also in ssoar, see ceeol</marc:subfield></marc:datafield>
+				<marc:datafield tag="856" ind1="4" ind2="0"><marc:subfield code="u">https://example.org/ssoar/ceeol</marc:subfield></marc:datafield></marc:record>`,
+			result: "marcxml",
+		},
+		{
+			about: "plain text",
+			input: `Hello World`,
+			err:   true,
+		},
+		{
+			about: "json without known keys",
+			input: `{"title": "Sample Title"}`,
+			err:   true,
+		},
+	}
+	for _, c := range cases {
+		f, _, err := formats.Detect([]byte(c.input))
+		if c.err {
+			if err == nil {
+				t.Errorf("%s: expected error, got %s", c.about, f.Name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: got %v, want %s", c.about, err, c.result)
+			continue
+		}
+		if f.Name != c.result {
+			t.Errorf("%s: got %s, want %s", c.about, f.Name, c.result)
+		}
+	}
+}
+
+func TestDetectAmbiguous(t *testing.T) {
+	input := `<record><header><identifier>oai:doaj.org/article:1</identifier></header>
+		<metadata><oai_dc:dc xmlns:oai_dc="http://www.openarchives.org/OAI/2.0/oai_dc/">
+		<dc:identifier>http://www.olmsonline.de/1</dc:identifier></oai_dc:dc></metadata></record>`
+	_, _, err := formats.Detect([]byte(input))
+	if _, ok := err.(formats.AmbiguousError); !ok {
+		t.Fatalf("got %v, want AmbiguousError", err)
+	}
+}
@@ -0,0 +1,432 @@
+package all
+
+import (
+	"bufio"
+	"bytes"
+	"encoding"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/miku/span"
+	"github.com/miku/span/formats"
+	"github.com/miku/span/formats/finc"
+	"github.com/segmentio/encoding/json"
+	"golang.org/x/net/html/charset"
+)
+
+// Golden files live in fixtures/<format>/: each NAME.input is converted with
+// the format and compared to NAME.is.json, a JSON array of the resulting
+// documents, skipped records are left out. An optional NAME.mapping is passed
+// to the format as configuration, like span-import -mapping; it is required
+// for formats, that need a source id. Identifiers of the converted documents
+// are validated. A format without fixtures fails the test, unless listed in
+// noGolden. To regenerate the golden files after an intended change, run:
+//
+//	$ go test ./formats/all -run TestGolden -update
+//
+// and review the changes with git diff.
+var update = flag.Bool("update", false, "regenerate golden files in fixtures")
+
+// fixturesDir is relative to this package.
+const fixturesDir = "../../fixtures"
+
+// noGolden lists formats without golden files and why. Every other format
+// needs at least one fixture.
+var noGolden = map[string]string{
+	"dummy": "example format, documents have no identifiers",
+}
+
+func TestGolden(t *testing.T) {
+	for _, f := range formats.All() {
+		f := f
+		t.Run(f.Name, func(t *testing.T) {
+			inputs, err := filepath.Glob(filepath.Join(fixturesDir, f.Name, "*.input"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reason, ok := noGolden[f.Name]; ok {
+				t.Skip(reason)
+			}
+			if len(inputs) == 0 {
+				t.Fatalf("no fixtures in %s", filepath.Join(fixturesDir, f.Name))
+			}
+			for _, input := range inputs {
+				testGolden(t, f, input)
+			}
+		})
+	}
+}
+
+// testGolden converts a single input and compares it to its golden file.
+func testGolden(t *testing.T, f formats.Format, input string) {
+	var (
+		base   = strings.TrimSuffix(input, ".input")
+		name   = filepath.Base(base)
+		golden = base + ".is.json"
+	)
+	if mf, err := os.Open(base + ".mapping"); err == nil {
+		defer mf.Close()
+		if f.Configure == nil {
+			t.Fatalf("%s: format cannot be configured", name)
+		}
+		if f.New, err = f.Configure(mf); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	} else if f.RequiresMapping {
+		t.Fatalf("%s: format requires a mapping with a source id: %v", name, err)
+	}
+	r, err := os.Open(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	docs, err := convertAll(f, r)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	validateIDs(t, f, name, docs)
+	got, err := json.MarshalIndent(docs, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *update {
+		if err := os.WriteFile(golden, append(got, '\n'), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("%s: %v, run with -update to create golden file", name, err)
+	}
+	diffs, err := diffDocuments(got, want)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	for _, d := range diffs {
+		t.Errorf("%s: %s", name, d)
+	}
+}
+
+// legacyIDs lists formats, whose finc.id predates the derivation from source
+// id and a registered key, see finc.RegisterIDKey.
+var legacyIDs = map[string]bool{
+	"ceeol":         true,
+	"ceeol-marcxml": true,
+	"doaj":          true,
+	"doaj-api":      true,
+	"doaj-legacy":   true,
+	"doaj-oai":      true,
+	"genderopen":    true,
+	"genios":        true,
+	"olms":          true,
+	"olms-mets":     true,
+	"ssoar":         true,
+}
+
+// validateIDs checks the identifiers of converted documents, like
+// span-import -validate: record id and source id must be set and the finc.id
+// must be derived from them. Other rules depend on the sample data.
+func validateIDs(t *testing.T, f formats.Format, name string, docs []*finc.IntermediateSchema) {
+	if legacyIDs[f.Name] {
+		return
+	}
+	v, err := finc.NewValidator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, doc := range docs {
+		b, err := json.Marshal(doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, violation := range v.Validate(b) {
+			if violation.Rule == finc.RuleID || strings.HasPrefix(violation.Field, "finc.") {
+				t.Errorf("%s: record #%d: %s", name, i, violation)
+			}
+		}
+	}
+}
+
+// convertAll converts all records of an input, one after another. Skipped
+// records are dropped, all other errors are returned.
+func convertAll(f formats.Format, r io.Reader) (result []*finc.IntermediateSchema, err error) {
+	add := func(v interface{}) error {
+		docs, err := toIntermediateSchema(v)
+		if _, ok := err.(span.Skip); ok {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		result = append(result, docs...)
+		return nil
+	}
+	switch f.Framing {
+	case formats.FramingXML:
+		names := f.Elements
+		if len(names) == 0 {
+			names = []string{elementName(f.New())}
+		}
+		dec := xml.NewDecoder(r)
+		dec.Strict = false
+		dec.CharsetReader = charset.NewReaderLabel
+		for {
+			tok, err := dec.Token()
+			if err == io.EOF {
+				return result, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			se, ok := tok.(xml.StartElement)
+			if !ok || !contains(names, se.Name.Local) {
+				continue
+			}
+			v := f.New()
+			if err := dec.DecodeElement(v, &se); err != nil {
+				return nil, err
+			}
+			if err := add(v); err != nil {
+				return nil, err
+			}
+		}
+	case formats.FramingNDJSON, formats.FramingDelimited:
+		br := bufio.NewReader(r)
+		sep := f.Separator
+		if f.Framing == formats.FramingNDJSON {
+			sep = '\n'
+		}
+		for {
+			b, err := br.ReadBytes(sep)
+			if len(bytes.TrimSpace(b)) > 0 {
+				v := f.New()
+				switch {
+				case f.Framing == formats.FramingNDJSON:
+					if err := json.Unmarshal(b, v); err != nil {
+						return nil, err
+					}
+				default:
+					if err := v.(encoding.BinaryUnmarshaler).UnmarshalBinary(b); err != nil {
+						return nil, err
+					}
+				}
+				if err := add(v); err != nil {
+					return nil, err
+				}
+			}
+			if err == io.EOF {
+				return result, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	case formats.FramingText:
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		v := f.New()
+		if err := v.(encoding.TextUnmarshaler).UnmarshalText(b); err != nil {
+			return nil, err
+		}
+		return result, add(v)
+	case formats.FramingTar:
+		err := f.Batch(r, func(doc *finc.IntermediateSchema, err error) error {
+			if _, ok := err.(span.Skip); ok {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			result = append(result, doc)
+			return nil
+		})
+		// Shipments are converted concurrently, in no particular order.
+		sort.Slice(result, func(i, j int) bool {
+			return result[i].RecordID < result[j].RecordID
+		})
+		return result, err
+	case formats.FramingStream:
+		dec := f.NewDecoder(r)
+		for {
+			v := f.New()
+			err := dec.Decode(v)
+			if err == io.EOF {
+				return result, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			if err := add(v); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unknown framing: %v", f.Framing)
+	}
+}
+
+// toIntermediateSchema converts a decoded record into one or more documents.
+func toIntermediateSchema(v interface{}) ([]*finc.IntermediateSchema, error) {
+	switch converter := v.(type) {
+	case formats.IntermediateSchemaLister:
+		return converter.ToIntermediateSchemaList()
+	case formats.IntermediateSchemaer:
+		doc, err := converter.ToIntermediateSchema()
+		if err != nil {
+			return nil, err
+		}
+		return []*finc.IntermediateSchema{doc}, nil
+	default:
+		return nil, fmt.Errorf("cannot convert to intermediate schema: %T", v)
+	}
+}
+
+// elementName returns the XML element name of a record, like span-import.
+func elementName(v interface{}) string {
+	t := reflect.Indirect(reflect.ValueOf(v)).Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Name != "XMLName" && field.Type != reflect.TypeOf(xml.Name{}) {
+			continue
+		}
+		if tag := strings.Split(field.Tag.Get("xml"), ",")[0]; tag != "" {
+			return tag[strings.LastIndex(tag, " ")+1:]
+		}
+	}
+	if field, ok := t.FieldByName("XMLName"); ok {
+		if tag := strings.Split(field.Tag.Get("xml"), ",")[0]; tag != "" {
+			return tag[strings.LastIndex(tag, " ")+1:]
+		}
+	}
+	return t.Name()
+}
+
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// diffDocuments compares two JSON arrays of documents field by field.
+// Documents are matched by record id, or by position, if there is none, so a
+// newly skipped record shows up as missing, not as a change in all following
+// documents.
+func diffDocuments(got, want []byte) (diffs []string, err error) {
+	var g, w []map[string]interface{}
+	if err := json.Unmarshal(got, &g); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(want, &w); err != nil {
+		return nil, fmt.Errorf("golden file: %w", err)
+	}
+	gm, gkeys := keyDocuments(g)
+	wm, wkeys := keyDocuments(w)
+	for _, k := range wkeys {
+		if _, ok := gm[k]; !ok {
+			diffs = append(diffs, fmt.Sprintf("record %s: missing", k))
+		}
+	}
+	for _, k := range gkeys {
+		wd, ok := wm[k]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("record %s: unexpected", k))
+			continue
+		}
+		gd := gm[k]
+		for _, field := range unionKeys(gd, wd) {
+			gv, gok := gd[field]
+			wv, wok := wd[field]
+			switch {
+			case !gok:
+				diffs = append(diffs, fmt.Sprintf("record %s: %s: missing, want %s", k, field, compact(wv)))
+			case !wok:
+				diffs = append(diffs, fmt.Sprintf("record %s: %s: unexpected %s", k, field, compact(gv)))
+			case !reflect.DeepEqual(gv, wv):
+				diffs = append(diffs, fmt.Sprintf("record %s: %s: got %s, want %s", k, field, compact(gv), compact(wv)))
+			}
+		}
+	}
+	return diffs, nil
+}
+
+// keyDocuments indexes documents by record id and returns the keys in order.
+func keyDocuments(docs []map[string]interface{}) (map[string]map[string]interface{}, []string) {
+	var (
+		m    = make(map[string]map[string]interface{})
+		keys []string
+	)
+	for i, doc := range docs {
+		k := fmt.Sprintf("#%d", i)
+		if id, ok := doc["finc.record_id"].(string); ok && id != "" {
+			k = fmt.Sprintf("%q", id)
+		}
+		if _, dup := m[k]; dup {
+			k = fmt.Sprintf("%s#%d", k, i)
+		}
+		m[k] = doc
+		keys = append(keys, k)
+	}
+	return m, keys
+}
+
+// unionKeys returns the sorted keys of both maps.
+func unionKeys(a, b map[string]interface{}) (keys []string) {
+	seen := make(map[string]bool)
+	for _, m := range []map[string]interface{}{a, b} {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// compact returns a short JSON representation of a value.
+func compact(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	if len(b) > 80 {
+		return string(b[:77]) + "..."
+	}
+	return string(b)
+}
+
+func TestDiffDocuments(t *testing.T) {
+	var (
+		want = `[{"finc.record_id": "1", "rft.atitle": "A", "x.oa": true}, {"finc.record_id": "2"}]`
+		got  = `[{"finc.record_id": "1", "rft.atitle": "B", "rft.issn": ["1"]}, {"finc.record_id": "3"}]`
+	)
+	diffs, err := diffDocuments([]byte(got), []byte(want))
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	expected := []string{
+		`record "2": missing`,
+		`record "1": rft.atitle: got "B", want "A"`,
+		`record "1": rft.issn: unexpected ["1"]`,
+		`record "1": x.oa: missing, want true`,
+		`record "3": unexpected`,
+	}
+	if !reflect.DeepEqual(diffs, expected) {
+		t.Errorf("got %q, want %q", diffs, expected)
+	}
+}
@@ -0,0 +1,386 @@
+package bibtex
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/miku/span/formats"
+)
+
+// months are predefined string macros.
+var months = map[string]string{
+	"jan": "January",
+	"feb": "February",
+	"mar": "March",
+	"apr": "April",
+	"may": "May",
+	"jun": "June",
+	"jul": "July",
+	"aug": "August",
+	"sep": "September",
+	"oct": "October",
+	"nov": "November",
+	"dec": "December",
+}
+
+// Decoder reads BibTeX entries from a stream. String macros defined with
+// @string are expanded in later entries, @comment and @preamble are ignored,
+// as are any text outside of entries and % comments. Values are returned with
+// LaTeX markup, which is decoded during conversion.
+type Decoder struct {
+	r      *bufio.Reader
+	line   int
+	start  int
+	macros map[string]string
+	raw    bytes.Buffer
+}
+
+// NewDecoder returns a decoder, that reads from r.
+func NewDecoder(r io.Reader) *Decoder {
+	macros := make(map[string]string)
+	for k, v := range months {
+		macros[k] = v
+	}
+	return &Decoder{r: bufio.NewReader(r), line: 1, macros: macros}
+}
+
+// Raw returns the text of the last entry.
+func (d *Decoder) Raw() []byte {
+	return d.raw.Bytes()
+}
+
+// Line returns the line, the last entry started on.
+func (d *Decoder) Line() int {
+	return d.start
+}
+
+// Decode reads the next entry into v, which must be an *Entry.
+func (d *Decoder) Decode(v interface{}) error {
+	entry, ok := v.(*Entry)
+	if !ok {
+		return fmt.Errorf("bibtex: cannot decode into %T", v)
+	}
+	for {
+		if err := d.skipToEntry(); err != nil {
+			return err
+		}
+		d.raw.Reset()
+		d.raw.WriteRune('@')
+		start := d.line
+		d.start = start
+		kind, closer, err := d.header()
+		if err != nil {
+			return d.recordError(start, err)
+		}
+		switch kind {
+		case "comment", "preamble":
+			if _, err := d.balanced(closer); err != nil {
+				return d.recordError(start, err)
+			}
+		case "string":
+			name, value, err := d.field()
+			if err != nil {
+				return d.recordError(start, err)
+			}
+			if err := d.expect(closer); err != nil {
+				return d.recordError(start, err)
+			}
+			d.macros[name] = value
+		default:
+			entry.Type = kind
+			if err := d.entry(entry, closer); err != nil {
+				return d.recordError(start, err)
+			}
+			return nil
+		}
+	}
+}
+
+// recordError turns syntax errors into record errors, so decoding can
+// continue with the next entry.
+func (d *Decoder) recordError(line int, err error) error {
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return &formats.RecordError{Line: line, Err: fmt.Errorf("bibtex: %w", err)}
+}
+
+// read returns the next rune and records it.
+func (d *Decoder) read() (rune, error) {
+	r, _, err := d.r.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+	if r == '\n' {
+		d.line++
+	}
+	d.raw.WriteRune(r)
+	return r, nil
+}
+
+// unread puts back the last rune.
+func (d *Decoder) unread(r rune) {
+	if err := d.r.UnreadRune(); err != nil {
+		panic(err)
+	}
+	if r == '\n' {
+		d.line--
+	}
+	d.raw.Truncate(d.raw.Len() - len(string(r)))
+}
+
+// skipTo consumes runes up to and including r.
+func (d *Decoder) skipTo(r rune) error {
+	for {
+		c, err := d.read()
+		if err != nil {
+			return err
+		}
+		if c == r {
+			return nil
+		}
+	}
+}
+
+// skipToEntry consumes text outside of entries up to and including the next
+// @. Like in LaTeX, % starts a comment up to the end of the line, which may
+// contain an @, e.g. in an email address.
+func (d *Decoder) skipToEntry() error {
+	for {
+		c, err := d.read()
+		if err != nil {
+			return err
+		}
+		switch c {
+		case '@':
+			return nil
+		case '%':
+			if err := d.skipTo('\n'); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// skipSpace consumes whitespace and comment lines and returns the next rune.
+func (d *Decoder) skipSpace() (rune, error) {
+	for {
+		r, err := d.read()
+		if err != nil {
+			return 0, err
+		}
+		switch {
+		case r == '%':
+			if err := d.skipTo('\n'); err != nil {
+				return 0, err
+			}
+		case !unicode.IsSpace(r):
+			return r, nil
+		}
+	}
+}
+
+// expect consumes whitespace and a given rune.
+func (d *Decoder) expect(want rune) error {
+	r, err := d.skipSpace()
+	if err != nil {
+		return err
+	}
+	if r != want {
+		return fmt.Errorf("line %d: expected %q, got %q", d.line, want, r)
+	}
+	return nil
+}
+
+// identifier reads a name, like an entry type, a key or a field name.
+func (d *Decoder) identifier() (string, error) {
+	r, err := d.skipSpace()
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	for {
+		if unicode.IsSpace(r) || strings.ContainsRune(`{}(),="#`, r) {
+			d.unread(r)
+			return sb.String(), nil
+		}
+		sb.WriteRune(r)
+		if r, err = d.read(); err != nil {
+			return "", err
+		}
+	}
+}
+
+// header reads the entry type and the opening delimiter and returns the
+// lowercase type and the closing delimiter.
+func (d *Decoder) header() (string, rune, error) {
+	kind, err := d.identifier()
+	if err != nil {
+		return "", 0, err
+	}
+	if kind == "" {
+		return "", 0, errors.New("missing entry type")
+	}
+	r, err := d.skipSpace()
+	if err != nil {
+		return "", 0, err
+	}
+	switch r {
+	case '{':
+		return strings.ToLower(kind), '}', nil
+	case '(':
+		return strings.ToLower(kind), ')', nil
+	default:
+		return "", 0, fmt.Errorf("line %d: expected { or ( after @%s", d.line, kind)
+	}
+}
+
+// balanced reads up to the closing delimiter, respecting nested braces.
+func (d *Decoder) balanced(closer rune) (string, error) {
+	var (
+		sb    strings.Builder
+		depth int
+	)
+	for {
+		r, err := d.read()
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case r == '{':
+			depth++
+		case r == '}' && depth > 0:
+			depth--
+		case r == closer && depth == 0:
+			return sb.String(), nil
+		}
+		sb.WriteRune(r)
+	}
+}
+
+// entry reads a citation key and fields up to the closing delimiter.
+func (d *Decoder) entry(entry *Entry, closer rune) error {
+	key, err := d.identifier()
+	if err != nil {
+		return err
+	}
+	entry.Key = key
+	entry.Fields = make(map[string]string)
+	for {
+		r, err := d.skipSpace()
+		if err != nil {
+			return err
+		}
+		switch r {
+		case closer:
+			return nil
+		case ',':
+		case '@':
+			// Missing closing delimiter, leave the next entry intact.
+			d.unread(r)
+			return fmt.Errorf("line %d: entry not closed", d.line)
+		default:
+			return fmt.Errorf("line %d: expected , or %q, got %q", d.line, closer, r)
+		}
+		r, err = d.skipSpace()
+		if err != nil {
+			return err
+		}
+		if r == closer {
+			// Trailing comma.
+			return nil
+		}
+		d.unread(r)
+		name, value, err := d.field()
+		if err != nil {
+			return err
+		}
+		entry.Fields[name] = value
+	}
+}
+
+// field reads "name = value", where value may be a concatenation of braced
+// or quoted strings, numbers and macros.
+func (d *Decoder) field() (string, string, error) {
+	name, err := d.identifier()
+	if err != nil {
+		return "", "", err
+	}
+	if name == "" {
+		return "", "", fmt.Errorf("line %d: missing field name", d.line)
+	}
+	if err := d.expect('='); err != nil {
+		return "", "", err
+	}
+	var sb strings.Builder
+	for {
+		r, err := d.skipSpace()
+		if err != nil {
+			return "", "", err
+		}
+		switch {
+		case r == '{':
+			s, err := d.balanced('}')
+			if err != nil {
+				return "", "", err
+			}
+			sb.WriteString(s)
+		case r == '"':
+			s, err := d.quoted()
+			if err != nil {
+				return "", "", err
+			}
+			sb.WriteString(s)
+		default:
+			d.unread(r)
+			s, err := d.identifier()
+			if err != nil {
+				return "", "", err
+			}
+			if s == "" {
+				return "", "", fmt.Errorf("line %d: missing value for %s", d.line, name)
+			}
+			if v, ok := d.macros[strings.ToLower(s)]; ok {
+				s = v
+			}
+			sb.WriteString(s)
+		}
+		r, err = d.skipSpace()
+		if err != nil {
+			return "", "", err
+		}
+		if r != '#' {
+			d.unread(r)
+			return strings.ToLower(name), sb.String(), nil
+		}
+	}
+}
+
+// quoted reads a value up to the closing quote; quotes in braces do not
+// count.
+func (d *Decoder) quoted() (string, error) {
+	var (
+		sb    strings.Builder
+		depth int
+	)
+	for {
+		r, err := d.read()
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case r == '{':
+			depth++
+		case r == '}':
+			depth--
+		case r == '"' && depth == 0:
+			return sb.String(), nil
+		}
+		sb.WriteRune(r)
+	}
+}
@@ -0,0 +1,164 @@
+// Package bibtex implements BibTeX (and the common biblatex fields) as input
+// format. Entries are converted via RIS, so reference types map to the same
+// genres and formats as in the ris package.
+package bibtex
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/miku/span/assetutil"
+	"github.com/miku/span/formats"
+	"github.com/miku/span/formats/finc"
+	"github.com/miku/span/formats/ris"
+)
+
+var (
+	// RefTypes maps entry types to RIS reference types.
+	RefTypes = assetutil.MustLoadStringMap("assets/bibtex/reftypes.json")
+
+	// authorSeparator splits names, e.g. "Doe, Jane and John Smith".
+	authorSeparator = regexp.MustCompile(`^\s+and\s+`)
+	// listSeparator splits keywords.
+	listSeparator = regexp.MustCompile(`\s*[,;]\s*`)
+)
+
+// Entry is a single BibTeX entry with lowercase type and field names. Values
+// still contain LaTeX markup.
+type Entry struct {
+	Type   string
+	Key    string
+	Fields map[string]string
+	source *formats.Source
+}
+
+// Value returns the first non-empty field, with LaTeX markup decoded.
+func (e *Entry) Value(names ...string) string {
+	for _, name := range names {
+		if v := strings.TrimSpace(DecodeLaTeX(e.Fields[name])); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// Raw returns a field without LaTeX decoding, for identifiers and links,
+// where characters like ~ or -- are literal. Outer braces are removed.
+func (e *Entry) Raw(name string) string {
+	v := strings.TrimSpace(e.Fields[name])
+	for len(v) > 1 && v[0] == '{' && v[len(v)-1] == '}' {
+		v = strings.TrimSpace(v[1 : len(v)-1])
+	}
+	return v
+}
+
+// Authors splits the author field on "and" outside of braces, so corporate
+// names like {Barnes and Noble} stay intact.
+func (e *Entry) Authors() (names []string) {
+	s := e.Fields["author"]
+	var (
+		depth int
+		last  int
+	)
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		}
+		if depth != 0 {
+			continue
+		}
+		if loc := authorSeparator.FindStringIndex(s[i:]); loc != nil {
+			names = append(names, s[last:i])
+			i += loc[1] - 1
+			last = i + 1
+		}
+	}
+	names = append(names, s[last:])
+	var result []string
+	for _, name := range names {
+		if name = strings.TrimSpace(DecodeLaTeX(name)); name != "" {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// Date returns a RIS date (YYYY/MM/DD), from date or year and month.
+func (e *Entry) Date() string {
+	if v := e.Value("date"); v != "" {
+		return strings.Replace(v, "-", "/", -1)
+	}
+	year := e.Value("year")
+	if year == "" {
+		return ""
+	}
+	month := e.Value("month")
+	for _, layout := range []string{"January", "Jan", "1"} {
+		if t, err := time.Parse(layout, month); err == nil {
+			return fmt.Sprintf("%s/%02d", year, t.Month())
+		}
+	}
+	return year
+}
+
+// Record returns the entry as RIS record.
+func (e *Entry) Record() *ris.Record {
+	r := ris.NewRecord(nil, e.source)
+	add := func(tag string, values ...string) {
+		for _, v := range values {
+			if v != "" {
+				r.Add(tag, v)
+			}
+		}
+	}
+	ty := RefTypes.Lookup(e.Type, "GEN")
+	add("TY", ty)
+	add("ID", e.Key)
+	add("TI", e.Value("title"))
+	add("ST", e.Value("shorttitle"))
+	switch ty {
+	case "JOUR", "EJOUR", "MGZN":
+		add("JF", e.Value("journaltitle", "journal"))
+	default:
+		add("T2", e.Value("booktitle", "journaltitle", "journal"))
+	}
+	add("AU", e.Authors()...)
+	add("PY", e.Date())
+	add("VL", e.Value("volume"))
+	add("IS", e.Value("number", "issue"))
+	if pages := e.Value("pages"); pages != "" {
+		parts := strings.FieldsFunc(pages, func(r rune) bool {
+			return r == '-' || r == '–' || r == '—'
+		})
+		if len(parts) > 0 {
+			add("SP", strings.TrimSpace(parts[0]))
+		}
+		if len(parts) > 1 {
+			add("EP", strings.TrimSpace(parts[len(parts)-1]))
+		}
+	}
+	add("SN", e.Value("issn"), e.Value("isbn"))
+	add("DO", e.Raw("doi"))
+	add("UR", e.Raw("url"))
+	add("AB", e.Value("abstract"))
+	if kw := e.Value("keywords"); kw != "" {
+		add("KW", listSeparator.Split(kw, -1)...)
+	}
+	add("LA", e.Value("language", "langid"))
+	add("PB", e.Value("publisher", "institution", "school", "organization"))
+	add("CY", e.Value("address", "location"))
+	add("ET", e.Value("edition"))
+	add("T3", e.Value("series"))
+	add("N1", e.Value("note"))
+	return r
+}
+
+// ToIntermediateSchema converts an entry via RIS.
+func (e *Entry) ToIntermediateSchema() (*finc.IntermediateSchema, error) {
+	return e.Record().ToIntermediateSchema()
+}
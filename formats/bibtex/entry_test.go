@@ -0,0 +1,155 @@
+package bibtex
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/miku/span/formats"
+)
+
+func TestDecodeLaTeX(t *testing.T) {
+	var cases = []struct {
+		s    string
+		want string
+	}{
+		{"", ""},
+		{"plain", "plain"},
+		{`M{\"u}ller`, "Müller"},
+		{`\c{C}elik`, "Çelik"},
+		{`Gro{\ss}e`, "Große"},
+		{`{\AA}ngstr{\"o}m`, "Ångström"},
+		{`na\"{\i}ve`, "naïve"},
+		{`\emph{Deep} {Learning}`, "Deep Learning"},
+		{`R\&D, 50\%`, "R&D, 50%"},
+		{"pp.~1--10", "pp. 1–10"},
+		{"a---b", "a—b"},
+		{"``quoted''", "“quoted”"},
+		{"$x^2$", "x^2"},
+		{"two\n  lines", "two lines"},
+	}
+	for _, c := range cases {
+		if got := DecodeLaTeX(c.s); got != c.want {
+			t.Errorf("DecodeLaTeX(%q): got %q, want %q", c.s, got, c.want)
+		}
+	}
+}
+
+const sample = `% A comment outside of entries, by jane@example.com.
+@comment{ignored {with braces}}
+@String{ pub = "Tag Press" }
+@string(jt = {Journal of Tags})
+
+@Article{doe2019,
+  author  = {M{\"u}ller, Jane and {Barnes and Noble} and John Smith},
+  title   = "On {"}Tags{"}",
+  journal = jt # { Quarterly},
+  year    = 2019,
+  month   = may,
+  pages   = {12--34},
+  doi     = {10.1/x},
+  % A comment line, with an @ sign.
+  keywords = {tags; formats},
+  publisher = pub,
+}
+
+@book{broken,
+  title = {Unclosed
+}
+
+@InProceedings(smith2001,
+  title = {A Paper},
+  booktitle = {Proceedings},
+  pages = {--},
+  year = {2001}
+)
+`
+
+func TestRawFields(t *testing.T) {
+	e := &Entry{
+		Type: "article",
+		Key:  "x",
+		Fields: map[string]string{
+			"title": "On Tags",
+			"year":  "2019",
+			"doi":   " {10.1000/foo--bar} ",
+			"url":   "http://example.org/~user/",
+		},
+		source: &formats.Source{SourceID: "1"},
+	}
+	output, err := e.ToIntermediateSchema()
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if output.DOI != "10.1000/foo--bar" {
+		t.Errorf("got %q, want 10.1000/foo--bar", output.DOI)
+	}
+	if want := []string{"http://example.org/~user/"}; !reflect.DeepEqual(output.URL, want) {
+		t.Errorf("got %q, want %q", output.URL, want)
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	var (
+		dec     = NewDecoder(strings.NewReader(sample))
+		entries []*Entry
+		errs    int
+	)
+	for {
+		e := &Entry{source: &formats.Source{SourceID: "1"}}
+		err := dec.Decode(e)
+		if err == io.EOF {
+			break
+		}
+		var re *formats.RecordError
+		if errors.As(err, &re) {
+			errs++
+			continue
+		}
+		if err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+		entries = append(entries, e)
+	}
+	if len(entries) != 2 || errs != 1 {
+		t.Fatalf("got %d entries and %d errors, want 2 and 1", len(entries), errs)
+	}
+	e := entries[0]
+	if e.Type != "article" || e.Key != "doe2019" {
+		t.Errorf("got %s %s, want article doe2019", e.Type, e.Key)
+	}
+	if got, want := e.Fields["journal"], "Journal of Tags Quarterly"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got, want := e.Value("title"), `On "Tags"`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got, want := e.Authors(), []string{"Müller, Jane", "Barnes and Noble", "John Smith"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got, want := e.Date(), "2019/05"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	output, err := e.ToIntermediateSchema()
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if output.ID != "ai-1-ZG9lMjAxOQ" || output.Genre != "article" || output.JournalTitle != "Journal of Tags Quarterly" {
+		t.Errorf("got %s %s %s", output.ID, output.Genre, output.JournalTitle)
+	}
+	if output.StartPage != "12" || output.EndPage != "34" || output.Publishers[0] != "Tag Press" {
+		t.Errorf("got %s %s %v", output.StartPage, output.EndPage, output.Publishers)
+	}
+	output, err = entries[1].ToIntermediateSchema()
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if output.Genre != "proceeding" || output.BookTitle != "Proceedings" {
+		t.Errorf("got %s %s, want proceeding", output.Genre, output.BookTitle)
+	}
+	if output.StartPage != "" || output.EndPage != "" {
+		t.Errorf("got %s %s, want no pages", output.StartPage, output.EndPage)
+	}
+}
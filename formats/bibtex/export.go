@@ -0,0 +1,181 @@
+package bibtex
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/miku/span/container"
+	"github.com/miku/span/formats/finc"
+)
+
+var (
+	// EntryTypes maps rft.genre to entry types, anything else is misc.
+	EntryTypes = container.MapDefault{
+		"article":    "article",
+		"book":       "book",
+		"bookitem":   "incollection",
+		"conference": "proceedings",
+		"proceeding": "inproceedings",
+		"report":     "techreport",
+		"preprint":   "unpublished",
+	}
+
+	// latexEscaper escapes characters with a special meaning in LaTeX.
+	latexEscaper = strings.NewReplacer(
+		`\`, `\textbackslash{}`,
+		`{`, `\{`,
+		`}`, `\}`,
+		`&`, `\&`,
+		`%`, `\%`,
+		`$`, `\$`,
+		`#`, `\#`,
+		`_`, `\_`,
+		`~`, `\textasciitilde{}`,
+		`^`, `\textasciicircum{}`,
+	)
+)
+
+// field is a single name, value pair of an entry.
+type field struct {
+	name  string
+	value string
+	// verbatim values, like URLs, are not escaped.
+	verbatim bool
+}
+
+// Exporter writes documents as BibTeX entries. Values are UTF-8, as
+// understood by biber and most reference managers.
+type Exporter struct{}
+
+// Export converts a document into a BibTeX entry.
+func (e *Exporter) Export(is finc.IntermediateSchema, _ bool) ([]byte, error) {
+	var (
+		ty     = EntryTypes.Lookup(is.Genre, "misc")
+		fields []field
+	)
+	add := func(name, value string) {
+		if value = strings.Join(strings.Fields(value), " "); value != "" {
+			fields = append(fields, field{name: name, value: value})
+		}
+	}
+	addVerbatim := func(name, value string) {
+		if value = strings.TrimSpace(value); value != "" {
+			fields = append(fields, field{name: name, value: value, verbatim: true})
+		}
+	}
+	var authors []string
+	for _, a := range is.Authors {
+		if name := authorName(a); name != "" {
+			authors = append(authors, name)
+		}
+	}
+	// Names are escaped one by one, to keep the braces around corporate
+	// names intact.
+	if len(authors) > 0 {
+		fields = append(fields, field{name: "author", value: strings.Join(authors, " and "), verbatim: true})
+	}
+	if is.ArticleTitle != "" {
+		add("title", is.ArticleTitle)
+	} else {
+		add("title", is.BookTitle)
+	}
+	add("subtitle", is.ArticleSubtitle)
+	switch ty {
+	case "article", "unpublished", "misc":
+		add("journal", is.JournalTitle)
+	case "incollection", "inproceedings":
+		booktitle := is.BookTitle
+		if booktitle == "" {
+			booktitle = is.JournalTitle
+		}
+		if booktitle != is.ArticleTitle {
+			add("booktitle", booktitle)
+		}
+	}
+	// The date is only written, if it is more precise than the year.
+	if parts := is.DateParts(); parts != nil {
+		add("year", strconv.Itoa(parts[0]))
+		if len(parts) > 1 {
+			date := fmt.Sprintf("%04d-%02d", parts[0], parts[1])
+			if len(parts) > 2 {
+				date += fmt.Sprintf("-%02d", parts[2])
+			}
+			add("date", date)
+		}
+	}
+	add("volume", is.Volume)
+	add("number", is.Issue)
+	switch {
+	case is.StartPage != "" && is.EndPage != "":
+		add("pages", is.StartPage+"--"+is.EndPage)
+	case is.StartPage != "":
+		add("pages", is.StartPage)
+	case is.Pages != "":
+		add("pages", is.Pages)
+	}
+	add("issn", strings.Join(is.ISSNList(), ", "))
+	add("isbn", strings.Join(is.ISBNList(), ", "))
+	addVerbatim("doi", is.DOI)
+	if len(is.URL) > 0 {
+		addVerbatim("url", is.URL[0])
+	}
+	add("abstract", is.AbstractCleaned())
+	add("keywords", strings.Join(is.Subjects, ", "))
+	add("language", strings.Join(is.Languages, ", "))
+	add("publisher", strings.Join(is.Publishers, " and "))
+	add("address", strings.Join(is.Places, " and "))
+	add("edition", is.Edition)
+	add("series", is.Series)
+	add("note", strings.Join(is.Footnotes, "; "))
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "@%s{%s", ty, citationKey(is))
+	for _, f := range fields {
+		value := f.value
+		if !f.verbatim {
+			value = latexEscaper.Replace(value)
+		}
+		fmt.Fprintf(&buf, ",\n  %s = {%s}", f.name, value)
+	}
+	buf.WriteString("\n}\n")
+	return buf.Bytes(), nil
+}
+
+// authorName returns "Last, First", corporate names are braced, so they are
+// not split into parts.
+func authorName(a finc.Author) string {
+	switch {
+	case a.LastName != "" && a.FirstName != "":
+		return latexEscaper.Replace(a.LastName) + ", " + latexEscaper.Replace(a.FirstName)
+	case a.LastName != "":
+		return latexEscaper.Replace(a.LastName)
+	case a.Name != "" && !strings.Contains(a.Name, " and "):
+		return latexEscaper.Replace(a.Name)
+	case a.Name != "":
+		return "{" + latexEscaper.Replace(a.Name) + "}"
+	case a.Corporate != "":
+		return "{" + latexEscaper.Replace(a.Corporate) + "}"
+	}
+	return ""
+}
+
+// citationKey returns the finc id, or the record id, with characters, that
+// are not allowed in keys, replaced.
+func citationKey(is finc.IntermediateSchema) string {
+	key := is.ID
+	if key == "" {
+		key = is.RecordID
+	}
+	if key == "" {
+		return "unknown"
+	}
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("-_:./", r) {
+			return r
+		}
+		return '_'
+	}, key)
+}
@@ -0,0 +1,85 @@
+package bibtex
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miku/span/formats"
+	"github.com/miku/span/formats/finc"
+)
+
+func TestExportRoundTrip(t *testing.T) {
+	is := finc.IntermediateSchema{
+		ID:           "ai-1-x",
+		RecordID:     "x",
+		ArticleTitle: "On {Tags} & 50% of_it",
+		Authors:      []finc.Author{{LastName: "Müller", FirstName: "Jane"}, {Name: "Barnes and Noble"}},
+		Date:         time.Date(2019, 5, 2, 0, 0, 0, 0, time.UTC),
+		DOI:          "10.1/x_y",
+		Genre:        "article",
+		JournalTitle: "Journal of Tags",
+		Volume:       "12",
+		Issue:        "3",
+		StartPage:    "1",
+		EndPage:      "10",
+	}
+	b, err := new(Exporter).Export(is, false)
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if !strings.HasPrefix(string(b), "@article{ai-1-x,\n") {
+		t.Errorf("unexpected entry: %s", b)
+	}
+	e := &Entry{source: &formats.Source{SourceID: "1"}}
+	if err := NewDecoder(strings.NewReader(string(b))).Decode(e); err != nil {
+		t.Fatalf("cannot decode exported entry: %v", err)
+	}
+	if got, want := e.Authors(), []string{"Müller, Jane", "Barnes and Noble"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	output, err := e.ToIntermediateSchema()
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	var cases = []struct {
+		got, want string
+	}{
+		{output.ArticleTitle, is.ArticleTitle},
+		{output.JournalTitle, is.JournalTitle},
+		{output.Genre, is.Genre},
+		{output.RawDate, "2019-05-02"},
+		{output.Volume, is.Volume},
+		{output.Issue, is.Issue},
+		{output.StartPage, is.StartPage},
+		{output.EndPage, is.EndPage},
+		{output.DOI, is.DOI},
+	}
+	for _, c := range cases {
+		if c.got != c.want {
+			t.Errorf("got %q, want %q", c.got, c.want)
+		}
+	}
+}
+
+func TestExportYear(t *testing.T) {
+	is := finc.IntermediateSchema{
+		RecordID:     "x",
+		ArticleTitle: "On Tags",
+		Genre:        "article",
+		Date:         time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+		RawDate:      "2019-01-01",
+	}
+	b, err := new(Exporter).Export(is, false)
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	e := &Entry{}
+	if err := NewDecoder(strings.NewReader(string(b))).Decode(e); err != nil {
+		t.Fatalf("cannot decode exported entry: %v", err)
+	}
+	if e.Value("year") != "2019" || e.Value("date") != "" {
+		t.Errorf("got year %q and date %q, want year only", e.Value("year"), e.Value("date"))
+	}
+}
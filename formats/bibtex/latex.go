@@ -0,0 +1,229 @@
+package bibtex
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+var (
+	// accents maps accent commands to combining characters, e.g. \"a or \c{c}.
+	accents = map[string]rune{
+		"\"": '\u0308',
+		"'":  '\u0301',
+		"`":  '\u0300',
+		"^":  '\u0302',
+		"~":  '\u0303',
+		"=":  '\u0304',
+		".":  '\u0307',
+		"u":  '\u0306',
+		"v":  '\u030c',
+		"H":  '\u030b',
+		"r":  '\u030a',
+		"c":  '\u0327',
+		"k":  '\u0328',
+		"d":  '\u0323',
+		"b":  '\u0331',
+	}
+	// symbols are commands without argument.
+	symbols = map[string]string{
+		"ss":           "ß",
+		"o":            "ø",
+		"O":            "Ø",
+		"aa":           "å",
+		"AA":           "Å",
+		"ae":           "æ",
+		"AE":           "Æ",
+		"oe":           "œ",
+		"OE":           "Œ",
+		"l":            "ł",
+		"L":            "Ł",
+		"i":            "ı",
+		"j":            "ȷ",
+		"dh":           "ð",
+		"DH":           "Ð",
+		"th":           "þ",
+		"TH":           "Þ",
+		"textendash":   "–",
+		"textemdash":   "—",
+		"ldots":        "…",
+		"dots":         "…",
+		"textellipsis": "…",
+		"S":            "§",
+		"P":            "¶",
+		"copyright":    "©",
+		"pounds":       "£",
+		"euro":         "€",
+		"LaTeX":        "LaTeX",
+		"TeX":          "TeX",
+
+		"textbackslash":   "\\",
+		"textasciitilde":  "~",
+		"textasciicircum": "^",
+	}
+)
+
+// DecodeLaTeX turns LaTeX markup, as found in BibTeX values, into plain text:
+// accents and special characters become unicode, braces and formatting
+// commands are removed, dashes and quotes are converted and whitespace is
+// collapsed.
+func DecodeLaTeX(s string) string {
+	if !strings.ContainsAny(s, "\\{}$~-`'\n\t") {
+		return s
+	}
+	d := &latexDecoder{rs: []rune(s)}
+	return norm.NFC.String(strings.Join(strings.Fields(d.decode(false)), " "))
+}
+
+// latexDecoder works on runes, one group at a time.
+type latexDecoder struct {
+	rs []rune
+	i  int
+}
+
+// peek returns the current rune without consuming it.
+func (d *latexDecoder) peek() (rune, bool) {
+	if d.i < len(d.rs) {
+		return d.rs[d.i], true
+	}
+	return 0, false
+}
+
+// decode decodes until the end of input or, if group is true, until the
+// closing brace of the current group.
+func (d *latexDecoder) decode(group bool) string {
+	var sb strings.Builder
+	for d.i < len(d.rs) {
+		r := d.rs[d.i]
+		d.i++
+		switch r {
+		case '{':
+			sb.WriteString(d.decode(true))
+		case '}':
+			if group {
+				return sb.String()
+			}
+		case '$':
+			// Math mode delimiters are dropped, content is kept.
+		case '~':
+			sb.WriteRune(' ')
+		case '-':
+			switch {
+			case d.hasPrefix("--"):
+				d.i += 2
+				sb.WriteRune('—')
+			case d.hasPrefix("-"):
+				d.i++
+				sb.WriteRune('–')
+			default:
+				sb.WriteRune('-')
+			}
+		case '`':
+			if d.hasPrefix("`") {
+				d.i++
+				sb.WriteRune('“')
+			} else {
+				sb.WriteRune('‘')
+			}
+		case '\'':
+			if d.hasPrefix("'") {
+				d.i++
+				sb.WriteRune('”')
+			} else {
+				sb.WriteRune('\'')
+			}
+		case '\\':
+			sb.WriteString(d.command())
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// hasPrefix checks the runes following the current position.
+func (d *latexDecoder) hasPrefix(s string) bool {
+	i := d.i
+	for _, r := range s {
+		if i >= len(d.rs) || d.rs[i] != r {
+			return false
+		}
+		i++
+	}
+	return true
+}
+
+// command decodes a control sequence after the backslash.
+func (d *latexDecoder) command() string {
+	r, ok := d.peek()
+	if !ok {
+		return ""
+	}
+	var name string
+	if unicode.IsLetter(r) {
+		start := d.i
+		for d.i < len(d.rs) && unicode.IsLetter(d.rs[d.i]) && d.rs[d.i] < unicode.MaxASCII {
+			d.i++
+		}
+		name = string(d.rs[start:d.i])
+		// Spaces after control words are ignored.
+		for d.i < len(d.rs) && d.rs[d.i] == ' ' {
+			d.i++
+		}
+	} else {
+		d.i++
+		name = string(r)
+	}
+	if mark, ok := accents[name]; ok {
+		arg := []rune(d.argument())
+		if len(arg) == 0 {
+			return string(mark)
+		}
+		switch arg[0] {
+		case 'ı':
+			arg[0] = 'i'
+		case 'ȷ':
+			arg[0] = 'j'
+		}
+		return string(arg[0]) + string(mark) + string(arg[1:])
+	}
+	if v, ok := symbols[name]; ok {
+		return v
+	}
+	switch name {
+	case "&", "%", "$", "#", "_", "{", "}":
+		return name
+	case " ", "\\", ",", ";", ":", "!", "/":
+		return " "
+	case "-":
+		// Discretionary hyphen.
+		return ""
+	}
+	// Formatting and unknown commands, like \emph{x}, keep their argument.
+	if r, ok := d.peek(); ok && r == '{' {
+		d.i++
+		return d.decode(true)
+	}
+	return ""
+}
+
+// argument returns a braced group or a single character.
+func (d *latexDecoder) argument() string {
+	for d.i < len(d.rs) && d.rs[d.i] == ' ' {
+		d.i++
+	}
+	r, ok := d.peek()
+	if !ok {
+		return ""
+	}
+	d.i++
+	switch r {
+	case '{':
+		return d.decode(true)
+	case '\\':
+		return d.command()
+	default:
+		return string(r)
+	}
+}
@@ -0,0 +1,39 @@
+package bibtex
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+
+	"github.com/miku/span/formats"
+)
+
+// entryStart matches the beginning of a BibTeX entry, like "@article{".
+var entryStart = regexp.MustCompile(`(?m)^\s*@[a-zA-Z]+\s*[{(]`)
+
+// isBibTeX checks for an entry near the start of the input.
+func isBibTeX(p []byte) bool {
+	p = bytes.TrimPrefix(p, []byte("\xef\xbb\xbf"))
+	if len(p) > 4096 {
+		p = p[:4096]
+	}
+	return entryStart.Match(p)
+}
+
+func init() {
+	formats.Register(formats.Format{
+		Name:            "bibtex",
+		Framing:         formats.FramingStream,
+		New:             func() interface{} { return &Entry{} },
+		NewDecoder:      func(r io.Reader) formats.Decoder { return NewDecoder(r) },
+		Signature:       &formats.Signature{Match: isBibTeX},
+		RequiresMapping: true,
+		Configure: func(r io.Reader) (formats.Factory, error) {
+			s, err := formats.ReadSource(r)
+			if err != nil {
+				return nil, err
+			}
+			return func() interface{} { return &Entry{source: s} }, nil
+		},
+	})
+}
@@ -272,15 +272,14 @@ func (r Record) ToIntermediateSchema() (*finc.IntermediateSchema, error) {
 	output := finc.NewIntermediateSchema()
 	v, err := r.ID()
 	if err != nil {
-		return output, span.Skip{Reason: err.Error()}
+		return output, span.Skip{Code: span.SkipMissingID, Reason: err.Error()}
 	}
 	output.RecordID = v
 	output.ID = fmt.Sprintf("ai-53-%s", output.RecordID)
 
 	v, err = r.Title()
 	if err != nil {
-		return output, span.Skip{
-			Reason: fmt.Sprintf("missing title: %s", output.RecordID)}
+		return output, span.Skip{Code: span.SkipMissingTitle, RecordID: output.RecordID}
 	}
 
 	switch r.RecordFormat() {
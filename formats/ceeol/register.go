@@ -0,0 +1,30 @@
+package ceeol
+
+import (
+	"regexp"
+
+	"github.com/miku/span/formats"
+)
+
+func init() {
+	formats.Register(formats.Format{
+		Name:    "ceeol",
+		Framing: formats.FramingXML,
+		New:     func() interface{} { return new(Article) },
+		Signature: &formats.Signature{
+			// Article alone is too common, e.g. in PubMed.
+			Elements: []string{"Article", "UniqueID"},
+		},
+	})
+	formats.Register(formats.Format{
+		Name:    "ceeol-marcxml",
+		Framing: formats.FramingXML,
+		New:     func() interface{} { return new(Record) },
+		Signature: &formats.Signature{
+			Elements:   []string{"record"},
+			Namespaces: []string{formats.NamespaceMARCXML},
+			// The record id is in the link in 856.u.
+			Text: map[string]*regexp.Regexp{"subfield": regexp.MustCompile(`^https?://(www\.)?ceeol\.com/`)},
+		},
+	})
+}
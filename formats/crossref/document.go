@@ -158,6 +158,59 @@ type Document struct {
 	Type                string      `json:"type"`
 	URL                 string      `json:"URL"`
 	Volume              string      `json:"volume"`
+	UpdateTo            []struct {
+		DOI     string    `json:"DOI"`
+		Type    string    `json:"type"`
+		Label   string    `json:"label"`
+		Updated DateField `json:"updated"`
+	} `json:"update-to"`
+}
+
+// deletingUpdates are the update-to types, that remove the updated document
+// from the index. Other types, e.g. correction, erratum, partial_retraction or
+// expression_of_concern, leave the document in place.
+var deletingUpdates = map[string]bool{
+	"removal":    true,
+	"retraction": true,
+	"withdrawal": true,
+}
+
+// IsWithdrawn returns true, if the document carries a deleting update, e.g. a
+// withdrawal or retraction, of the document itself.
+func (doc *Document) IsWithdrawn() bool {
+	for _, u := range doc.UpdateTo {
+		if deletingUpdates[u.Type] && strings.EqualFold(u.DOI, doc.DOI) {
+			return true
+		}
+	}
+	return false
+}
+
+// Withdrawals returns the DOIs of other documents, that this document
+// withdraws, retracts or removes, e.g. if it is a retraction notice.
+func (doc *Document) Withdrawals() (dois []string) {
+	for _, u := range doc.UpdateTo {
+		if deletingUpdates[u.Type] && u.DOI != "" && !strings.EqualFold(u.DOI, doc.DOI) {
+			dois = append(dois, u.DOI)
+		}
+	}
+	return dois
+}
+
+// LinkTo returns the URL of a DOI, with the resolver of the document URL,
+// defaults to http://dx.doi.org/, which the id of a document is derived from.
+func (doc *Document) LinkTo(doi string) string {
+	prefix := "http://dx.doi.org/"
+	if i := len(doc.URL) - len(doc.DOI); doc.DOI != "" && i > 0 && strings.EqualFold(doc.URL[i:], doc.DOI) {
+		prefix = doc.URL[:i]
+	}
+	return prefix + doi
+}
+
+// tombstone returns a deletion of a DOI, with an id derived from its link.
+func (doc *Document) tombstone(doi string) *finc.IntermediateSchema {
+	id := fmt.Sprintf("ai-%s-%s", SourceID, base64.RawURLEncoding.EncodeToString([]byte(doc.LinkTo(doi))))
+	return finc.NewTombstone(id, SourceID, doi)
 }
 
 // PageInfo holds various page related data.
@@ -278,11 +331,38 @@ func (doc *Document) FindLanguages() []string {
 	return []string{"und"}
 }
 
+// ToIntermediateSchemaList converts the document and adds a tombstone for
+// every other document it withdraws, retracts or removes. A withdrawal notice, that cannot be
+// converted, still deletes the withdrawn documents.
+func (doc *Document) ToIntermediateSchemaList() ([]*finc.IntermediateSchema, error) {
+	output, err := doc.ToIntermediateSchema()
+	withdrawals := doc.Withdrawals()
+	if len(withdrawals) == 0 {
+		return []*finc.IntermediateSchema{output}, err
+	}
+	var result []*finc.IntermediateSchema
+	if err == nil {
+		result = append(result, output)
+	} else {
+		log.Printf("warning: crossref: dropping withdrawal notice %s: %v", doc.DOI, err)
+	}
+	for _, doi := range withdrawals {
+		result = append(result, doc.tombstone(doi))
+	}
+	return result, nil
+}
+
 // ToIntermediateSchema converts a crossref document into IS. XXX: Use a
 // canonical publisher, based on doi prefix, /cc @ad.
 func (doc *Document) ToIntermediateSchema() (*finc.IntermediateSchema, error) {
 	var err error
 	output := finc.NewIntermediateSchema()
+	if doc.IsWithdrawn() {
+		if doc.URL != "" {
+			return finc.NewTombstone(doc.ID(), SourceID, doc.DOI), nil
+		}
+		return doc.tombstone(doc.DOI), nil
+	}
 	output.Date, err = doc.PublishedPrint.Date()
 	if err != nil {
 		// Fallback to previous behaviour, refs #12321.
@@ -306,21 +386,31 @@ func (doc *Document) ToIntermediateSchema() (*finc.IntermediateSchema, error) {
 	}
 	output.ID = doc.ID()
 	if len(output.ID) > span.KeyLengthLimit {
-		return output, span.Skip{Reason: fmt.Sprintf("ID_TOO_LONG %s", output.ID)}
+		return output, span.Skip{Code: span.SkipIDTooLong, RecordID: output.ID}
 	}
 	if output.Date.After(Future) {
-		return output, span.Skip{Reason: fmt.Sprintf("TOO_FUTURISTIC %s", output.ID)}
+		return output, span.Skip{
+			Code:     span.SkipTooFuturistic,
+			RecordID: output.ID,
+			Field:    "date",
+			Value:    output.RawDate,
+		}
 	}
 	if doc.Type == "journal-issue" {
-		return output, span.Skip{Reason: fmt.Sprintf("JOURNAL_ISSUE %s", output.ID)}
+		return output, span.Skip{Code: span.SkipJournalIssue, RecordID: output.ID}
 	}
 	output.ArticleTitle = doc.CombinedTitle()
 	if len(output.ArticleTitle) == 0 {
-		return output, span.Skip{Reason: fmt.Sprintf("NO_ATITLE %s", output.ID)}
+		return output, span.Skip{Code: span.SkipMissingArticleTitle, RecordID: output.ID}
 	}
 	for _, title := range ArticleTitleBlocker {
 		if output.ArticleTitle == title {
-			return output, span.Skip{Reason: fmt.Sprintf("BLOCKED_ATITLE %s", output.ID)}
+			return output, span.Skip{
+				Code:     span.SkipBlockedArticleTitle,
+				RecordID: output.ID,
+				Field:    "title",
+				Value:    title,
+			}
 		}
 	}
 	for _, p := range ArticleTitleCleanerPatterns {
@@ -328,9 +418,10 @@ func (doc *Document) ToIntermediateSchema() (*finc.IntermediateSchema, error) {
 	}
 	// refs. #8428, refs. #14286
 	if len(output.ArticleTitle) > 2400 {
-		return output, span.Skip{Reason: fmt.Sprintf("TOO_LONG_TITLE %s", output.ID)}
+		return output, span.Skip{Code: span.SkipTitleTooLong, RecordID: output.ID}
 	}
 	output.DOI = doc.DOI // refs #6312 and #10923, most // URL seem valid
+	output.RecordID = doc.DOI
 	output.Format = Formats.Lookup(doc.Type, DefaultFormat)
 	output.Genre = Genres.Lookup(doc.Type, "unknown")
 	output.ISBN = doc.ISBN
@@ -347,7 +438,7 @@ func (doc *Document) ToIntermediateSchema() (*finc.IntermediateSchema, error) {
 	if len(doc.ContainerTitle) > 0 {
 		output.JournalTitle = strutil.UnescapeTrim(doc.ContainerTitle[0])
 	} else {
-		return output, span.Skip{Reason: fmt.Sprintf("NO_JTITLE %s", output.ID)}
+		return output, span.Skip{Code: span.SkipMissingJournalTitle, RecordID: output.ID}
 	}
 	// refs #10864
 	if strings.HasPrefix(doc.Type, "book-") {
@@ -360,7 +451,7 @@ func (doc *Document) ToIntermediateSchema() (*finc.IntermediateSchema, error) {
 	// TODO(miku): do we need a config for these things?
 	// Maybe a generic filter (in js?) that will gather exclusion rules?
 	// if len(output.Authors) == 0 {
-	// 	return output, span.Skip{Reason: fmt.Sprintf("NO_AUTHORS %s", output.ID)}
+	// 	return output, span.Skip{Code: "NO_AUTHORS", RecordID: output.ID}
 	// }
 	pi := doc.PageInfo()
 	output.StartPage = fmt.Sprintf("%d", pi.StartPage)
@@ -375,7 +466,12 @@ func (doc *Document) ToIntermediateSchema() (*finc.IntermediateSchema, error) {
 	}
 	for _, s := range publisherBlacklist {
 		if doc.Publisher == s {
-			return output, span.Skip{Reason: fmt.Sprintf("BLACKLISTED_COLLECTION %s", output.ID)}
+			return output, span.Skip{
+				Code:     span.SkipBlacklistedCollection,
+				RecordID: output.ID,
+				Field:    "publisher",
+				Value:    s,
+			}
 		}
 	}
 	if doc.Publisher == "" {
@@ -1,6 +1,12 @@
 package crossref
 
-import "testing"
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/miku/span"
+)
 
 func TestDocumentCombinedTitle(t *testing.T) {
 	var cases = []struct {
@@ -50,3 +56,49 @@ func TestDocumentCombinedTitle(t *testing.T) {
 		}
 	}
 }
+
+func TestWithdrawnTombstone(t *testing.T) {
+	var cases = []struct {
+		about string
+		doc   string
+		want  []string // record ids of tombstones
+	}{
+		{"no update", `{"DOI": "10.1/a", "URL": "http://dx.doi.org/10.1/a"}`, nil},
+		{"withdrawal notice", `{"DOI": "10.1/a", "URL": "https://doi.org/10.1/a",
+			"update-to": [{"DOI": "10.1/b", "type": "withdrawal"}, {"DOI": "10.1/c", "type": "correction"}]}`, []string{"10.1/b"}},
+		{"withdrawn", `{"DOI": "10.1/a", "URL": "http://dx.doi.org/10.1/a",
+			"update-to": [{"DOI": "10.1/A", "type": "withdrawal", "label": "Withdrawal"}]}`, []string{"10.1/a"}},
+		{"withdrawn without url", `{"DOI": "10.1/a",
+			"update-to": [{"DOI": "10.1/a", "type": "withdrawal"}]}`, []string{"10.1/a"}},
+		{"retraction notice", `{"DOI": "10.1/a", "URL": "https://doi.org/10.1/a",
+			"update-to": [{"DOI": "10.1/b", "type": "retraction", "label": "Retraction"}]}`, []string{"10.1/b"}},
+		{"removal notice", `{"DOI": "10.1/a", "URL": "https://doi.org/10.1/a",
+			"update-to": [{"DOI": "10.1/b", "type": "removal"}, {"DOI": "10.1/c", "type": "withdrawal"}]}`, []string{"10.1/b", "10.1/c"}},
+		{"retracted", `{"DOI": "10.1/a", "URL": "http://dx.doi.org/10.1/a",
+			"update-to": [{"DOI": "10.1/a", "type": "retraction"}]}`, []string{"10.1/a"}},
+		{"updates without deletion", `{"DOI": "10.1/a", "URL": "https://doi.org/10.1/a",
+			"update-to": [{"DOI": "10.1/b", "type": "partial_retraction"}, {"DOI": "10.1/c", "type": "expression_of_concern"},
+			{"DOI": "10.1/d", "type": "erratum"}, {"DOI": "10.1/a", "type": "corrigendum"}]}`, nil},
+	}
+	for _, c := range cases {
+		var doc Document
+		if err := json.Unmarshal([]byte(c.doc), &doc); err != nil {
+			t.Fatal(err)
+		}
+		// The notices lack dates and are skipped, tombstones are not.
+		outputs, _ := doc.ToIntermediateSchemaList()
+		var got []string
+		for _, output := range outputs {
+			if output == nil || !output.Deleted {
+				continue
+			}
+			got = append(got, output.RecordID)
+			if want := span.GenFincID(SourceID, doc.LinkTo(output.RecordID)); output.ID != want {
+				t.Errorf("%s: got id %s, want %s", c.about, output.ID, want)
+			}
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: got tombstones %q, want %q", c.about, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package crossref
+
+import (
+	"github.com/miku/span/formats"
+	"github.com/miku/span/formats/finc"
+)
+
+func init() {
+	formats.Register(formats.Format{
+		Name:    "crossref",
+		Framing: formats.FramingNDJSON,
+		New:     func() interface{} { return new(Document) },
+		Signature: &formats.Signature{
+			Keys: []string{"DOI", "member"},
+		},
+		// The finc.id is derived from the URL, which tombstones lack, but
+		// it is a DOI resolver link.
+		IDKey: func(is *finc.IntermediateSchema) []string {
+			return append([]string{
+				"http://dx.doi.org/" + is.RecordID,
+				"https://doi.org/" + is.RecordID,
+			}, is.URL...)
+		},
+	})
+}
@@ -0,0 +1,307 @@
+// Package crosswalk converts MODS and Dublin Core records, with or without OAI
+// or METS envelope, using a crosswalk from XML paths to intermediate schema
+// fields. Onboarding a new repository should only require a new crosswalk
+// file, passed to span-import via -mapping.
+package crosswalk
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/miku/span"
+	"github.com/miku/span/formats"
+	"github.com/miku/span/formats/finc"
+	"github.com/segmentio/encoding/json"
+)
+
+var (
+	// DefaultMODS is used for MODS, if no crosswalk is configured.
+	DefaultMODS = MustLoadCrosswalk("assets/crosswalk/mods.json")
+	// DefaultOAIDC is used for Dublin Core, if no crosswalk is configured.
+	DefaultOAIDC = MustLoadCrosswalk("assets/crosswalk/oai_dc.json")
+)
+
+// headerPrefix marks paths, that are resolved against the OAI header instead
+// of the metadata.
+const headerPrefix = "header/"
+
+// Rule cleans up the values of a field. Values not matching Match are dropped,
+// then Pattern is replaced by Replace, which may refer to submatches like $1.
+// Values, that are empty after cleanup, are dropped, too.
+type Rule struct {
+	Match   string `json:"match,omitempty"`
+	Pattern string `json:"pattern,omitempty"`
+	Replace string `json:"replace,omitempty"`
+
+	match   *regexp.Regexp
+	pattern *regexp.Regexp
+}
+
+// Apply runs the rule on a list of values.
+func (r *Rule) Apply(values []string) (result []string) {
+	for _, v := range values {
+		if r.match != nil && !r.match.MatchString(v) {
+			continue
+		}
+		if r.pattern != nil {
+			v = strings.TrimSpace(r.pattern.ReplaceAllString(v, r.Replace))
+		}
+		if v != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// Crosswalk describes how to map a MODS or Dublin Core record to intermediate
+// schema fields, keyed by their JSON names, e.g.
+//
+//	{
+//	  "root": "dc",
+//	  "id": ["header/identifier"],
+//	  "constants": {"finc.source_id": ["1234"]},
+//	  "defaults": {"languages": ["deu"]},
+//	  "fields": {"rft.atitle": ["title"], "doi": ["identifier"]},
+//	  "cleanup": {"doi": [{"match": "10[.]", "pattern": "^.*?(10[.].*)$", "replace": "$1"}]}
+//	}
+//
+// Paths are relative to the root element, paths starting with "header/" are
+// relative to the OAI header, see Path for the syntax.
+type Crosswalk struct {
+	// Root is the local name of the metadata element, e.g. "mods" or "dc".
+	Root string `json:"root"`
+	// ID lists paths for the record identifier, first non-empty wins.
+	ID []string `json:"id"`
+	// Constants are set on every record.
+	Constants map[string][]string `json:"constants"`
+	// Defaults are used, if the paths of a field yield no value.
+	Defaults map[string][]string `json:"defaults"`
+	// Fields maps intermediate schema fields to paths.
+	Fields map[string][]string `json:"fields"`
+	// Cleanup lists rules per field, applied in order.
+	Cleanup map[string][]*Rule `json:"cleanup"`
+
+	id     []*Path
+	fields map[string][]*Path
+}
+
+// Validate checks fields, paths and patterns and prepares the crosswalk for
+// use.
+func (c *Crosswalk) Validate() error {
+	if c.Root == "" {
+		return fmt.Errorf("crosswalk: missing root element")
+	}
+	for _, fields := range []map[string][]string{c.Constants, c.Defaults, c.Fields} {
+		for k := range fields {
+			if !finc.IsField(k) {
+				return fmt.Errorf("crosswalk: unknown intermediate schema field: %s", k)
+			}
+		}
+	}
+	c.id = nil
+	for _, s := range c.ID {
+		p, err := ParsePath(s)
+		if err != nil {
+			return fmt.Errorf("crosswalk: id: %w", err)
+		}
+		c.id = append(c.id, p)
+	}
+	c.fields = make(map[string][]*Path)
+	for k, specs := range c.Fields {
+		for _, s := range specs {
+			p, err := ParsePath(s)
+			if err != nil {
+				return fmt.Errorf("crosswalk: %s: %w", k, err)
+			}
+			c.fields[k] = append(c.fields[k], p)
+		}
+	}
+	for k, rules := range c.Cleanup {
+		if !finc.IsField(k) {
+			return fmt.Errorf("crosswalk: unknown intermediate schema field: %s", k)
+		}
+		for _, r := range rules {
+			var err error
+			if r.Match != "" {
+				if r.match, err = regexp.Compile(r.Match); err != nil {
+					return fmt.Errorf("crosswalk: %s: %w", k, err)
+				}
+			}
+			if r.Pattern != "" {
+				if r.pattern, err = regexp.Compile(r.Pattern); err != nil {
+					return fmt.Errorf("crosswalk: %s: %w", k, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// SourceID returns the source id set by the crosswalk, if any.
+func (c *Crosswalk) SourceID() string {
+	for _, v := range c.Constants["finc.source_id"] {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// ReadCrosswalk reads and validates a JSON crosswalk.
+func ReadCrosswalk(r io.Reader) (*Crosswalk, error) {
+	var c Crosswalk
+	if err := json.NewDecoder(r).Decode(&c); err != nil {
+		return nil, err
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// MustLoadCrosswalk loads a crosswalk from the embedded assets and panics on
+// failure.
+func MustLoadCrosswalk(path string) *Crosswalk {
+	f, err := span.Static.Open(path)
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	c, err := ReadCrosswalk(f)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// values returns the values of a path, resolved against the OAI record or the
+// metadata.
+func values(p *Path, spec string, n, root *Node) []string {
+	if strings.HasPrefix(spec, headerPrefix) {
+		if n.Name != "record" {
+			return nil
+		}
+		return p.Values(n)
+	}
+	return p.Values(root)
+}
+
+// Convert applies the crosswalk to a record, which may be an OAI record, a
+// METS document or the bare metadata element.
+func (c *Crosswalk) Convert(n *Node) (*finc.IntermediateSchema, error) {
+	var (
+		output = finc.NewIntermediateSchema()
+		header *Node
+		root   = n.Find(c.Root)
+	)
+	if n.Name == "record" {
+		for _, child := range n.Children {
+			if child.Name == "header" {
+				header = child
+				break
+			}
+		}
+	}
+	for i, p := range c.id {
+		if vs := values(p, c.ID[i], n, root); len(vs) > 0 {
+			output.RecordID = vs[0]
+			break
+		}
+	}
+	for _, k := range sortedKeys(c.Constants) {
+		if err := output.SetField(k, c.Constants[k]...); err != nil {
+			return output, err
+		}
+	}
+	if output.SourceID == "" {
+		return output, formats.MissingSourceID(output.RecordID)
+	}
+	if header != nil && header.Attr["status"] == "deleted" {
+		if output.RecordID == "" {
+			return output, span.Skip{Code: span.SkipMissingID, Field: "id"}
+		}
+		return finc.NewTombstone(span.GenFincID(output.SourceID, output.RecordID), output.SourceID, output.RecordID), nil
+	}
+	keys := sortedKeys(c.Fields)
+	for _, k := range sortedKeys(c.Defaults) {
+		if _, ok := c.Fields[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		var vs []string
+		for i, p := range c.fields[k] {
+			vs = append(vs, values(p, c.Fields[k][i], n, root)...)
+		}
+		for _, r := range c.Cleanup[k] {
+			vs = r.Apply(vs)
+		}
+		if k == "languages" {
+			vs = languageCodes(vs)
+		}
+		if len(vs) == 0 {
+			vs = c.Defaults[k]
+		}
+		if err := output.SetField(k, vs...); err != nil {
+			if k != "x.date" {
+				return output, err
+			}
+			return output, span.Skip{
+				Code:     span.SkipInvalidDate,
+				RecordID: output.RecordID,
+				Field:    k,
+				Reason:   err.Error(),
+			}
+		}
+	}
+	if output.RecordID == "" {
+		return output, span.Skip{Code: span.SkipMissingID, Field: "id"}
+	}
+	output.ID = span.GenFincID(output.SourceID, output.RecordID)
+	if output.Date.IsZero() {
+		return output, span.Skip{Code: span.SkipMissingDate, RecordID: output.RecordID}
+	}
+	return output, nil
+}
+
+// sortedKeys makes conversions deterministic.
+func sortedKeys(m map[string][]string) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// languageCodes returns three letter codes for language names or codes, like
+// "en", "en-US", "ger" or "German"; unknown languages are dropped.
+func languageCodes(vs []string) (result []string) {
+	for _, v := range vs {
+		if code := formats.LanguageCode(v); code != "" {
+			result = append(result, code)
+		}
+	}
+	return result
+}
+
+// Record is a MODS or Dublin Core record, converted with a crosswalk.
+type Record struct {
+	Node
+	crosswalk *Crosswalk
+}
+
+// UnmarshalXML decodes the record.
+func (r *Record) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
+	return r.Node.UnmarshalXML(dec, start)
+}
+
+// ToIntermediateSchema converts a record using the configured crosswalk.
+func (r *Record) ToIntermediateSchema() (*finc.IntermediateSchema, error) {
+	return r.crosswalk.Convert(&r.Node)
+}
@@ -0,0 +1,212 @@
+package crosswalk
+
+import (
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miku/span"
+	"github.com/miku/span/formats/finc"
+)
+
+const modsRecord = `<record xmlns="http://www.openarchives.org/OAI/2.0/">
+  <header><identifier>oai:example.org:1</identifier></header>
+  <metadata>
+    <mets:mets xmlns:mets="http://www.loc.gov/METS/"><mets:dmdSec><mets:mdWrap><mets:xmlData>
+    <mods xmlns="http://www.loc.gov/mods/v3">
+      <titleInfo><title>On <i>tags</i></title><subTitle>A study</subTitle></titleInfo>
+      <titleInfo type="alternative"><title>Tags</title></titleInfo>
+      <name type="personal">
+        <namePart type="family">Doe</namePart><namePart type="given">Jane</namePart>
+      </name>
+      <name type="corporate"><namePart>Tag Society</namePart></name>
+      <originInfo><publisher>Example Press</publisher><dateIssued>2019-05</dateIssued></originInfo>
+      <language><languageTerm type="code" authority="iso639-2b">ger</languageTerm></language>
+      <identifier type="doi">https://doi.org/10.1000/tags.1</identifier>
+      <relatedItem type="host">
+        <titleInfo><title>Journal of Tags</title></titleInfo>
+        <identifier type="issn">0001-0002</identifier>
+        <part>
+          <detail type="volume"><number>12</number></detail>
+          <extent unit="pages"><start>1</start><end>10</end></extent>
+        </part>
+      </relatedItem>
+      <subject><topic>Tags</topic></subject>
+    </mods>
+    </mets:xmlData></mets:mdWrap></mets:dmdSec></mets:mets>
+  </metadata>
+</record>`
+
+func TestMODS(t *testing.T) {
+	r := &Record{crosswalk: DefaultMODS}
+	if err := xml.Unmarshal([]byte(modsRecord), r); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	// Without source id, there is no finc.id.
+	if _, err := r.ToIntermediateSchema(); err == nil || err.(span.Skip).Code != span.SkipMissingID {
+		t.Fatalf("got %v, want %s", err, span.SkipMissingID)
+	}
+	c := *DefaultMODS
+	c.Constants = map[string][]string{"finc.source_id": {"1"}}
+	for k, v := range DefaultMODS.Constants {
+		c.Constants[k] = v
+	}
+	r.crosswalk = &c
+	output, err := r.ToIntermediateSchema()
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	want := &finc.IntermediateSchema{
+		ID:              span.GenFincID("1", "oai:example.org:1"),
+		SourceID:        "1",
+		Format:          "ElectronicArticle",
+		RecordID:        "oai:example.org:1",
+		RefType:         "EJOUR",
+		ArticleTitle:    "On tags",
+		ArticleSubtitle: "A study",
+		Authors:         []finc.Author{{Name: "Doe, Jane"}},
+		DOI:             "10.1000/tags.1",
+		EndPage:         "10",
+		Genre:           "article",
+		ISSN:            []string{"0001-0002"},
+		JournalTitle:    "Journal of Tags",
+		Languages:       []string{"deu"},
+		Publishers:      []string{"Example Press"},
+		RawDate:         "2019-05-01",
+		Date:            time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC),
+		StartPage:       "1",
+		Subjects:        []string{"Tags"},
+		Version:         finc.IntermediateSchemaVersion,
+		Volume:          "12",
+	}
+	if !reflect.DeepEqual(output, want) {
+		t.Errorf("got %+v, want %+v", output, want)
+	}
+}
+
+func TestDublinCore(t *testing.T) {
+	doc := `<oai_dc:dc xmlns:oai_dc="http://www.openarchives.org/OAI/2.0/oai_dc/"
+		xmlns:dc="http://purl.org/dc/elements/1.1/">
+	  <dc:title>Hello</dc:title>
+	  <dc:creator>Doe, Jane</dc:creator>
+	  <dc:date>2001</dc:date>
+	  <dc:identifier>https://example.org/1</dc:identifier>
+	  <dc:identifier>doi:10.1000/1 (PDF)</dc:identifier>
+	  <dc:source>Journal of Tags; ISSN 1234-567X</dc:source>
+	  <dc:language>en</dc:language>
+	</oai_dc:dc>`
+	c, err := ReadCrosswalk(strings.NewReader(`{
+	  "root": "dc",
+	  "id": ["identifier"],
+	  "constants": {"finc.source_id": ["1"]},
+	  "defaults": {"rft.pub": ["Example Repository"], "x.subjects": ["General"]},
+	  "fields": {
+	    "rft.atitle": ["title"], "authors": ["creator"], "x.date": ["date"],
+	    "doi": ["identifier"], "rft.issn": ["source"], "languages": ["language"],
+	    "x.subjects": ["subject"]
+	  },
+	  "cleanup": {
+	    "doi": [{"match": "10[.]", "pattern": "^.*?(10[.][0-9]+/\\S*).*$", "replace": "$1"}],
+	    "rft.issn": [{"pattern": "^.*([0-9]{4}-[0-9]{3}[0-9X]).*$", "replace": "$1"}]
+	  }
+	}`))
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	r := &Record{crosswalk: c}
+	if err := xml.Unmarshal([]byte(doc), r); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	output, err := r.ToIntermediateSchema()
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	want := &finc.IntermediateSchema{
+		ID:           span.GenFincID("1", "https://example.org/1"),
+		RecordID:     "https://example.org/1",
+		SourceID:     "1",
+		ArticleTitle: "Hello",
+		Authors:      []finc.Author{{Name: "Doe, Jane"}},
+		DOI:          "10.1000/1",
+		ISSN:         []string{"1234-567X"},
+		Languages:    []string{"eng"},
+		Publishers:   []string{"Example Repository"},
+		RawDate:      "2001-01-01",
+		Date:         output.Date,
+		Subjects:     []string{"General"},
+		Version:      finc.IntermediateSchemaVersion,
+	}
+	if !reflect.DeepEqual(output, want) {
+		t.Errorf("got %+v, want %+v", output, want)
+	}
+}
+
+func TestDeleted(t *testing.T) {
+	doc := `<record><header status="deleted"><identifier>oai:example.org:1</identifier></header></record>`
+	c := *DefaultOAIDC
+	c.Constants = map[string][]string{"finc.source_id": {"1"}}
+	r := &Record{crosswalk: &c}
+	if err := xml.Unmarshal([]byte(doc), r); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	output, err := r.ToIntermediateSchema()
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	want := finc.NewTombstone(span.GenFincID("1", "oai:example.org:1"), "1", "oai:example.org:1")
+	if !reflect.DeepEqual(output, want) {
+		t.Errorf("got %+v, want %+v", output, want)
+	}
+}
+
+func TestReadCrosswalk(t *testing.T) {
+	var cases = []struct {
+		about string
+		input string
+	}{
+		{"missing root", `{"fields": {"rft.atitle": ["title"]}}`},
+		{"unknown field", `{"root": "dc", "fields": {"rft.nope": ["title"]}}`},
+		{"bad path", `{"root": "dc", "fields": {"rft.atitle": ["title[type=main]"]}}`},
+		{"bad pattern", `{"root": "dc", "cleanup": {"doi": [{"match": "("}]}}`},
+	}
+	for _, c := range cases {
+		if _, err := ReadCrosswalk(strings.NewReader(c.input)); err == nil {
+			t.Errorf("%s: got nil, want error", c.about)
+		}
+	}
+}
+
+func TestPath(t *testing.T) {
+	doc := `<mods>
+	  <name type="personal"><namePart type="family">Doe</namePart><namePart type="given">Jane</namePart></name>
+	  <name type="personal"><namePart>Roe, R.</namePart></name>
+	  <location><url access="raw object">https://example.org/1.pdf</url></location>
+	  <titleInfo><title>H<sub>2</sub>O</title></titleInfo>
+	</mods>`
+	var n Node
+	if err := xml.Unmarshal([]byte(doc), &n); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	var cases = []struct {
+		path string
+		want []string
+	}{
+		{"name[@type=personal]/namePart[@type=family]+namePart[@type=given]", []string{"Doe, Jane"}},
+		{"name[@type=personal]/namePart[@type=]", []string{"Roe, R."}},
+		{"name/*", []string{"Doe", "Jane", "Roe, R."}},
+		{"location/url/@access", []string{"raw object"}},
+		{"titleInfo/title", []string{"H2O"}},
+		{"titleInfo/subTitle", nil},
+	}
+	for _, c := range cases {
+		p, err := ParsePath(c.path)
+		if err != nil {
+			t.Fatalf("%s: got %v, want nil", c.path, err)
+		}
+		if got := p.Values(&n); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: got %q, want %q", c.path, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package crosswalk
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// Node is a namespace agnostic XML element, kept generic, so crosswalks can
+// address any element or attribute by path.
+type Node struct {
+	Name     string
+	Attr     map[string]string
+	Children []*Node
+	text     string // all text, including descendants
+}
+
+// UnmarshalXML reads an element and all its children.
+func (n *Node) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	n.Name = start.Name.Local
+	n.Attr = make(map[string]string)
+	for _, attr := range start.Attr {
+		n.Attr[attr.Name.Local] = attr.Value
+	}
+	var sb strings.Builder
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch v := tok.(type) {
+		case xml.StartElement:
+			child := new(Node)
+			if err := child.UnmarshalXML(d, v); err != nil {
+				return err
+			}
+			n.Children = append(n.Children, child)
+			sb.WriteString(child.text)
+		case xml.CharData:
+			sb.Write(v)
+		case xml.EndElement:
+			n.text = sb.String()
+			return nil
+		}
+	}
+}
+
+// Text returns the text of the element including its children in document
+// order, like XPath string(), with whitespace collapsed.
+func (n *Node) Text() string {
+	return strings.Join(strings.Fields(n.text), " ")
+}
+
+// Find returns the node itself or the first descendant with a given name.
+func (n *Node) Find(name string) *Node {
+	if n.Name == name {
+		return n
+	}
+	for _, c := range n.Children {
+		if v := c.Find(name); v != nil {
+			return v
+		}
+	}
+	return nil
+}
+
+// step is a path segment, an element name with optional attribute
+// predicates, like identifier[@type=doi].
+type step struct {
+	name  string
+	preds [][2]string
+}
+
+// matches checks name and predicates; an empty predicate value matches a
+// missing attribute, too.
+func (s step) matches(n *Node) bool {
+	if s.name != "*" && s.name != n.Name {
+		return false
+	}
+	for _, p := range s.preds {
+		if !strings.EqualFold(n.Attr[p[0]], p[1]) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseStep parses a segment like "name[@type=personal][@usage=primary]".
+func parseStep(s string) (step, error) {
+	i := strings.Index(s, "[")
+	if i == -1 {
+		return step{name: s}, nil
+	}
+	st := step{name: s[:i]}
+	for rest := s[i:]; rest != ""; {
+		j := strings.Index(rest, "]")
+		if !strings.HasPrefix(rest, "[@") || j == -1 {
+			return st, fmt.Errorf("invalid predicate in %q", s)
+		}
+		kv := strings.SplitN(rest[2:j], "=", 2)
+		if len(kv) != 2 {
+			return st, fmt.Errorf("invalid predicate in %q", s)
+		}
+		st.preds = append(st.preds, [2]string{kv[0], strings.Trim(kv[1], `'"`)})
+		rest = rest[j+1:]
+	}
+	return st, nil
+}
+
+// Path selects values from a node. Segments are separated by slashes and
+// are element names with optional attribute predicates; the last segment
+// may select an attribute (@name) or join the first values of alternative
+// subpaths (family+given), e.g.
+//
+//	titleInfo[@type=]/title
+//	identifier[@type=doi]
+//	location/url/@access
+//	name[@type=personal]/namePart[@type=family]+namePart[@type=given]
+type Path struct {
+	steps []step
+	attr  string
+	join  []*Path
+}
+
+// ParsePath parses a path expression.
+func ParsePath(s string) (*Path, error) {
+	if s == "" {
+		return nil, fmt.Errorf("empty path")
+	}
+	p := &Path{}
+	segments := strings.Split(s, "/")
+	last := segments[len(segments)-1]
+	switch {
+	case strings.HasPrefix(last, "@"):
+		p.attr = last[1:]
+		segments = segments[:len(segments)-1]
+	case strings.Contains(last, "+"):
+		for _, alt := range strings.Split(last, "+") {
+			q, err := ParsePath(alt)
+			if err != nil {
+				return nil, err
+			}
+			p.join = append(p.join, q)
+		}
+		segments = segments[:len(segments)-1]
+	}
+	for _, seg := range segments {
+		st, err := parseStep(seg)
+		if err != nil {
+			return nil, err
+		}
+		p.steps = append(p.steps, st)
+	}
+	return p, nil
+}
+
+// nodes returns the nodes at the end of the element steps.
+func (p *Path) nodes(n *Node) []*Node {
+	current := []*Node{n}
+	for _, st := range p.steps {
+		var next []*Node
+		for _, c := range current {
+			for _, child := range c.Children {
+				if st.matches(child) {
+					next = append(next, child)
+				}
+			}
+		}
+		current = next
+	}
+	return current
+}
+
+// Values returns all non-empty values, the path selects below n.
+func (p *Path) Values(n *Node) (result []string) {
+	if n == nil {
+		return nil
+	}
+	for _, m := range p.nodes(n) {
+		var v string
+		switch {
+		case p.attr != "":
+			v = strings.TrimSpace(m.Attr[p.attr])
+		case len(p.join) > 0:
+			var parts []string
+			for _, q := range p.join {
+				if vs := q.Values(m); len(vs) > 0 {
+					parts = append(parts, vs[0])
+				}
+			}
+			v = strings.Join(parts, ", ")
+		default:
+			v = m.Text()
+		}
+		if v != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
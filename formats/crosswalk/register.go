@@ -0,0 +1,50 @@
+package crosswalk
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/miku/span/formats"
+)
+
+// configure reads a crosswalk and returns a factory for records using it.
+// The crosswalk must set a source id.
+func configure(r io.Reader) (formats.Factory, error) {
+	c, err := ReadCrosswalk(r)
+	if err != nil {
+		return nil, err
+	}
+	if c.SourceID() == "" {
+		return nil, fmt.Errorf("crosswalk: %w, add it to constants", formats.ErrMissingSourceID)
+	}
+	return func() interface{} { return &Record{crosswalk: c} }, nil
+}
+
+func init() {
+	formats.Register(formats.Format{
+		Name:     "mods",
+		Framing:  formats.FramingXML,
+		New:      func() interface{} { return &Record{crosswalk: DefaultMODS} },
+		Elements: []string{"record", "mets", "mods"},
+		Signature: &formats.Signature{
+			Elements:   []string{"mods"},
+			Namespaces: []string{formats.NamespaceMODS},
+			Generic:    true,
+		},
+		Configure:       configure,
+		RequiresMapping: true,
+	})
+	formats.Register(formats.Format{
+		Name:     "oai_dc",
+		Framing:  formats.FramingXML,
+		New:      func() interface{} { return &Record{crosswalk: DefaultOAIDC} },
+		Elements: []string{"record", "dc"},
+		Signature: &formats.Signature{
+			Elements:   []string{"dc"},
+			Namespaces: []string{formats.NamespaceOAIDC},
+			Generic:    true,
+		},
+		Configure:       configure,
+		RequiresMapping: true,
+	})
+}
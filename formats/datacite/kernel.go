@@ -0,0 +1,127 @@
+package datacite
+
+import (
+	"encoding/xml"
+	"strings"
+
+	"github.com/miku/span/formats"
+	"github.com/miku/span/formats/finc"
+)
+
+// Namespace of the metadata kernel 4.x.
+const Namespace = "http://datacite.org/schema/kernel-4"
+
+// Record is a resource in DataCite XML, e.g. from a metadata store or an
+// OAI-PMH oai_datacite harvest.
+type Record struct {
+	XMLName    xml.Name `xml:"resource"`
+	Identifier struct {
+		Type  string `xml:"identifierType,attr"`
+		Value string `xml:",chardata"`
+	} `xml:"identifier"`
+	Creators []struct {
+		CreatorName struct {
+			NameType string `xml:"nameType,attr"`
+			Value    string `xml:",chardata"`
+		} `xml:"creatorName"`
+		GivenName       string `xml:"givenName"`
+		FamilyName      string `xml:"familyName"`
+		NameIdentifiers []struct {
+			Scheme    string `xml:"nameIdentifierScheme,attr"`
+			SchemeURI string `xml:"schemeURI,attr"`
+			Value     string `xml:",chardata"`
+		} `xml:"nameIdentifier"`
+	} `xml:"creators>creator"`
+	Titles []struct {
+		TitleType string `xml:"titleType,attr"`
+		Lang      string `xml:"lang,attr"`
+		Value     string `xml:",chardata"`
+	} `xml:"titles>title"`
+	Publisher       string `xml:"publisher"`
+	PublicationYear string `xml:"publicationYear"`
+	ResourceType    struct {
+		General string `xml:"resourceTypeGeneral,attr"`
+		Value   string `xml:",chardata"`
+	} `xml:"resourceType"`
+	Subjects []struct {
+		Scheme string `xml:"subjectScheme,attr"`
+		Value  string `xml:",chardata"`
+	} `xml:"subjects>subject"`
+	Dates []struct {
+		DateType string `xml:"dateType,attr"`
+		Value    string `xml:",chardata"`
+	} `xml:"dates>date"`
+	Language   string `xml:"language"`
+	RightsList []struct {
+		RightsURI        string `xml:"rightsURI,attr"`
+		RightsIdentifier string `xml:"rightsIdentifier,attr"`
+		Value            string `xml:",chardata"`
+	} `xml:"rightsList>rights"`
+	Descriptions []struct {
+		DescriptionType string `xml:"descriptionType,attr"`
+		Value           string `xml:",chardata"`
+	} `xml:"descriptions>description"`
+	Version string `xml:"version"`
+	source  *formats.Source
+}
+
+// Resource returns the XML record in the structure of the REST API.
+func (r *Record) Resource() *Resource {
+	res := &Resource{
+		Publisher:       Publisher(r.Publisher),
+		PublicationYear: Year(strings.TrimSpace(r.PublicationYear)),
+		Language:        r.Language,
+		Types: Types{
+			ResourceTypeGeneral: r.ResourceType.General,
+			ResourceType:        strings.TrimSpace(r.ResourceType.Value),
+		},
+		Version: strings.TrimSpace(r.Version),
+	}
+	if strings.EqualFold(r.Identifier.Type, "DOI") {
+		res.DOI = strings.TrimSpace(r.Identifier.Value)
+	}
+	for _, c := range r.Creators {
+		creator := Creator{
+			Name:       strings.TrimSpace(c.CreatorName.Value),
+			NameType:   c.CreatorName.NameType,
+			GivenName:  c.GivenName,
+			FamilyName: c.FamilyName,
+		}
+		for _, id := range c.NameIdentifiers {
+			creator.NameIdentifiers = append(creator.NameIdentifiers, NameIdentifier{
+				NameIdentifier:       strings.TrimSpace(id.Value),
+				NameIdentifierScheme: id.Scheme,
+				SchemeURI:            id.SchemeURI,
+			})
+		}
+		res.Creators = append(res.Creators, creator)
+	}
+	for _, t := range r.Titles {
+		res.Titles = append(res.Titles, Title{Title: t.Value, TitleType: t.TitleType, Lang: t.Lang})
+	}
+	for _, s := range r.Subjects {
+		res.Subjects = append(res.Subjects, Subject{Subject: s.Value, SubjectScheme: s.Scheme})
+	}
+	for _, d := range r.Dates {
+		res.Dates = append(res.Dates, Date{Date: d.Value, DateType: d.DateType})
+	}
+	for _, rights := range r.RightsList {
+		res.RightsList = append(res.RightsList, Rights{
+			Rights:           strings.TrimSpace(rights.Value),
+			RightsURI:        rights.RightsURI,
+			RightsIdentifier: rights.RightsIdentifier,
+		})
+	}
+	for _, d := range r.Descriptions {
+		res.Descriptions = append(res.Descriptions, Description{
+			Description:     strings.Join(strings.Fields(d.Value), " "),
+			DescriptionType: d.DescriptionType,
+		})
+	}
+	return res
+}
+
+// ToIntermediateSchema converts the record like a REST API document.
+func (r *Record) ToIntermediateSchema() (*finc.IntermediateSchema, error) {
+	return r.Resource().ToIntermediateSchema(r.source)
+}
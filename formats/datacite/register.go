@@ -0,0 +1,52 @@
+package datacite
+
+import (
+	"io"
+
+	"github.com/miku/span/formats"
+	"github.com/miku/span/formats/finc"
+)
+
+func init() {
+	formats.Register(formats.Format{
+		Name:    "datacite",
+		Framing: formats.FramingNDJSON,
+		New:     func() interface{} { return &Document{} },
+		Signature: &formats.Signature{
+			Keys:     []string{"id", "type", "attributes"},
+			Contains: []string{`"dois"`},
+		},
+		RequiresMapping: true,
+		IDKey:           idKey,
+		Configure: func(r io.Reader) (formats.Factory, error) {
+			s, err := formats.ReadSource(r)
+			if err != nil {
+				return nil, err
+			}
+			return func() interface{} { return &Document{source: s} }, nil
+		},
+	})
+	formats.Register(formats.Format{
+		Name:    "datacite-xml",
+		Framing: formats.FramingXML,
+		New:     func() interface{} { return &Record{} },
+		Signature: &formats.Signature{
+			Elements:   []string{"resource"},
+			Namespaces: []string{Namespace},
+		},
+		RequiresMapping: true,
+		IDKey:           idKey,
+		Configure: func(r io.Reader) (formats.Factory, error) {
+			s, err := formats.ReadSource(r)
+			if err != nil {
+				return nil, err
+			}
+			return func() interface{} { return &Record{source: s} }, nil
+		},
+	})
+}
+
+// idKey returns the link the finc.id is derived from, the record id is the DOI.
+func idKey(is *finc.IntermediateSchema) []string {
+	return []string{"https://doi.org/" + is.RecordID}
+}
@@ -0,0 +1,343 @@
+// Package datacite implements DataCite metadata as input format, both as
+// returned by the REST API (one JSON document per line) and as XML following
+// the metadata kernel 4.x: https://schema.datacite.org/meta/kernel-4/
+package datacite
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/miku/span"
+	"github.com/miku/span/assetutil"
+	"github.com/miku/span/formats"
+	"github.com/miku/span/formats/finc"
+	"github.com/segmentio/encoding/json"
+)
+
+const (
+	// DefaultGenre is used for unknown resource types.
+	DefaultGenre = "unknown"
+	// DefaultFormat is used for unknown resource types.
+	DefaultFormat = "ElectronicResourceRemoteAccess"
+)
+
+var (
+	// Formats maps resourceTypeGeneral to finc.format.
+	Formats = assetutil.MustLoadStringMap("assets/datacite/formats.json")
+	// Genres maps resourceTypeGeneral to rft.genre.
+	Genres = assetutil.MustLoadStringMap("assets/datacite/genres.json")
+	// RefTypes maps resourceTypeGeneral to ris.type.
+	RefTypes = assetutil.MustLoadStringMap("assets/datacite/reftypes.json")
+
+	orcidPattern = regexp.MustCompile(`[0-9]{4}-[0-9]{4}-[0-9]{4}-[0-9]{3}[0-9X]`)
+	yearPattern  = regexp.MustCompile(`^[12][0-9]{3}$`)
+)
+
+// NameIdentifier is an identifier of a creator, like an ORCID.
+type NameIdentifier struct {
+	NameIdentifier       string `json:"nameIdentifier"`
+	NameIdentifierScheme string `json:"nameIdentifierScheme"`
+	SchemeURI            string `json:"schemeUri"`
+}
+
+// Creator is a person or organization.
+type Creator struct {
+	Name            string           `json:"name"`
+	NameType        string           `json:"nameType"`
+	GivenName       string           `json:"givenName"`
+	FamilyName      string           `json:"familyName"`
+	NameIdentifiers []NameIdentifier `json:"nameIdentifiers"`
+}
+
+// ORCID returns the ORCID of a creator as URI or the empty string.
+func (c Creator) ORCID() string {
+	for _, id := range c.NameIdentifiers {
+		if !strings.EqualFold(id.NameIdentifierScheme, "ORCID") {
+			continue
+		}
+		if v := orcidPattern.FindString(id.NameIdentifier); v != "" {
+			return "https://orcid.org/" + v
+		}
+	}
+	return ""
+}
+
+// Author returns the creator as author.
+func (c Creator) Author() finc.Author {
+	author := finc.Author{
+		ID:        c.ORCID(),
+		FirstName: strings.TrimSpace(c.GivenName),
+		LastName:  strings.TrimSpace(c.FamilyName),
+	}
+	name := strings.TrimSpace(c.Name)
+	switch {
+	case c.NameType == "Organizational":
+		author.Corporate = name
+	case author.LastName == "" && strings.Contains(name, ","):
+		parts := strings.SplitN(name, ",", 2)
+		author.LastName = strings.TrimSpace(parts[0])
+		author.FirstName = strings.TrimSpace(parts[1])
+	case author.LastName == "":
+		author.Name = name
+	}
+	return author
+}
+
+// Title with an optional type, like Subtitle, AlternativeTitle or
+// TranslatedTitle; main titles have no type.
+type Title struct {
+	Title     string `json:"title"`
+	TitleType string `json:"titleType"`
+	Lang      string `json:"lang"`
+}
+
+// Subject is a keyword or classification.
+type Subject struct {
+	Subject       string `json:"subject"`
+	SubjectScheme string `json:"subjectScheme"`
+}
+
+// Date with a type, like Issued, Created or Available.
+type Date struct {
+	Date     string `json:"date"`
+	DateType string `json:"dateType"`
+}
+
+// Rights describes a license.
+type Rights struct {
+	Rights           string `json:"rights"`
+	RightsURI        string `json:"rightsUri"`
+	RightsIdentifier string `json:"rightsIdentifier"`
+}
+
+// Description, like an Abstract, Methods or TechnicalInfo.
+type Description struct {
+	Description     string `json:"description"`
+	DescriptionType string `json:"descriptionType"`
+}
+
+// Types holds the resource type, resourceTypeGeneral is controlled, the
+// others are free text or derived.
+type Types struct {
+	ResourceTypeGeneral string `json:"resourceTypeGeneral"`
+	ResourceType        string `json:"resourceType"`
+}
+
+// Container is the journal or series, a resource is part of.
+type Container struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Volume    string `json:"volume"`
+	Issue     string `json:"issue"`
+	FirstPage string `json:"firstPage"`
+	LastPage  string `json:"lastPage"`
+}
+
+// Publisher is a plain string in older API responses and an object in newer
+// ones, both are accepted.
+type Publisher string
+
+// UnmarshalJSON accepts a string or an object with a name.
+func (p *Publisher) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimSpace(b)
+	if bytes.HasPrefix(b, []byte("{")) {
+		var v struct {
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(b, &v); err != nil {
+			return err
+		}
+		*p = Publisher(v.Name)
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	*p = Publisher(s)
+	return nil
+}
+
+// Year is a publication year, a number or a string, depending on the source.
+type Year string
+
+// UnmarshalJSON accepts a number or a string.
+func (y *Year) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimSpace(b)
+	if bytes.Equal(b, []byte("null")) {
+		return nil
+	}
+	*y = Year(strings.Trim(string(b), `"`))
+	return nil
+}
+
+// Resource are the metadata of a DOI, as found in the attributes of the
+// REST API response. XML records are converted into this structure, too.
+type Resource struct {
+	DOI             string        `json:"doi"`
+	Creators        []Creator     `json:"creators"`
+	Titles          []Title       `json:"titles"`
+	Publisher       Publisher     `json:"publisher"`
+	Container       Container     `json:"container"`
+	PublicationYear Year          `json:"publicationYear"`
+	Subjects        []Subject     `json:"subjects"`
+	Dates           []Date        `json:"dates"`
+	Language        string        `json:"language"`
+	Types           Types         `json:"types"`
+	RightsList      []Rights      `json:"rightsList"`
+	Descriptions    []Description `json:"descriptions"`
+	Version         string        `json:"version"`
+	URL             string        `json:"url"`
+}
+
+// Title returns the first title of a given type, use the empty string for
+// the main title.
+func (r *Resource) Title(kind string) string {
+	for _, t := range r.Titles {
+		if t.TitleType == kind {
+			if v := strings.TrimSpace(t.Title); v != "" {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
+// Date returns the issue date, if given, otherwise the publication year.
+func (r *Resource) Date() (time.Time, error) {
+	for _, d := range r.Dates {
+		if d.DateType != "Issued" {
+			continue
+		}
+		// Dates may be ranges, like 2019-01/2019-12, take the start.
+		v := strings.TrimSpace(strings.SplitN(d.Date, "/", 2)[0])
+		for _, layout := range []string{"2006-01-02", "2006-01", "2006"} {
+			if len(v) < len(layout) {
+				continue
+			}
+			if t, err := time.Parse(layout, v[:len(layout)]); err == nil {
+				return t, nil
+			}
+		}
+	}
+	year := strings.TrimSpace(string(r.PublicationYear))
+	if !yearPattern.MatchString(year) {
+		return time.Time{}, fmt.Errorf("invalid publication year: %q", year)
+	}
+	return time.Parse("2006", year)
+}
+
+// Licenses returns license URIs, or names, if there is no URI.
+func (r *Resource) Licenses() (result []string) {
+	for _, rights := range r.RightsList {
+		switch {
+		case rights.RightsURI != "":
+			result = append(result, strings.TrimSpace(rights.RightsURI))
+		case rights.Rights != "":
+			result = append(result, strings.TrimSpace(rights.Rights))
+		}
+	}
+	return result
+}
+
+// Link returns the landing page of the DOI.
+func (r *Resource) Link() string {
+	return "https://doi.org/" + r.DOI
+}
+
+// ToIntermediateSchema converts a resource, the record id is the DOI.
+func (r *Resource) ToIntermediateSchema(source *formats.Source) (*finc.IntermediateSchema, error) {
+	output := finc.NewIntermediateSchema()
+	r.DOI = strings.ToLower(strings.TrimSpace(r.DOI))
+	if r.DOI == "" {
+		return output, span.Skip{Code: span.SkipMissingDOI}
+	}
+	output.DOI = r.DOI
+	output.RecordID = r.DOI
+	if err := source.Apply(output, r.Link()); err != nil {
+		return output, err
+	}
+	output.ArticleTitle = r.Title("")
+	if output.ArticleTitle == "" && len(r.Titles) > 0 {
+		output.ArticleTitle = strings.TrimSpace(r.Titles[0].Title)
+	}
+	if output.ArticleTitle == "" {
+		return output, span.Skip{Code: span.SkipMissingTitle, RecordID: r.DOI}
+	}
+	output.ArticleSubtitle = r.Title("Subtitle")
+	date, err := r.Date()
+	if err != nil {
+		return output, span.Skip{
+			Code:     span.SkipMissingDate,
+			RecordID: r.DOI,
+			Field:    "publicationYear",
+			Value:    string(r.PublicationYear),
+		}
+	}
+	output.Date = date
+	output.RawDate = date.Format("2006-01-02")
+
+	kind := r.Types.ResourceTypeGeneral
+	output.Format = Formats.Lookup(kind, DefaultFormat)
+	output.Genre = Genres.Lookup(kind, DefaultGenre)
+	output.RefType = RefTypes.Lookup(kind, "GEN")
+	output.Type = kind
+	switch output.Genre {
+	case "book":
+		output.BookTitle = output.ArticleTitle
+	case "bookitem", "proceeding":
+		output.BookTitle = strings.TrimSpace(r.Container.Title)
+	default:
+		output.JournalTitle = strings.TrimSpace(r.Container.Title)
+	}
+	output.Volume = r.Container.Volume
+	output.Issue = r.Container.Issue
+	output.StartPage = r.Container.FirstPage
+	output.EndPage = r.Container.LastPage
+	if output.StartPage != "" && output.EndPage != "" {
+		output.Pages = output.StartPage + "-" + output.EndPage
+	}
+	for _, c := range r.Creators {
+		output.Authors = append(output.Authors, c.Author())
+	}
+	if p := strings.TrimSpace(string(r.Publisher)); p != "" && p != "(:unav)" {
+		output.Publishers = append(output.Publishers, p)
+	}
+	for _, s := range r.Subjects {
+		if v := strings.TrimSpace(s.Subject); v != "" {
+			output.Subjects = append(output.Subjects, v)
+		}
+	}
+	for _, d := range r.Descriptions {
+		if d.DescriptionType == "Abstract" {
+			output.Abstract = strings.TrimSpace(d.Description)
+			break
+		}
+	}
+	if code := formats.LanguageCode(r.Language); code != "" {
+		output.Languages = []string{code}
+	}
+	output.License = r.Licenses()
+	output.Edition = r.Version
+	output.URL = []string{r.Link()}
+	return output, nil
+}
+
+// Document is a single DOI from the REST API, e.g. a line of a data dump.
+type Document struct {
+	ID         string   `json:"id"`
+	Type       string   `json:"type"`
+	Attributes Resource `json:"attributes"`
+	source     *formats.Source
+}
+
+// ToIntermediateSchema converts the attributes, DOI fall back to the id.
+func (doc *Document) ToIntermediateSchema() (*finc.IntermediateSchema, error) {
+	if doc.Attributes.DOI == "" {
+		doc.Attributes.DOI = doc.ID
+	}
+	return doc.Attributes.ToIntermediateSchema(doc.source)
+}
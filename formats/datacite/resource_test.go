@@ -0,0 +1,151 @@
+package datacite
+
+import (
+	"encoding/xml"
+	"reflect"
+	"testing"
+
+	"github.com/miku/span"
+	"github.com/miku/span/formats"
+	"github.com/miku/span/formats/finc"
+	"github.com/segmentio/encoding/json"
+)
+
+const document = `{
+  "id": "10.5281/zenodo.123",
+  "type": "dois",
+  "attributes": {
+    "doi": "10.5281/ZENODO.123",
+    "creators": [
+      {"name": "Doe, Jane", "nameType": "Personal", "givenName": "Jane", "familyName": "Doe",
+       "nameIdentifiers": [{"nameIdentifier": "https://orcid.org/0000-0002-1825-0097", "nameIdentifierScheme": "ORCID"}]},
+      {"name": "Smith, John", "nameType": "Personal"},
+      {"name": "Tag Lab", "nameType": "Organizational"}
+    ],
+    "titles": [{"title": "Measurements"}, {"title": "A dataset", "titleType": "Subtitle"}],
+    "publisher": {"name": "Zenodo"},
+    "publicationYear": 2019,
+    "subjects": [{"subject": "tags"}],
+    "dates": [{"date": "2019-05-02", "dateType": "Issued"}],
+    "language": "en",
+    "types": {"resourceTypeGeneral": "Dataset"},
+    "rightsList": [{"rights": "Creative Commons Attribution 4.0", "rightsUri": "https://creativecommons.org/licenses/by/4.0/"}],
+    "descriptions": [{"description": "About tags.", "descriptionType": "Abstract"}],
+    "version": "1.0"
+  }
+}`
+
+const record = `<resource xmlns="http://datacite.org/schema/kernel-4">
+  <identifier identifierType="DOI">10.5281/zenodo.123</identifier>
+  <creators>
+    <creator>
+      <creatorName nameType="Personal">Doe, Jane</creatorName>
+      <givenName>Jane</givenName>
+      <familyName>Doe</familyName>
+      <nameIdentifier nameIdentifierScheme="ORCID" schemeURI="https://orcid.org">0000-0002-1825-0097</nameIdentifier>
+    </creator>
+    <creator><creatorName>Smith, John</creatorName></creator>
+    <creator><creatorName nameType="Organizational">Tag Lab</creatorName></creator>
+  </creators>
+  <titles>
+    <title xml:lang="en">Measurements</title>
+    <title titleType="Subtitle">A dataset</title>
+  </titles>
+  <publisher>Zenodo</publisher>
+  <publicationYear>2019</publicationYear>
+  <resourceType resourceTypeGeneral="Dataset">Measurements</resourceType>
+  <subjects><subject>tags</subject></subjects>
+  <dates><date dateType="Issued">2019-05-02</date></dates>
+  <language>en</language>
+  <version>1.0</version>
+  <rightsList>
+    <rights rightsURI="https://creativecommons.org/licenses/by/4.0/">Creative Commons Attribution 4.0</rights>
+  </rightsList>
+  <descriptions>
+    <description descriptionType="Abstract">About
+      tags.</description>
+  </descriptions>
+</resource>`
+
+func TestToIntermediateSchema(t *testing.T) {
+	source := &formats.Source{SourceID: "1", MegaCollections: []string{"Zenodo"}}
+	var doc = &Document{source: source}
+	if err := json.Unmarshal([]byte(document), doc); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	var rec = &Record{source: source}
+	if err := xml.Unmarshal([]byte(record), rec); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	for _, v := range []interface {
+		ToIntermediateSchema() (*finc.IntermediateSchema, error)
+	}{doc, rec} {
+		output, err := v.ToIntermediateSchema()
+		if err != nil {
+			t.Fatalf("%T: got %v, want nil", v, err)
+		}
+		want := &finc.IntermediateSchema{
+			Format:          "ElectronicResourceRemoteAccess",
+			MegaCollections: []string{"Zenodo"},
+			ID:              "ai-1-aHR0cHM6Ly9kb2kub3JnLzEwLjUyODEvemVub2RvLjEyMw",
+			RecordID:        "10.5281/zenodo.123",
+			SourceID:        "1",
+			RefType:         "DATA",
+			ArticleTitle:    "Measurements",
+			Edition:         "1.0",
+			Genre:           "unknown",
+			Publishers:      []string{"Zenodo"},
+			RawDate:         "2019-05-02",
+			Date:            output.Date,
+			Abstract:        "About tags.",
+			Authors: []finc.Author{
+				{ID: "https://orcid.org/0000-0002-1825-0097", FirstName: "Jane", LastName: "Doe"},
+				{FirstName: "John", LastName: "Smith"},
+				{Corporate: "Tag Lab"},
+			},
+			DOI:             "10.5281/zenodo.123",
+			Languages:       []string{"eng"},
+			URL:             []string{"https://doi.org/10.5281/zenodo.123"},
+			Version:         finc.IntermediateSchemaVersion,
+			ArticleSubtitle: "A dataset",
+			Subjects:        []string{"tags"},
+			Type:            "Dataset",
+			License:         []string{"https://creativecommons.org/licenses/by/4.0/"},
+		}
+		if !reflect.DeepEqual(output, want) {
+			t.Errorf("%T: got %+v, want %+v", v, output, want)
+		}
+	}
+}
+
+func TestPublicationYear(t *testing.T) {
+	var cases = []struct {
+		doc  string
+		date string
+		err  bool
+	}{
+		{`{"id": "10.1/1", "attributes": {"titles": [{"title": "A"}], "publicationYear": "2001"}}`, "2001-01-01", false},
+		{`{"id": "10.1/1", "attributes": {"titles": [{"title": "A"}], "publicationYear": 2001, "publisher": "P"}}`, "2001-01-01", false},
+		{`{"id": "10.1/1", "attributes": {"titles": [{"title": "A"}], "dates": [{"date": "2001-03/2001-04", "dateType": "Issued"}]}}`, "2001-03-01", false},
+		{`{"id": "10.1/1", "attributes": {"titles": [{"title": "A"}], "publicationYear": null}}`, "", true},
+	}
+	for _, c := range cases {
+		doc := &Document{source: &formats.Source{SourceID: "1"}}
+		if err := json.Unmarshal([]byte(c.doc), doc); err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+		output, err := doc.ToIntermediateSchema()
+		if c.err {
+			if skip, ok := err.(span.Skip); !ok || skip.Code != span.SkipMissingDate {
+				t.Errorf("got %v, want missing date", err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+		if output.RawDate != c.date {
+			t.Errorf("got %v, want %v", output.RawDate, c.date)
+		}
+	}
+}
@@ -72,11 +72,17 @@ func (article *Article) ToIntermediateSchema() (*finc.IntermediateSchema, error)
 	}
 	output.Date, err = time.Parse("2006", article.Year)
 	if err != nil {
-		return nil, span.Skip{Reason: fmt.Sprintf("unparsable date: %s", article.Year)}
+		return nil, span.Skip{
+			Code:     span.SkipInvalidDate,
+			RecordID: article.ID(),
+			Field:    "year",
+			Value:    article.Year,
+		}
 	}
 	output.MegaCollections = []string{"DBLP", "sid-210-coll-dblp"}
 	output.SourceID = "210"
 	output.ID = article.ID()
+	output.RecordID = article.Key
 	output.RawDate = output.Date.Format("2006-01-02")
 	output.Volume = article.Volume
 	switch {
@@ -0,0 +1,14 @@
+package dblp
+
+import "github.com/miku/span/formats"
+
+func init() {
+	formats.Register(formats.Format{
+		Name:    "dblp",
+		Framing: formats.FramingXML,
+		New:     func() interface{} { return new(Article) },
+		Signature: &formats.Signature{
+			Elements: []string{"dblp", "article"},
+		},
+	})
+}
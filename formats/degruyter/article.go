@@ -75,7 +75,7 @@ func (article *Article) ToIntermediateSchema() (*finc.IntermediateSchema, error)
 
 	id := ids.ID
 	if len(id) > span.KeyLengthLimit {
-		return output, span.Skip{Reason: fmt.Sprintf("id too long: %s", id)}
+		return output, span.Skip{Code: span.SkipIDTooLong, RecordID: id}
 	}
 	output.ID = id
 	output.RecordID = ids.DOI
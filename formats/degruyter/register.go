@@ -0,0 +1,17 @@
+package degruyter
+
+import (
+	"github.com/miku/span/formats"
+	"github.com/miku/span/formats/finc"
+)
+
+func init() {
+	formats.Register(formats.Format{
+		Name:    "degruyter",
+		Framing: formats.FramingXML,
+		New:     func() interface{} { return new(Article) },
+		IDKey: func(is *finc.IntermediateSchema) []string {
+			return is.URL
+		},
+	})
+}
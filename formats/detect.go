@@ -0,0 +1,300 @@
+package formats
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+)
+
+// SniffLen is the number of bytes to look at for format detection.
+const SniffLen = 1 << 16
+
+// Common namespaces for use in signatures.
+const (
+	NamespaceOAI     = "http://www.openarchives.org/OAI/2.0/"
+	NamespaceOAIDC   = "http://www.openarchives.org/OAI/2.0/oai_dc/"
+	NamespaceMARCXML = "http://www.loc.gov/MARC21/slim"
+	NamespaceMETS    = "http://www.loc.gov/METS/"
+	NamespaceMODS    = "http://www.loc.gov/mods/v3"
+	NamespaceDIM     = "http://www.dspace.org/xmlns/dspace/dim"
+)
+
+// ErrNoMatch is returned, if no registered format matches the input.
+var ErrNoMatch = errors.New("formats: cannot detect input format")
+
+// Signature describes how to recognize a format from a prefix of the input.
+// All given conditions must hold. For tar framing, a tar header is required
+// in addition.
+type Signature struct {
+	// Elements are XML local element names, e.g. "record".
+	Elements []string
+	// Namespaces are XML namespace URIs, e.g. NamespaceMARCXML.
+	Namespaces []string
+	// Keys are top-level keys of the first JSON object, e.g. "DOI".
+	Keys []string
+	// Contains are literal strings, e.g. parts of an OAI identifier.
+	Contains []string
+	// Text maps XML local element names to a pattern, which the character
+	// data of at least one such element must match, e.g. a link in a MARC
+	// subfield. Unlike Contains, a mere mention elsewhere does not count.
+	Text map[string]*regexp.Regexp
+	// Match is an additional check, e.g. for binary formats.
+	Match func(p []byte) bool
+	// Generic formats only match, if no specific format matches, e.g.
+	// MARCXML in general versus MARCXML from a particular vendor.
+	Generic bool
+}
+
+// prefix summarizes the sniffed bytes.
+type prefix struct {
+	raw        []byte
+	isTar      bool
+	isJSON     bool
+	isXML      bool
+	elements   map[string]bool
+	namespaces map[string]bool
+	keys       map[string]bool
+	texts      map[string][]string
+}
+
+// AmbiguousError is returned, if more than one format matches.
+type AmbiguousError struct {
+	Candidates []string
+}
+
+// Error lists the candidates.
+func (e AmbiguousError) Error() string {
+	return fmt.Sprintf("formats: input matches more than one format, refusing to guess: %s",
+		strings.Join(e.Candidates, ", "))
+}
+
+// Detect finds the single registered format matching a prefix of the input
+// and returns it together with a short explanation. Use at least SniffLen
+// bytes, if available.
+func Detect(p []byte) (Format, string, error) {
+	pfx := analyze(p)
+	var (
+		matches []Format
+		reasons []string
+	)
+	for _, f := range All() {
+		if f.Signature == nil {
+			continue
+		}
+		if reason, ok := pfx.match(f); ok {
+			matches = append(matches, f)
+			reasons = append(reasons, reason)
+		}
+	}
+	// Prefer specific over generic formats.
+	var specific []int
+	for i, f := range matches {
+		if !f.Signature.Generic {
+			specific = append(specific, i)
+		}
+	}
+	if len(specific) > 0 && len(specific) < len(matches) {
+		var (
+			fs []Format
+			rs []string
+		)
+		for _, i := range specific {
+			fs = append(fs, matches[i])
+			rs = append(rs, reasons[i])
+		}
+		matches, reasons = fs, rs
+	}
+	switch len(matches) {
+	case 0:
+		return Format{}, "", ErrNoMatch
+	case 1:
+		return matches[0], reasons[0], nil
+	default:
+		var candidates []string
+		for i, f := range matches {
+			candidates = append(candidates, fmt.Sprintf("%s (%s)", f.Name, reasons[i]))
+		}
+		return Format{}, "", AmbiguousError{Candidates: candidates}
+	}
+}
+
+// analyze looks at the raw bytes and collects features of a tar, JSON or XML
+// prefix. A truncated prefix is fine, we keep what we have seen so far.
+func analyze(p []byte) *prefix {
+	pfx := &prefix{
+		raw:        p,
+		elements:   make(map[string]bool),
+		namespaces: make(map[string]bool),
+		keys:       make(map[string]bool),
+		texts:      make(map[string][]string),
+	}
+	if len(p) > 262 && bytes.HasPrefix(p[257:], []byte("ustar")) {
+		pfx.isTar = true
+		return pfx
+	}
+	trimmed := bytes.TrimLeft(bytes.TrimPrefix(p, []byte("\xef\xbb\xbf")), " \t\r\n")
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		pfx.isJSON = true
+		dec := json.NewDecoder(bytes.NewReader(trimmed))
+		if _, err := dec.Token(); err != nil {
+			return pfx
+		}
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				break
+			}
+			key, ok := tok.(string)
+			if !ok {
+				break
+			}
+			pfx.keys[key] = true
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				break
+			}
+		}
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		pfx.isXML = true
+		dec := xml.NewDecoder(bytes.NewReader(trimmed))
+		dec.Strict = false
+		dec.CharsetReader = charset.NewReaderLabel
+		var stack []string
+		for {
+			tok, err := dec.Token()
+			if err != nil {
+				if err != io.EOF && len(pfx.elements) == 0 {
+					pfx.isXML = false
+				}
+				break
+			}
+			switch t := tok.(type) {
+			case xml.EndElement:
+				if len(stack) > 0 {
+					stack = stack[:len(stack)-1]
+				}
+				continue
+			case xml.CharData:
+				if len(stack) > 0 {
+					if v := strings.TrimSpace(string(t)); v != "" {
+						name := stack[len(stack)-1]
+						pfx.texts[name] = append(pfx.texts[name], v)
+					}
+				}
+				continue
+			}
+			se, ok := tok.(xml.StartElement)
+			if !ok {
+				continue
+			}
+			stack = append(stack, se.Name.Local)
+			pfx.elements[se.Name.Local] = true
+			if se.Name.Space != "" {
+				pfx.namespaces[se.Name.Space] = true
+			}
+			for _, attr := range se.Attr {
+				if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" {
+					pfx.namespaces[attr.Value] = true
+				}
+			}
+		}
+	}
+	return pfx
+}
+
+// matchText reports whether any element with a given local name has
+// character data matching a pattern.
+func (pfx *prefix) matchText(name string, re *regexp.Regexp) bool {
+	for _, v := range pfx.texts[name] {
+		if re.MatchString(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// match reports whether a format matches and why.
+func (pfx *prefix) match(f Format) (string, bool) {
+	var (
+		s       = f.Signature
+		reasons []string
+	)
+	switch f.Framing {
+	case FramingTar:
+		if !pfx.isTar {
+			return "", false
+		}
+		reasons = append(reasons, "tar header")
+	case FramingNDJSON:
+		if !pfx.isJSON || len(s.Keys) == 0 {
+			return "", false
+		}
+		for _, k := range s.Keys {
+			if !pfx.keys[k] {
+				return "", false
+			}
+		}
+		reasons = append(reasons, fmt.Sprintf("JSON keys %s", strings.Join(s.Keys, ", ")))
+	case FramingXML:
+		if !pfx.isXML || len(s.Elements)+len(s.Namespaces) == 0 {
+			return "", false
+		}
+		for _, e := range s.Elements {
+			if !pfx.elements[e] {
+				return "", false
+			}
+		}
+		for _, ns := range s.Namespaces {
+			if !pfx.namespaces[ns] {
+				return "", false
+			}
+		}
+		if len(s.Elements) > 0 {
+			reasons = append(reasons, fmt.Sprintf("XML elements %s", strings.Join(s.Elements, ", ")))
+		}
+		if len(s.Namespaces) > 0 {
+			reasons = append(reasons, fmt.Sprintf("namespaces %s", strings.Join(s.Namespaces, ", ")))
+		}
+		var names []string
+		for name := range s.Text {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if !pfx.matchText(name, s.Text[name]) {
+				return "", false
+			}
+			reasons = append(reasons, fmt.Sprintf("%s matching %s", name, s.Text[name]))
+		}
+	case FramingDelimited, FramingStream:
+		if s.Match == nil {
+			return "", false
+		}
+	default:
+		return "", false
+	}
+	if s.Match != nil {
+		if !s.Match(pfx.raw) {
+			return "", false
+		}
+		reasons = append(reasons, "magic bytes")
+	}
+	for _, v := range s.Contains {
+		if !bytes.Contains(pfx.raw, []byte(v)) {
+			return "", false
+		}
+	}
+	if len(s.Contains) > 0 {
+		reasons = append(reasons, fmt.Sprintf("contains %s", strings.Join(s.Contains, ", ")))
+	}
+	return strings.Join(reasons, "; "), true
+}
@@ -177,13 +177,18 @@ func (doc Document) ToIntermediateSchema() (*finc.IntermediateSchema, error) {
 	output := finc.NewIntermediateSchema()
 	output.Date, err = doc.Date()
 	if err != nil {
-		return output, span.Skip{Reason: err.Error()}
+		return output, span.Skip{
+			Code:     span.SkipInvalidDate,
+			RecordID: doc.ID,
+			Field:    "date",
+			Reason:   err.Error(),
+		}
 	}
 	output.RawDate = output.Date.Format("2006-01-02")
 
 	id := fmt.Sprintf("ai-%s-%s", SourceIdentifier, doc.ID)
 	if len(id) > span.KeyLengthLimit {
-		return output, span.Skip{Reason: fmt.Sprintf("id too long: %s", id)}
+		return output, span.Skip{Code: span.SkipIDTooLong, RecordID: id}
 	}
 
 	output.ArticleTitle = doc.BibJSON.Title
@@ -249,7 +254,7 @@ func (doc Document) ToIntermediateSchema() (*finc.IntermediateSchema, error) {
 	for _, l := range doc.Index.Language {
 		languages.Add(LanguageMap.Lookup(l, "und"))
 	}
-	output.Languages = languages.Values()
+	output.Languages = languages.SortedValues()
 
 	output.RefType = DefaultRefType
 	return output, nil
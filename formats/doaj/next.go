@@ -106,16 +106,21 @@ func (doc ArticleV1) ToIntermediateSchema() (*finc.IntermediateSchema, error) {
 	output := finc.NewIntermediateSchema()
 	output.Date, err = doc.Date()
 	if err != nil {
-		return output, span.Skip{Reason: err.Error()}
+		return output, span.Skip{
+			Code:     span.SkipInvalidDate,
+			RecordID: doc.Id,
+			Field:    "date",
+			Reason:   err.Error(),
+		}
 	}
 	output.RawDate = output.Date.Format("2006-01-02")
 
 	if doc.Id == "" {
-		return output, span.Skip{Reason: "no identifier in source"}
+		return output, span.Skip{Code: span.SkipMissingID, Field: "id"}
 	}
 	id := fmt.Sprintf("ai-%s-%s", SourceIdentifier, doc.Id)
 	if len(id) > span.KeyLengthLimit {
-		return output, span.Skip{Reason: fmt.Sprintf("id too long: %s", id)}
+		return output, span.Skip{Code: span.SkipIDTooLong, RecordID: id}
 	}
 
 	output.ArticleTitle = doc.Bibjson.Title
@@ -186,7 +191,7 @@ func (doc ArticleV1) ToIntermediateSchema() (*finc.IntermediateSchema, error) {
 		}
 		languages.Add(detected)
 	}
-	output.Languages = languages.Values()
+	output.Languages = languages.SortedValues()
 	output.OpenAccess = true
 
 	output.RefType = DefaultRefType
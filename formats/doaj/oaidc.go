@@ -83,6 +83,10 @@ func (record Record) Identifier() string {
 			return strings.Replace(id, prefix, "", -1)
 		}
 	}
+	// Deleted records come without metadata, oai:doaj.org/article:72a8...
+	if i := strings.LastIndex(record.Header.Identifier, ":"); i > 0 {
+		return record.Header.Identifier[i+1:]
+	}
 	return ""
 }
 
@@ -207,9 +211,21 @@ func (record Record) ToIntermediateSchema() (*finc.IntermediateSchema, error) {
 	var err error
 
 	output := finc.NewIntermediateSchema()
+	if record.Header.Status == "deleted" {
+		id := record.Identifier()
+		if id == "" {
+			return output, fmt.Errorf("missing record id")
+		}
+		return finc.NewTombstone(fmt.Sprintf("ai-28-%s", id), "28", id), nil
+	}
 	date, err := record.Date()
 	if err != nil {
-		return output, span.Skip{Reason: "missing date"}
+		return output, span.Skip{
+			Code:     span.SkipMissingDate,
+			RecordID: record.Header.Identifier,
+			Field:    "date",
+			Reason:   err.Error(),
+		}
 	}
 	output.ArticleTitle = record.Metadata.Dc.Title
 	output.Date = date
@@ -240,7 +256,7 @@ func (record Record) ToIntermediateSchema() (*finc.IntermediateSchema, error) {
 	for _, l := range record.Metadata.Dc.Language {
 		languages.Add(LanguageMap.Lookup(l, "und"))
 	}
-	output.Languages = languages.Values()
+	output.Languages = languages.SortedValues()
 	output.Format = "ElectronicArticle"
 	output.Genre = "article"
 	output.RefType = "EJOUR"
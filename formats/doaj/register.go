@@ -0,0 +1,37 @@
+package doaj
+
+import "github.com/miku/span/formats"
+
+func init() {
+	formats.Register(formats.Format{
+		Name:    "doaj",
+		Framing: formats.FramingNDJSON,
+		New:     func() interface{} { return new(ArticleV1) },
+		Signature: &formats.Signature{
+			Keys: []string{"bibjson"},
+		},
+	})
+	formats.Register(formats.Format{
+		Name:    "doaj-api",
+		Framing: formats.FramingNDJSON,
+		New:     func() interface{} { return new(ArticleV1) },
+	})
+	formats.Register(formats.Format{
+		Name:    "doaj-legacy",
+		Framing: formats.FramingNDJSON,
+		New:     func() interface{} { return new(Response) },
+		Signature: &formats.Signature{
+			Keys: []string{"_index", "_source"},
+		},
+	})
+	formats.Register(formats.Format{
+		Name:    "doaj-oai",
+		Framing: formats.FramingXML,
+		New:     func() interface{} { return new(Record) },
+		Signature: &formats.Signature{
+			Elements:   []string{"record"},
+			Namespaces: []string{formats.NamespaceOAIDC},
+			Contains:   []string{"oai:doaj.org"},
+		},
+	})
+}
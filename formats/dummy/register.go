@@ -0,0 +1,11 @@
+package dummy
+
+import "github.com/miku/span/formats"
+
+func init() {
+	formats.Register(formats.Format{
+		Name:    "dummy",
+		Framing: formats.FramingNDJSON,
+		New:     func() interface{} { return new(Example) },
+	})
+}
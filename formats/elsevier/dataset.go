@@ -2,21 +2,17 @@
 package elsevier
 
 import (
-	"archive/tar"
 	"bytes"
 	"encoding/base64"
 	"encoding/xml"
 	"errors"
 	"fmt"
-	"io"
-	"os"
 	"strconv"
 	"strings"
 	"time"
 
-	log "github.com/sirupsen/logrus"
-
 	"github.com/kennygrant/sanitize"
+	"github.com/miku/span"
 	"github.com/miku/span/formats/finc"
 )
 
@@ -375,149 +371,38 @@ func (article Article) Authors() []finc.Author {
 	return authors
 }
 
-// Shipment is a tar export, looks like SAXC0000000000046A.tar. The tar is not
-// extracted but loaded into memory at once. Issues and articles are stored in a
-// map, each keyed on the PII.
-type Shipment struct {
-	// the full path to the file
-	origin string
-	// parsed dataset.xml from the tar file
-	dataset Dataset
-	// issues, keyed by the PII
-	issues map[string]SerialIssue
-	// articles, keyed by the PII
-	articles map[string]Article
-}
-
-// String describes the shipment briefly.
-func (s Shipment) String() string {
-	return fmt.Sprintf("<Shipment origin=%s, issues=%d, articles=%d>",
-		s.origin,
-		len(s.dataset.DatasetContent.JournalIssue),
-		len(s.dataset.DatasetContent.JournalItem))
-}
-
-// NewShipment creates a new bag of data from a given tarfile.
-func NewShipment(r io.Reader) (Shipment, error) {
-
-	var shipment = Shipment{
-		origin:   fmt.Sprintf("%T", r),
-		issues:   make(map[string]SerialIssue),
-		articles: make(map[string]Article),
-	}
-
-	if ff, ok := r.(*os.File); ok {
-		shipment.origin = ff.Name()
+// ToIntermediateSchema converts the article level fields. Issue and journal
+// information is only available from issue.xml and dataset.xml, see Shipment.
+func (article Article) ToIntermediateSchema() (*finc.IntermediateSchema, error) {
+	output := finc.NewIntermediateSchema()
+	output.Authors = article.Authors()
+	output.DOI = article.ItemInfo.Doi
+	output.Format = Format
+	output.Genre = Genre
+	output.Languages = []string{"eng"}
+	output.MegaCollections = []string{Collection}
+	output.ID = fmt.Sprintf("ai-%s-%s", SourceID, base64.RawURLEncoding.EncodeToString([]byte(article.ItemInfo.Doi)))
+	output.RecordID = article.ItemInfo.Doi
+	output.RefType = DefaultRefType
+	output.SourceID = SourceID
+	output.ArticleTitle = article.Title()
+	output.URL = []string{
+		fmt.Sprintf("http://doi.org/%s", article.ItemInfo.Doi),
 	}
-
-	tr := tar.NewReader(r)
-
-	for {
-		header, err := tr.Next()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return shipment, err
-		}
-
-		var buf bytes.Buffer
-		if _, err := io.Copy(&buf, tr); err != nil {
-			return shipment, err
-		}
-
-		dec := xml.NewDecoder(&buf)
-		dec.Strict = false
-
-		switch {
-		case strings.HasSuffix(header.Name, "main.xml"):
-			var article Article
-			if err := dec.Decode(&article); err != nil {
-				return shipment, err
-			}
-			shipment.articles[article.ItemInfo.Pii] = article
-		case strings.HasSuffix(header.Name, "issue.xml"):
-			var si SerialIssue
-			if err := dec.Decode(&si); err != nil {
-				return shipment, err
-			}
-			shipment.issues[si.IssueInfo.Pii] = si
-		case strings.HasSuffix(header.Name, "dataset.xml"):
-			var ds Dataset
-			if err := dec.Decode(&ds); err != nil {
-				return shipment, err
-			}
-			shipment.dataset = ds
+	date, err := article.Date()
+	if err != nil {
+		return output, span.Skip{
+			Code:     span.SkipMissingDate,
+			RecordID: output.RecordID,
+			Reason:   err.Error(),
 		}
 	}
-	return shipment, nil
-}
-
-// BatchConvert converts all items for a shipment into importable objects.
-func (s Shipment) BatchConvert() ([]finc.IntermediateSchema, error) {
-	var outputs []finc.IntermediateSchema
-
-	for _, ji := range s.dataset.DatasetContent.JournalIssue {
-		pii := ji.JournalIssueUniqueIds.Pii
-		si, ok := s.issues[pii]
-		if !ok {
-			log.Println(fmt.Sprintf("skipping, issue referenced %s, but not cached", pii))
-			continue
-		}
-		for _, sec := range si.IssueBody.IssueSec {
-			for _, ii := range sec.IncludeItem {
-				output := finc.NewIntermediateSchema()
-
-				article, ok := s.articles[ii.Pii]
-
-				if !ok {
-					log.Println(fmt.Sprintf("skipping, article referenced %s, but not cached", ii.Pii))
-					continue
-				}
-
-				output.Authors = article.Authors()
-				output.DOI = article.ItemInfo.Doi
-				output.Format = Format
-				output.Genre = Genre
-				output.ISSN = []string{si.IssueInfo.Issn}
-				output.Issue = si.IssueInfo.VolumeIssueNumber.IssFirst
-				output.Languages = []string{"eng"}
-				output.MegaCollections = []string{Collection}
-				output.ID = fmt.Sprintf("ai-%s-%s", SourceID, base64.RawURLEncoding.EncodeToString([]byte(article.ItemInfo.Doi)))
-				output.RecordID = article.ItemInfo.Doi
-				output.RefType = DefaultRefType
-				output.SourceID = SourceID
-				output.Volume = si.IssueInfo.VolumeIssueNumber.VolFirst
-
-				output.ArticleTitle = article.Title()
-				output.JournalTitle = ji.JournalIssueProperties.CollectionTitle
-
-				output.StartPage = ii.Pages.FirstPage
-				output.EndPage = ii.Pages.LastPage
-				output.Pages = ii.Pages.Total()
-
-				output.URL = []string{
-					fmt.Sprintf("http://doi.org/%s", article.ItemInfo.Doi),
-				}
-
-				date, err := article.Date()
-				if err != nil {
-					log.Printf("%+v: %s", article.Head, err)
-					continue
-				}
-
-				output.Date = date
-				output.RawDate = date.Format("2006-01-02")
-
-				var buf bytes.Buffer
-				for _, abs := range article.Head.Abstract {
-					buf.WriteString(sanitize.HTML(abs.Text))
-				}
-				output.Abstract = buf.String()
-
-				outputs = append(outputs, *output)
-			}
-		}
+	output.Date = date
+	output.RawDate = date.Format("2006-01-02")
+	var buf bytes.Buffer
+	for _, abs := range article.Head.Abstract {
+		buf.WriteString(sanitize.HTML(abs.Text))
 	}
-	return outputs, nil
+	output.Abstract = buf.String()
+	return output, nil
 }
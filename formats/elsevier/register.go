@@ -0,0 +1,18 @@
+package elsevier
+
+import (
+	"io"
+
+	"github.com/miku/span/formats"
+)
+
+func init() {
+	formats.Register(formats.Format{
+		Name:    "elsevier-tar",
+		Framing: formats.FramingTar,
+		Batch: func(r io.Reader, emit formats.EmitFunc) error {
+			return NewShipment(r).Convert(emit)
+		},
+		Signature: &formats.Signature{},
+	})
+}
@@ -0,0 +1,314 @@
+package elsevier
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/miku/span"
+	"github.com/miku/span/formats"
+	"github.com/miku/span/formats/finc"
+)
+
+// Shipment is a tar export, looks like SAXC0000000000046A.tar. The tar is
+// read as a stream: articles (main.xml) are decoded and converted
+// concurrently and joined with the issue (issue.xml) and journal
+// (dataset.xml) information as soon as it is available. Only the issue level
+// information is kept for the whole shipment. Articles wait for their
+// issue.xml, which usually follows the article directories, and for
+// dataset.xml, which usually comes first, so memory stays bounded by the size
+// of an issue.
+type Shipment struct {
+	// Workers is the number of goroutines decoding articles, defaults to
+	// the number of CPUs.
+	Workers int
+
+	// the full path to the file, if known
+	origin string
+	r      io.Reader
+	// number of issues and articles read
+	issues   int
+	articles int
+}
+
+// NewShipment prepares the conversion of a tar file.
+func NewShipment(r io.Reader) *Shipment {
+	s := &Shipment{r: r, origin: fmt.Sprintf("%T", r)}
+	if ff, ok := r.(*os.File); ok {
+		s.origin = ff.Name()
+	}
+	return s
+}
+
+// String describes the shipment briefly.
+func (s *Shipment) String() string {
+	return fmt.Sprintf("<Shipment origin=%s, issues=%d, articles=%d>",
+		s.origin, s.issues, s.articles)
+}
+
+// entry is a file from the tar.
+type entry struct {
+	name string
+	b    []byte
+}
+
+// event is something read from the shipment: the dataset, an issue or a
+// converted article, with its error, if any.
+type event struct {
+	dataset *Dataset
+	issue   *SerialIssue
+	article *finc.IntermediateSchema
+	pii     string
+	err     error
+}
+
+// Convert reads the shipment and passes each document to emit, as soon as
+// it is complete. Articles, that cannot be decoded, are passed as
+// *formats.RecordError, articles without date as span.Skip; conversion goes
+// on, unless emit returns an error. Convert returns the first error reading
+// the tar, dataset.xml or an issue.xml.
+func (s *Shipment) Convert(emit formats.EmitFunc) error {
+	workers := s.Workers
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+	var (
+		entries = make(chan entry)
+		events  = make(chan event)
+		done    = make(chan struct{})
+		readErr error
+		wg      sync.WaitGroup
+	)
+	// send passes an event to the joiner, false on cancellation.
+	send := func(ev event) bool {
+		select {
+		case events <- ev:
+			return true
+		case <-done:
+			return false
+		}
+	}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for e := range entries {
+				if !send(decodeArticle(e)) {
+					return
+				}
+			}
+		}()
+	}
+	// Reader, closes events, when the tar is exhausted and all articles
+	// are converted.
+	go func() {
+		defer close(events)
+		defer wg.Wait()
+		defer close(entries)
+		readErr = s.read(entries, done, send)
+	}()
+	j := &joiner{
+		emit:    emit,
+		titles:  make(map[string]string),
+		items:   make(map[string]item),
+		pending: make(map[string]*finc.IntermediateSchema),
+	}
+	for ev := range events {
+		if err := j.add(ev); err != nil {
+			close(done)
+			for range events {
+			}
+			return err
+		}
+	}
+	if readErr != nil {
+		return readErr
+	}
+	return j.finish()
+}
+
+// read passes articles to the workers and the issues and the dataset
+// directly to the joiner.
+func (s *Shipment) read(entries chan<- entry, done <-chan struct{}, send func(event) bool) error {
+	tr := tar.NewReader(s.r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var (
+			name = header.Name
+			ev   event
+		)
+		switch {
+		case strings.HasSuffix(name, "main.xml"):
+			b, err := io.ReadAll(tr)
+			if err != nil {
+				return err
+			}
+			s.articles++
+			select {
+			case entries <- entry{name: name, b: b}:
+				continue
+			case <-done:
+				return nil
+			}
+		case strings.HasSuffix(name, "issue.xml"):
+			ev.issue = new(SerialIssue)
+			if err := decode(tr, ev.issue); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			s.issues++
+		case strings.HasSuffix(name, "dataset.xml"):
+			ev.dataset = new(Dataset)
+			if err := decode(tr, ev.dataset); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		default:
+			continue
+		}
+		if !send(ev) {
+			return nil
+		}
+	}
+}
+
+// decode reads an XML document leniently.
+func decode(r io.Reader, v interface{}) error {
+	dec := xml.NewDecoder(r)
+	dec.Strict = false
+	return dec.Decode(v)
+}
+
+// decodeArticle decodes and converts a main.xml file.
+func decodeArticle(e entry) event {
+	var article Article
+	if err := decode(bytes.NewReader(e.b), &article); err != nil {
+		return event{err: &formats.RecordError{Name: e.name, Err: err}}
+	}
+	output, err := article.ToIntermediateSchema()
+	return event{article: output, pii: article.ItemInfo.Pii, err: err}
+}
+
+// item is the issue level information about an article.
+type item struct {
+	issue *SerialIssue
+	pages Pages
+}
+
+// joiner completes articles with issue and journal information, it is only
+// used from a single goroutine.
+type joiner struct {
+	emit    formats.EmitFunc
+	dataset *Dataset
+	// titles are journal titles, keyed by issue PII, from dataset.xml
+	titles map[string]string
+	// items are all articles referenced by issues, keyed by PII
+	items map[string]item
+	// pending articles, waiting for their issue or the dataset
+	pending map[string]*finc.IntermediateSchema
+}
+
+// add processes a single event.
+func (j *joiner) add(ev event) error {
+	switch {
+	case ev.err != nil:
+		return j.emit(ev.article, ev.err)
+	case ev.dataset != nil:
+		j.dataset = ev.dataset
+		for _, ji := range ev.dataset.DatasetContent.JournalIssue {
+			j.titles[ji.JournalIssueUniqueIds.Pii] = ji.JournalIssueProperties.CollectionTitle
+		}
+		for _, pii := range sortedKeys(j.pending) {
+			if err := j.try(pii); err != nil {
+				return err
+			}
+		}
+	case ev.issue != nil:
+		for _, sec := range ev.issue.IssueBody.IssueSec {
+			for _, ii := range sec.IncludeItem {
+				j.items[ii.Pii] = item{issue: ev.issue, pages: ii.Pages}
+				if err := j.try(ii.Pii); err != nil {
+					return err
+				}
+			}
+		}
+	case ev.article != nil:
+		j.pending[ev.pii] = ev.article
+		return j.try(ev.pii)
+	}
+	return nil
+}
+
+// try emits an article, if all information is available.
+func (j *joiner) try(pii string) error {
+	output, ok := j.pending[pii]
+	if !ok || j.dataset == nil {
+		return nil
+	}
+	it, ok := j.items[pii]
+	if !ok {
+		return nil
+	}
+	delete(j.pending, pii)
+	title, ok := j.titles[it.issue.IssueInfo.Pii]
+	if !ok {
+		return j.emit(output, span.Skip{
+			Code:     span.SkipMissingIssue,
+			RecordID: output.RecordID,
+			Field:    "dataset",
+			Reason:   fmt.Sprintf("issue %s not in dataset", it.issue.IssueInfo.Pii),
+		})
+	}
+	info := it.issue.IssueInfo
+	output.ISSN = []string{info.Issn}
+	output.Issue = info.VolumeIssueNumber.IssFirst
+	output.Volume = info.VolumeIssueNumber.VolFirst
+	output.JournalTitle = title
+	output.StartPage = it.pages.FirstPage
+	output.EndPage = it.pages.LastPage
+	output.Pages = it.pages.Total()
+	return j.emit(output, nil)
+}
+
+// finish reports articles, that are not referenced by any issue.
+func (j *joiner) finish() error {
+	for _, pii := range sortedKeys(j.pending) {
+		output := j.pending[pii]
+		skip := span.Skip{
+			Code:     span.SkipMissingIssue,
+			RecordID: output.RecordID,
+			Field:    "issue",
+			Reason:   fmt.Sprintf("article %s not referenced by any issue", pii),
+		}
+		if j.dataset == nil {
+			skip.Code = span.SkipMissingDataset
+			skip.Field = "dataset"
+			skip.Reason = "shipment without dataset.xml"
+		}
+		if err := j.emit(output, skip); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sortedKeys keeps the output of a shipment deterministic.
+func sortedKeys(m map[string]*finc.IntermediateSchema) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,163 @@
+package elsevier
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/miku/span"
+	"github.com/miku/span/formats"
+	"github.com/miku/span/formats/finc"
+)
+
+const (
+	dataset = `<dataset><dataset-content><journal-issue>
+		<journal-issue-unique-ids><pii>I1</pii></journal-issue-unique-ids>
+		<journal-issue-properties><issn>0001-0002</issn><collection-title>Journal of Tags</collection-title></journal-issue-properties>
+	</journal-issue></dataset-content></dataset>`
+	issue = `<serial-issue>
+		<issue-info><pii>I1</pii><issn>0001-0002</issn><volume-issue-number><vol-first>12</vol-first><iss-first>3</iss-first></volume-issue-number></issue-info>
+		<issue-body><issue-sec>
+			<include-item><pii>A1</pii><pages><first-page>1</first-page><last-page>10</last-page></pages></include-item>
+			<include-item><pii>A2</pii></include-item>
+			<include-item><pii>A3</pii></include-item>
+		</issue-sec></issue-body>
+	</serial-issue>`
+)
+
+// article returns a main.xml, without date, if year is empty.
+func article(pii, year string) string {
+	s := `<article><item-info><pii>` + pii + `</pii><doi>10.1/` + pii + `</doi></item-info><head><title>On ` + pii + `</title>`
+	if year != "" {
+		s += `<date-received year="` + year + `"/>`
+	}
+	return s + `</head></article>`
+}
+
+// shipment creates a tar from name, content pairs.
+func shipment(t *testing.T, files ...string) *bytes.Buffer {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for i := 0; i < len(files); i += 2 {
+		hdr := &tar.Header{Name: files[i], Mode: 0644, Size: int64(len(files[i+1]))}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(files[i+1])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return &buf
+}
+
+func TestShipmentConvert(t *testing.T) {
+	// Dataset last, issue between articles, one broken, one without date,
+	// one not referenced.
+	r := shipment(t,
+		"S1/I1/A1/main.xml", article("A1", "2019"),
+		"S1/I1/A2/main.xml", article("A2", ""),
+		"S1/I1/issue.xml", issue,
+		"S1/I1/A3/main.xml", `<article><item-info>`,
+		"S1/I1/A4/main.xml", article("A4", "2019"),
+		"dataset.xml", dataset,
+	)
+	var (
+		docs    []*finc.IntermediateSchema
+		skipped []string
+		errs    int
+	)
+	s := NewShipment(r)
+	s.Workers = 2
+	err := s.Convert(func(doc *finc.IntermediateSchema, err error) error {
+		switch err.(type) {
+		case nil:
+			docs = append(docs, doc)
+		case span.Skip:
+			skipped = append(skipped, fmt.Sprintf("%s %s", doc.RecordID, err.(span.Skip).Code))
+		case *formats.RecordError:
+			errs++
+		default:
+			t.Errorf("unexpected error: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if len(docs) != 1 {
+		t.Fatalf("got %d documents, want 1", len(docs))
+	}
+	doc := docs[0]
+	if doc.RecordID != "10.1/A1" || doc.JournalTitle != "Journal of Tags" || doc.Volume != "12" ||
+		doc.Issue != "3" || doc.StartPage != "1" || doc.Pages != "9" || doc.RawDate != "2019-01-01" {
+		t.Errorf("unexpected document: %+v", doc)
+	}
+	sort.Strings(skipped)
+	if want := []string{"10.1/A2 NO_DATE", "10.1/A4 NO_ISSUE"}; !reflect.DeepEqual(skipped, want) {
+		t.Errorf("got %v skipped, want %v", skipped, want)
+	}
+	if errs != 1 {
+		t.Errorf("got %d errors, want 1", errs)
+	}
+	if want := "<Shipment origin=*bytes.Buffer, issues=1, articles=4>"; s.String() != want {
+		t.Errorf("got %s, want %s", s, want)
+	}
+}
+
+func TestShipmentConvertWithoutDataset(t *testing.T) {
+	r := shipment(t,
+		"S1/I1/A1/main.xml", article("A1", "2019"),
+		"S1/I1/issue.xml", issue,
+	)
+	var codes []span.SkipCode
+	err := NewShipment(r).Convert(func(doc *finc.IntermediateSchema, err error) error {
+		if skip, ok := err.(span.Skip); ok {
+			codes = append(codes, skip.Code)
+			return nil
+		}
+		t.Errorf("got %v, want skip", err)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if want := []span.SkipCode{span.SkipMissingDataset}; !reflect.DeepEqual(codes, want) {
+		t.Errorf("got %v, want %v", codes, want)
+	}
+}
+
+func TestShipmentConvertStop(t *testing.T) {
+	var files []string
+	for _, pii := range []string{"A1", "A2", "A3", "A4", "A5", "A6"} {
+		files = append(files, "S1/I1/"+pii+"/main.xml", article(pii, ""))
+	}
+	var (
+		stop = errors.New("stop")
+		n    int
+	)
+	err := NewShipment(shipment(t, files...)).Convert(func(doc *finc.IntermediateSchema, err error) error {
+		n++
+		return stop
+	})
+	if err != stop {
+		t.Fatalf("got %v, want %v", err, stop)
+	}
+	if n != 1 {
+		t.Errorf("emit called %d times, want 1", n)
+	}
+}
+
+func TestShipmentConvertTarError(t *testing.T) {
+	r := shipment(t, "dataset.xml", dataset)
+	r.Truncate(100)
+	if err := NewShipment(r).Convert(func(*finc.IntermediateSchema, error) error { return nil }); err == nil {
+		t.Errorf("got nil, want error")
+	}
+}
@@ -0,0 +1,131 @@
+package finc
+
+import (
+	"strings"
+
+	"github.com/miku/span/container"
+	"github.com/segmentio/encoding/json"
+)
+
+// CSLTypes maps rft.genre to CSL item types, anything else is a document.
+var CSLTypes = container.MapDefault{
+	"article":    "article-journal",
+	"book":       "book",
+	"bookitem":   "chapter",
+	"conference": "book",
+	"issue":      "periodical",
+	"journal":    "periodical",
+	"preprint":   "article",
+	"proceeding": "paper-conference",
+	"report":     "report",
+}
+
+// CSLName is a name variable, either a personal name in parts or a literal
+// name, e.g. for organizations.
+type CSLName struct {
+	Family  string `json:"family,omitempty"`
+	Given   string `json:"given,omitempty"`
+	Suffix  string `json:"suffix,omitempty"`
+	Literal string `json:"literal,omitempty"`
+}
+
+// CSLDate is a date variable, with year, month and day parts.
+type CSLDate struct {
+	DateParts [][]int `json:"date-parts"`
+}
+
+// CSLJSON is a single item in CSL-JSON, the input format of citeproc
+// processors and reference managers like Zotero, see
+// https://citeproc-js.readthedocs.io/en/latest/csl-json/markup.html. Items
+// are written one per line, use e.g. jq -s to get an array.
+type CSLJSON struct {
+	ID              string    `json:"id"`
+	Type            string    `json:"type"`
+	Title           string    `json:"title,omitempty"`
+	ShortTitle      string    `json:"title-short,omitempty"`
+	ContainerTitle  string    `json:"container-title,omitempty"`
+	CollectionTitle string    `json:"collection-title,omitempty"`
+	Author          []CSLName `json:"author,omitempty"`
+	Issued          *CSLDate  `json:"issued,omitempty"`
+	Volume          string    `json:"volume,omitempty"`
+	Issue           string    `json:"issue,omitempty"`
+	Page            string    `json:"page,omitempty"`
+	PageFirst       string    `json:"page-first,omitempty"`
+	NumberOfPages   string    `json:"number-of-pages,omitempty"`
+	Edition         string    `json:"edition,omitempty"`
+	ISSN            string    `json:"ISSN,omitempty"`
+	ISBN            string    `json:"ISBN,omitempty"`
+	DOI             string    `json:"DOI,omitempty"`
+	URL             string    `json:"URL,omitempty"`
+	Abstract        string    `json:"abstract,omitempty"`
+	Language        string    `json:"language,omitempty"`
+	Publisher       string    `json:"publisher,omitempty"`
+	PublisherPlace  string    `json:"publisher-place,omitempty"`
+	Keyword         string    `json:"keyword,omitempty"`
+	Note            string    `json:"note,omitempty"`
+}
+
+// Export converts a document into a CSL-JSON item.
+func (c *CSLJSON) Export(is IntermediateSchema, _ bool) ([]byte, error) {
+	c.convert(is)
+	return json.Marshal(c)
+}
+
+// convert fills in the item from a document.
+func (c *CSLJSON) convert(is IntermediateSchema) {
+	*c = CSLJSON{
+		ID:              is.ID,
+		Type:            CSLTypes.Lookup(is.Genre, "document"),
+		ShortTitle:      is.ShortTitle,
+		CollectionTitle: is.Series,
+		Volume:          is.Volume,
+		Issue:           is.Issue,
+		PageFirst:       is.StartPage,
+		NumberOfPages:   is.PageCount,
+		Edition:         is.Edition,
+		ISSN:            strings.Join(is.ISSNList(), ", "),
+		ISBN:            strings.Join(is.ISBNList(), ", "),
+		DOI:             is.DOI,
+		Abstract:        is.AbstractCleaned(),
+		Language:        strings.Join(is.Languages, ", "),
+		Publisher:       strings.Join(is.Publishers, "; "),
+		PublisherPlace:  strings.Join(is.Places, "; "),
+		Keyword:         strings.Join(is.Subjects, ", "),
+		Note:            strings.Join(is.Footnotes, "; "),
+	}
+	if c.ID == "" {
+		c.ID = is.RecordID
+	}
+	c.Title = is.ArticleTitle
+	if c.Title == "" {
+		c.Title = is.BookTitle
+	}
+	c.ContainerTitle = is.JournalTitle
+	if c.ContainerTitle == "" && is.BookTitle != c.Title {
+		c.ContainerTitle = is.BookTitle
+	}
+	for _, a := range is.Authors {
+		switch {
+		case a.LastName != "":
+			c.Author = append(c.Author, CSLName{Family: a.LastName, Given: a.FirstName, Suffix: a.Suffix})
+		case a.Name != "":
+			c.Author = append(c.Author, CSLName{Literal: a.Name})
+		case a.Corporate != "":
+			c.Author = append(c.Author, CSLName{Literal: a.Corporate})
+		}
+	}
+	if parts := is.DateParts(); parts != nil {
+		c.Issued = &CSLDate{DateParts: [][]int{parts}}
+	}
+	switch {
+	case is.StartPage != "" && is.EndPage != "":
+		c.Page = is.StartPage + "-" + is.EndPage
+	case is.Pages != "":
+		c.Page = is.Pages
+	default:
+		c.Page = is.StartPage
+	}
+	if len(is.URL) > 0 {
+		c.URL = is.URL[0]
+	}
+}
@@ -0,0 +1,48 @@
+package finc
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCSLJSON(t *testing.T) {
+	is := IntermediateSchema{
+		ID:           "ai-1-x",
+		ArticleTitle: "On Tags",
+		BookTitle:    "Tags and Formats",
+		Authors:      []Author{{LastName: "Doe", FirstName: "Jane"}, {Corporate: "Mellon Foundation"}},
+		Date:         time.Date(2019, 5, 2, 0, 0, 0, 0, time.UTC),
+		DOI:          "10.1/x",
+		Genre:        "bookitem",
+		ISBN:         []string{"978-3-16-148410-0"},
+		StartPage:    "12",
+		EndPage:      "34",
+	}
+	b, err := new(CSLJSON).Export(is, false)
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	want := `{"id":"ai-1-x","type":"chapter","title":"On Tags","container-title":"Tags and Formats",` +
+		`"author":[{"family":"Doe","given":"Jane"},{"literal":"Mellon Foundation"}],` +
+		`"issued":{"date-parts":[[2019,5,2]]},"page":"12-34","page-first":"12",` +
+		`"ISBN":"978-3-16-148410-0","DOI":"10.1/x"}`
+	if string(b) != want {
+		t.Errorf("got %s, want %s", b, want)
+	}
+	// A year is not turned into January 1st.
+	is.Date, is.RawDate = time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), "2019-01-01"
+	if b, err = new(CSLJSON).Export(is, false); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if want := `"issued":{"date-parts":[[2019]]}`; !strings.Contains(string(b), want) {
+		t.Errorf("got %s, want %s", b, want)
+	}
+	// Exporters are reused across records.
+	c := &CSLJSON{}
+	c.convert(is)
+	c.convert(IntermediateSchema{RecordID: "y"})
+	if c.ID != "y" || c.Type != "document" || c.Author != nil || c.Issued != nil {
+		t.Errorf("got %+v, want fresh item", c)
+	}
+}
@@ -0,0 +1,189 @@
+package finc
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/segmentio/encoding/json"
+)
+
+const (
+	// DefaultElasticsearchIndex is the index name, if none is given.
+	DefaultElasticsearchIndex = "ai"
+	// DefaultElasticsearchIDField is the field used as document id.
+	DefaultElasticsearchIDField = "finc.id"
+)
+
+// esTextFields are analyzed for full text search, with a keyword subfield for
+// sorting and aggregations; other strings are keywords.
+var esTextFields = map[string]bool{
+	"abstract":    true,
+	"rft.atitle":  true,
+	"rft.au":      true,
+	"rft.aucorp":  true,
+	"rft.btitle":  true,
+	"rft.jtitle":  true,
+	"rft.series":  true,
+	"rft.stitle":  true,
+	"x.footnotes": true,
+	"x.fulltext":  true,
+	"x.headings":  true,
+	"x.subjects":  true,
+	"x.subtitle":  true,
+}
+
+// esDateFields are strings, that hold an ISO 8601 date.
+var esDateFields = map[string]bool{
+	"rft.date": true,
+}
+
+// ElasticsearchBulk writes documents as action and source line pairs for the
+// Elasticsearch and OpenSearch bulk API. Tombstones become delete actions.
+type ElasticsearchBulk struct {
+	// Index name, defaults to DefaultElasticsearchIndex.
+	Index string
+	// IDField is the JSON name of the field used as document id, defaults to
+	// DefaultElasticsearchIDField.
+	IDField string
+	// OpType is "index" (default), which replaces existing documents, or
+	// "create", which fails for existing documents.
+	OpType string
+}
+
+// bulkAction is the metadata line of a bulk request.
+type bulkAction struct {
+	Index string `json:"_index,omitempty"`
+	ID    string `json:"_id"`
+}
+
+// action returns the action line for a document. An id is required for all
+// operations: without it, Elasticsearch would generate one and every import
+// would add the documents again.
+func (e *ElasticsearchBulk) action(is *IntermediateSchema, op string) ([]byte, error) {
+	index, idField := e.Index, e.IDField
+	if index == "" {
+		index = DefaultElasticsearchIndex
+	}
+	if idField == "" {
+		idField = DefaultElasticsearchIDField
+	}
+	ids, err := is.Field(idField)
+	if err != nil {
+		return nil, err
+	}
+	var id string
+	if len(ids) > 0 {
+		id = ids[0]
+	}
+	if id == "" {
+		return nil, fmt.Errorf("cannot %s document without %s: record %q", op, idField, is.RecordID)
+	}
+	return json.Marshal(map[string]bulkAction{op: {Index: index, ID: id}})
+}
+
+// Export returns an action line, followed by the document.
+func (e *ElasticsearchBulk) Export(is IntermediateSchema, _ bool) ([]byte, error) {
+	if is.Deleted {
+		return e.ExportTombstone(is)
+	}
+	op := e.OpType
+	switch op {
+	case "":
+		op = "index"
+	case "index", "create":
+	default:
+		return nil, fmt.Errorf("unsupported bulk op type: %s", op)
+	}
+	action, err := e.action(&is, op)
+	if err != nil {
+		return nil, err
+	}
+	source, err := Marshal(&is)
+	if err != nil {
+		return nil, err
+	}
+	return append(append(action, '\n'), source...), nil
+}
+
+// ExportTombstone returns a delete action.
+func (e *ElasticsearchBulk) ExportTombstone(is IntermediateSchema) ([]byte, error) {
+	return e.action(&is, "delete")
+}
+
+// ElasticsearchMapping returns an index mapping for intermediate schema
+// documents, derived from the struct tags, for the create index API. Dotted
+// names, like "rft.atitle", are objects in Elasticsearch, so the mapping is
+// nested accordingly.
+func ElasticsearchMapping() ([]byte, error) {
+	mapping := map[string]interface{}{
+		"mappings": map[string]interface{}{
+			"properties": esProperties(reflect.TypeOf(IntermediateSchema{})),
+		},
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(mapping); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// esProperties returns the properties of a struct type.
+func esProperties(t reflect.Type) map[string]interface{} {
+	props := make(map[string]interface{})
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		// Nest dotted names, e.g. "rft.atitle" into "rft" and "atitle".
+		var (
+			parts = strings.Split(name, ".")
+			m     = props
+		)
+		for _, p := range parts[:len(parts)-1] {
+			obj, ok := m[p].(map[string]interface{})
+			if !ok {
+				obj = map[string]interface{}{"properties": make(map[string]interface{})}
+				m[p] = obj
+			}
+			m = obj["properties"].(map[string]interface{})
+		}
+		m[parts[len(parts)-1]] = esType(name, t.Field(i).Type)
+	}
+	return props
+}
+
+// esType returns the mapping of a single field.
+func esType(name string, t reflect.Type) map[string]interface{} {
+	if t == reflect.TypeOf(time.Time{}) {
+		return map[string]interface{}{"type": "date"}
+	}
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice:
+		return esType(name, t.Elem())
+	case reflect.Struct:
+		return map[string]interface{}{"properties": esProperties(t)}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int64:
+		return map[string]interface{}{"type": "long"}
+	}
+	switch {
+	case esDateFields[name]:
+		return map[string]interface{}{"type": "date"}
+	case esTextFields[name]:
+		return map[string]interface{}{
+			"type": "text",
+			"fields": map[string]interface{}{
+				"keyword": map[string]interface{}{"type": "keyword", "ignore_above": 256},
+			},
+		}
+	default:
+		return map[string]interface{}{"type": "keyword"}
+	}
+}
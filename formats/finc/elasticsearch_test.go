@@ -0,0 +1,109 @@
+package finc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/segmentio/encoding/json"
+)
+
+func TestElasticsearchBulk(t *testing.T) {
+	is := IntermediateSchema{ID: "ai-1-x", RecordID: "x", ArticleTitle: "On Tags", RawDate: "2019-01-01", Version: Version10}
+	var cases = []struct {
+		about    string
+		exporter ElasticsearchBulk
+		is       IntermediateSchema
+		want     string
+		err      bool
+	}{
+		{
+			about: "defaults",
+			is:    is,
+			want:  `{"index":{"_index":"ai","_id":"ai-1-x"}}` + "\n" + `{"finc.id":"ai-1-x","finc.record_id":"x","rft.atitle":"On Tags","rft.date":"2019-01-01","version":"1.0"}`,
+		},
+		{
+			about:    "create with record id",
+			exporter: ElasticsearchBulk{Index: "test", IDField: "finc.record_id", OpType: "create"},
+			is:       is,
+			want:     `{"create":{"_index":"test","_id":"x"}}` + "\n" + `{"finc.id":"ai-1-x","finc.record_id":"x","rft.atitle":"On Tags","rft.date":"2019-01-01","version":"1.0"}`,
+		},
+		{
+			about: "tombstone",
+			is:    *NewTombstone("ai-1-x", "1", "x"),
+			want:  `{"delete":{"_index":"ai","_id":"ai-1-x"}}`,
+		},
+		{
+			about:    "unknown op type",
+			exporter: ElasticsearchBulk{OpType: "update"},
+			is:       is,
+			err:      true,
+		},
+		{
+			about: "index without id",
+			is:    IntermediateSchema{RecordID: "x", ArticleTitle: "On Tags"},
+			err:   true,
+		},
+		{
+			about:    "create without id",
+			exporter: ElasticsearchBulk{IDField: "doi", OpType: "create"},
+			is:       is,
+			err:      true,
+		},
+		{
+			about: "tombstone without id",
+			is:    *NewTombstone("", "1", "x"),
+			err:   true,
+		},
+		{
+			about:    "unknown id field",
+			exporter: ElasticsearchBulk{IDField: "finc.idx"},
+			is:       is,
+			err:      true,
+		},
+	}
+	for _, c := range cases {
+		b, err := c.exporter.Export(c.is, false)
+		if (err != nil) != c.err {
+			t.Errorf("%s: got %v, want error %v", c.about, err, c.err)
+		}
+		if err == nil && string(b) != c.want {
+			t.Errorf("%s: got %s, want %s", c.about, b, c.want)
+		}
+	}
+}
+
+func TestElasticsearchMapping(t *testing.T) {
+	b, err := ElasticsearchMapping()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatal(err)
+	}
+	var cases = []struct {
+		path string
+		typ  string
+	}{
+		{"finc.id", "keyword"},
+		{"rft.atitle", "text"},
+		{"rft.date", "date"},
+		{"x.date", "date"},
+		{"x.oa", "boolean"},
+		{"authors.rft.aulast", "keyword"},
+		{"x.provenance.offset", "long"},
+		{"x.provenance.converted", "date"},
+	}
+	for _, c := range cases {
+		v := m["mappings"]
+		for _, p := range strings.Split(c.path, ".") {
+			v = v.(map[string]interface{})["properties"].(map[string]interface{})[p]
+			if v == nil {
+				t.Fatalf("%s: missing %s", c.path, p)
+			}
+		}
+		if typ := v.(map[string]interface{})["type"]; typ != c.typ {
+			t.Errorf("%s: got %v, want %s", c.path, typ, c.typ)
+		}
+	}
+}
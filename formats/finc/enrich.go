@@ -0,0 +1,74 @@
+package finc
+
+import (
+	"strings"
+
+	"github.com/miku/span"
+)
+
+// InferredLanguages marks languages, that were detected during import.
+const InferredLanguages = "languages"
+
+// DefaultMinConfidence is the confidence a detected language needs by
+// default, the threshold of the detection library for reliable results.
+const DefaultMinConfidence = 0.8
+
+// LanguageDetector fills in languages from title and abstract for documents,
+// where the source gives none or only "und" (undetermined). Detection on
+// short texts is unreliable, so only results above a minimum confidence are
+// used.
+type LanguageDetector struct {
+	// MinConfidence, between 0 and 1.
+	MinConfidence float64
+	// Sources restricts detection to these source ids, all sources, if empty.
+	Sources map[string]bool
+}
+
+// NewLanguageDetector creates a detector for a comma separated list of source
+// ids, an empty string means all sources.
+func NewLanguageDetector(minConfidence float64, sources string) *LanguageDetector {
+	d := &LanguageDetector{MinConfidence: minConfidence, Sources: make(map[string]bool)}
+	for _, sid := range strings.Split(sources, ",") {
+		if sid = strings.TrimSpace(sid); sid != "" {
+			d.Sources[sid] = true
+		}
+	}
+	return d
+}
+
+// Enrich sets the language of a document, if it has none and the language
+// can be detected with enough confidence. The field is marked as inferred.
+// Enrich returns true, if the document was changed.
+func (d *LanguageDetector) Enrich(is *IntermediateSchema) bool {
+	if is.Deleted || hasLanguage(is.Languages) {
+		return false
+	}
+	if len(d.Sources) > 0 && !d.Sources[is.SourceID] {
+		return false
+	}
+	var parts []string
+	for _, s := range []string{is.ArticleTitle, is.ArticleSubtitle, is.BookTitle, is.AbstractCleaned()} {
+		if s = strings.TrimSpace(s); s != "" {
+			parts = append(parts, s)
+		}
+	}
+	code, confidence := span.DetectLanguage(strings.Join(parts, "\n"))
+	if code == "" || confidence < d.MinConfidence {
+		return false
+	}
+	is.Languages = []string{code}
+	if !contains(is.Inferred, InferredLanguages) {
+		is.Inferred = append(is.Inferred, InferredLanguages)
+	}
+	return true
+}
+
+// hasLanguage returns true, if there is at least one determined language.
+func hasLanguage(languages []string) bool {
+	for _, l := range languages {
+		if l != "und" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package finc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLanguageDetector(t *testing.T) {
+	var (
+		title = "in Hoffnung den Grund und die rechte Tieffe darinnen zu finden"
+		cases = []struct {
+			about     string
+			is        IntermediateSchema
+			sources   string
+			languages []string
+			enriched  bool
+		}{
+			{
+				about:     "title only",
+				is:        IntermediateSchema{SourceID: "1", ArticleTitle: title},
+				languages: []string{"deu"},
+				enriched:  true,
+			},
+			{
+				about:     "abstract with markup",
+				is:        IntermediateSchema{SourceID: "1", Abstract: "<p>" + title + "</p>"},
+				languages: []string{"deu"},
+				enriched:  true,
+			},
+			{
+				about:     "language given by source",
+				is:        IntermediateSchema{SourceID: "1", ArticleTitle: title, Languages: []string{"eng"}},
+				languages: []string{"eng"},
+			},
+			{
+				about:     "undetermined language",
+				is:        IntermediateSchema{SourceID: "1", ArticleTitle: title, Languages: []string{"und"}},
+				languages: []string{"deu"},
+				enriched:  true,
+			},
+			{
+				about:   "source not allowed",
+				is:      IntermediateSchema{SourceID: "1", ArticleTitle: title},
+				sources: "2, 3",
+			},
+			{
+				about:     "source allowed",
+				is:        IntermediateSchema{SourceID: "3", ArticleTitle: title},
+				sources:   "2, 3",
+				languages: []string{"deu"},
+				enriched:  true,
+			},
+			{
+				about: "low confidence",
+				is:    IntermediateSchema{SourceID: "1", ArticleTitle: "Hello World"},
+			},
+			{
+				about: "tombstone",
+				is:    IntermediateSchema{SourceID: "1", ArticleTitle: title, Deleted: true},
+			},
+		}
+	)
+	for _, c := range cases {
+		d := NewLanguageDetector(DefaultMinConfidence, c.sources)
+		if enriched := d.Enrich(&c.is); enriched != c.enriched {
+			t.Errorf("%s: got %v, want %v", c.about, enriched, c.enriched)
+		}
+		if !reflect.DeepEqual(c.is.Languages, c.languages) {
+			t.Errorf("%s: got %v, want %v", c.about, c.is.Languages, c.languages)
+		}
+		if inferred := len(c.is.Inferred) > 0; inferred != c.enriched {
+			t.Errorf("%s: got inferred %v, want %v", c.about, c.is.Inferred, c.enriched)
+		}
+	}
+}
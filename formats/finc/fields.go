@@ -0,0 +1,148 @@
+package finc
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// yearPattern finds a plausible year in a free-form date string.
+	yearPattern = regexp.MustCompile(`[12][0-9]{3}`)
+	// isoDatePattern finds an ISO 8601 date or year and month.
+	isoDatePattern = regexp.MustCompile(`[12][0-9]{3}-[01][0-9](-[0-3][0-9])?`)
+)
+
+// fieldIndex maps JSON field names to struct field indices, e.g. "rft.atitle"
+// to the index of ArticleTitle. Nested blocks, like provenance, cannot be set
+// from strings and are left out.
+var fieldIndex = func() map[string]int {
+	m := make(map[string]int)
+	t := reflect.TypeOf(IntermediateSchema{})
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" && t.Field(i).Type.Kind() != reflect.Ptr {
+			m[name] = i
+		}
+	}
+	return m
+}()
+
+// IsField returns true, if name is the JSON name of a field, e.g. "rft.issn".
+func IsField(name string) bool {
+	_, ok := fieldIndex[name]
+	return ok
+}
+
+// SetField sets a field by its JSON name, e.g. "rft.atitle", from one or more
+// string values. Single valued fields use the first non-empty value, slices
+// get all non-empty values appended, without duplicates. Authors are taken as
+// names, "x.date" accepts YYYY-MM-DD, YYYY-MM or anything with a year in it
+// and sets "rft.date" as well. Mapping based formats (MARC, MODS, Dublin Core) use this to stay
+// format agnostic.
+func (is *IntermediateSchema) SetField(name string, values ...string) error {
+	var vs []string
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			vs = append(vs, v)
+		}
+	}
+	if len(vs) == 0 {
+		return nil
+	}
+	i, ok := fieldIndex[name]
+	if !ok {
+		return fmt.Errorf("unknown intermediate schema field: %s", name)
+	}
+	field := reflect.ValueOf(is).Elem().Field(i)
+	switch v := field.Addr().Interface().(type) {
+	case *string:
+		*v = vs[0]
+	case *[]string:
+		for _, s := range vs {
+			if !contains(*v, s) {
+				*v = append(*v, s)
+			}
+		}
+	case *bool:
+		b, err := strconv.ParseBool(vs[0])
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		*v = b
+	case *[]Author:
+		for _, s := range vs {
+			*v = append(*v, Author{Name: s})
+		}
+	case *time.Time:
+		t, err := parseDate(vs[0])
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		*v = t
+		is.RawDate = t.Format("2006-01-02")
+	default:
+		return fmt.Errorf("cannot set field %s of type %T", name, v)
+	}
+	return nil
+}
+
+// parseDate finds the most precise date in a free-form string: a full date,
+// year and month or a year.
+func parseDate(s string) (time.Time, error) {
+	if m := isoDatePattern.FindString(s); m != "" {
+		layout := "2006-01"
+		if len(m) == len("2006-01-02") {
+			layout = "2006-01-02"
+		}
+		if t, err := time.Parse(layout, m); err == nil {
+			return t, nil
+		}
+	}
+	year := yearPattern.FindString(s)
+	if year == "" {
+		return time.Time{}, fmt.Errorf("no year found in %q", s)
+	}
+	return time.Parse("2006", year)
+}
+
+// Field returns the values of a field by its JSON name, the reverse of
+// SetField. Single valued fields yield exactly one value, which may be empty,
+// authors are formatted names and "x.date" is formatted as YYYY-MM-DD.
+func (is *IntermediateSchema) Field(name string) ([]string, error) {
+	i, ok := fieldIndex[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown intermediate schema field: %s", name)
+	}
+	switch v := reflect.ValueOf(is).Elem().Field(i).Interface().(type) {
+	case string:
+		return []string{v}, nil
+	case []string:
+		return append([]string(nil), v...), nil
+	case bool:
+		return []string{strconv.FormatBool(v)}, nil
+	case []Author:
+		var names []string
+		for _, a := range v {
+			names = append(names, a.String())
+		}
+		return names, nil
+	case time.Time:
+		return []string{v.Format("2006-01-02")}, nil
+	default:
+		return nil, fmt.Errorf("cannot get field %s of type %T", name, v)
+	}
+}
+
+// contains returns true, if s is in ss.
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,34 @@
+package finc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetFieldDate(t *testing.T) {
+	var cases = []struct {
+		value string
+		want  time.Time
+		err   bool
+	}{
+		{"2019-05-12", time.Date(2019, 5, 12, 0, 0, 0, 0, time.UTC), false},
+		{"2019-05-12T10:00:00Z", time.Date(2019, 5, 12, 0, 0, 0, 0, time.UTC), false},
+		{"2019-05", time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC), false},
+		{"[ca. 2019]", time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), false},
+		{"2019-13-01", time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), false},
+		{"undated", time.Time{}, true},
+	}
+	for _, c := range cases {
+		var is IntermediateSchema
+		err := is.SetField("x.date", c.value)
+		if (err != nil) != c.err {
+			t.Fatalf("%s: got %v, want error %v", c.value, err, c.err)
+		}
+		if !is.Date.Equal(c.want) {
+			t.Errorf("%s: got %v, want %v", c.value, is.Date, c.want)
+		}
+		if !c.err && is.RawDate != c.want.Format("2006-01-02") {
+			t.Errorf("%s: got rft.date %q", c.value, is.RawDate)
+		}
+	}
+}
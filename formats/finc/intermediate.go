@@ -33,7 +33,7 @@ import (
 const (
 	IntermediateSchemaRecordType = "is"
 	AIRecordType                 = "ai"
-	IntermediateSchemaVersion    = "0.9"
+	IntermediateSchemaVersion    = Version09
 )
 
 var (
@@ -49,6 +49,26 @@ type Exporter interface {
 	Export(is IntermediateSchema, withFullrecord bool) ([]byte, error)
 }
 
+// SelfDelimiter is implemented by exporters, whose records carry their own
+// terminator, like ISO 2709, so no newline is appended.
+type SelfDelimiter interface {
+	SelfDelimiting() bool
+}
+
+// Framer is implemented by exporters, whose records must be wrapped to form a
+// valid document, like MARCXML records in a collection element. The header
+// is written before the first and the footer after the last record.
+type Framer interface {
+	Header() []byte
+	Footer() []byte
+}
+
+// TombstoneExporter is implemented by exporters, that write deletions of
+// records into their output, instead of leaving them out.
+type TombstoneExporter interface {
+	ExportTombstone(is IntermediateSchema) ([]byte, error)
+}
+
 // Author representes an author, "inspired" by OpenURL.
 type Author struct {
 	ID           string `json:"x.id,omitempty"`
@@ -67,6 +87,26 @@ type Author struct {
 	Corporate string `json:"rft.aucorp,omitempty"`
 }
 
+// Provenance tells, which input, conversion and span version produced a
+// document, to trace problems in the index back to their origin.
+type Provenance struct {
+	// Filename of the input, empty for standard input.
+	Filename string `json:"filename,omitempty"`
+	// Offset is the byte offset of the record in the uncompressed input,
+	// for XML formats.
+	Offset int64 `json:"offset,omitempty"`
+	// Line is the line number of the record, for line based formats.
+	Line int64 `json:"line,omitempty"`
+	// Format is the name of the input format, e.g. "crossref".
+	Format string `json:"format,omitempty"`
+	// Mapping is the mapping or settings file, if any.
+	Mapping string `json:"mapping,omitempty"`
+	// Version of span, see span.AppVersion.
+	Version string `json:"version,omitempty"`
+	// Converted is the time of the conversion.
+	Converted time.Time `json:"converted"`
+}
+
 // String returns a formatted author string.
 // TODO(miku): make this complete.
 func (author *Author) String() string {
@@ -129,7 +169,8 @@ type IntermediateSchema struct {
 	Publishers   []string `json:"rft.pub,omitempty"`
 	Quarter      string   `json:"rft.quarter,omitempty"`
 
-	// TODO(miku): we do not need both dates
+	// Both carry the same date, version 1.0 only keeps RawDate on the wire,
+	// Date is filled in on decoding, see Migrate09To10.
 	RawDate string    `json:"rft.date,omitempty"`
 	Date    time.Time `json:"x.date,omitempty"`
 
@@ -165,6 +206,17 @@ type IntermediateSchema struct {
 
 	// Footnote, via solr schema, refs #13653
 	Footnotes []string `json:"x.footnotes,omitempty"`
+
+	// Deleted marks a tombstone, a record that has been deleted or withdrawn
+	// at the source and should be removed from the index.
+	Deleted bool `json:"x.deleted,omitempty"`
+
+	// Inferred lists fields, that were not given by the source, but filled
+	// in during import, e.g. "languages", see LanguageDetector.
+	Inferred []string `json:"x.inferred,omitempty"`
+
+	// Provenance is optional, see span-import -provenance.
+	Provenance *Provenance `json:"x.provenance,omitempty"`
 }
 
 // NewIntermediateSchema creates a new intermediate schema document with the
@@ -173,28 +225,47 @@ func NewIntermediateSchema() *IntermediateSchema {
 	return &IntermediateSchema{Version: IntermediateSchemaVersion}
 }
 
-// ISSNList returns a deduplicated list of all ISSN and EISSN.
-func (is *IntermediateSchema) ISSNList() []string {
-	set := make(map[string]struct{})
-	for _, issn := range append(is.ISSN, is.EISSN...) {
-		set[issn] = struct{}{}
+// NewTombstone creates a minimal document, that only carries the identifiers
+// of a deleted record. The id must match the id, the record had when it was
+// converted.
+func NewTombstone(id, sourceID, recordID string) *IntermediateSchema {
+	return &IntermediateSchema{
+		ID:       id,
+		SourceID: sourceID,
+		RecordID: recordID,
+		Version:  IntermediateSchemaVersion,
+		Deleted:  true,
 	}
+}
+
+// ISSNList returns a deduplicated list of all ISSN and EISSN, in order.
+func (is *IntermediateSchema) ISSNList() []string {
 	var issns []string
-	for k := range set {
-		issns = append(issns, k)
+	for _, issn := range is.ISSN {
+		if !contains(issns, issn) {
+			issns = append(issns, issn)
+		}
+	}
+	for _, issn := range is.EISSN {
+		if !contains(issns, issn) {
+			issns = append(issns, issn)
+		}
 	}
 	return issns
 }
 
-// ISBNList returns a deduplicated list of all ISBN and EISBN.
+// ISBNList returns a deduplicated list of all ISBN and EISBN, in order.
 func (is *IntermediateSchema) ISBNList() []string {
-	set := make(map[string]struct{})
-	for _, isbn := range append(is.ISBN, is.EISBN...) {
-		set[isbn] = struct{}{}
-	}
 	var isbns []string
-	for k := range set {
-		isbns = append(isbns, k)
+	for _, isbn := range is.ISBN {
+		if !contains(isbns, isbn) {
+			isbns = append(isbns, isbn)
+		}
+	}
+	for _, isbn := range is.EISBN {
+		if !contains(isbns, isbn) {
+			isbns = append(isbns, isbn)
+		}
 	}
 	return isbns
 }
@@ -306,6 +377,27 @@ func (is *IntermediateSchema) AbstractCleaned() string {
 	return sanitize.HTML(is.Abstract)
 }
 
+// DateParts returns year, month and day of the date, as far as they are
+// known, or nil for a document without date. A year or a year and month in
+// rft.date limit the precision. Converters store a bare year as January 1st,
+// so this date counts as a year only.
+func (is *IntermediateSchema) DateParts() []int {
+	if is.Date.IsZero() {
+		return nil
+	}
+	parts := []int{is.Date.Year(), int(is.Date.Month()), is.Date.Day()}
+	switch {
+	case len(is.RawDate) == 4:
+		return parts[:1]
+	case len(is.RawDate) == 7:
+		return parts[:2]
+	case parts[1] == 1 && parts[2] == 1:
+		return parts[:1]
+	default:
+		return parts
+	}
+}
+
 // StrippedSchema is a snippet of an IntermediateSchema.
 type StrippedSchema struct {
 	DOI      string   `json:"doi"`
@@ -0,0 +1,29 @@
+package finc
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDateParts(t *testing.T) {
+	var cases = []struct {
+		about   string
+		date    time.Time
+		rawDate string
+		want    []int
+	}{
+		{"no date", time.Time{}, "", nil},
+		{"date", time.Date(2019, 5, 2, 0, 0, 0, 0, time.UTC), "2019-05-02", []int{2019, 5, 2}},
+		{"first of month", time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC), "2019-05-01", []int{2019, 5, 1}},
+		{"year as january first", time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), "2019-01-01", []int{2019}},
+		{"year", time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), "2019", []int{2019}},
+		{"month", time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC), "2019-05", []int{2019, 5}},
+	}
+	for _, c := range cases {
+		is := IntermediateSchema{Date: c.date, RawDate: c.rawDate}
+		if got := is.DateParts(); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: got %v, want %v", c.about, got, c.want)
+		}
+	}
+}
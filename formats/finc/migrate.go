@@ -0,0 +1,169 @@
+package finc
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/segmentio/encoding/json"
+)
+
+// Known intermediate schema versions. Version 1.0 drops the redundant
+// "x.date" and keeps a single ISO 8601 date in "rft.date". Converters still
+// write IntermediateSchemaVersion during the transition, span-migrate
+// upgrades stored files.
+const (
+	Version09 = "0.9"
+	Version10 = "1.0"
+)
+
+// Versions lists all supported versions, oldest first.
+var Versions = []string{Version09, Version10}
+
+// migrations maps a version to the functions, that move a document one
+// version up or down.
+var migrations = map[string]struct {
+	up, down func(*IntermediateSchema) error
+}{
+	Version09: {up: Migrate09To10},
+	Version10: {down: Migrate10To09},
+}
+
+// isoDate is the layout of "rft.date".
+const isoDate = "2006-01-02"
+
+// Versioned reads and writes a document in the layout of its version: 1.0
+// documents omit "x.date" and get Date from "rft.date" when read.
+// IntermediateSchema itself has no custom JSON methods, which keeps
+// converters and exporters fast, so only use Versioned, where documents may
+// be 1.0, like in span-migrate.
+type Versioned struct {
+	*IntermediateSchema
+}
+
+// MarshalJSON omits "x.date" from 1.0 documents.
+func (v Versioned) MarshalJSON() ([]byte, error) {
+	if !isVersion1(v.Version) {
+		return json.Marshal(v.IntermediateSchema)
+	}
+	return json.Marshal(struct {
+		IntermediateSchema
+		Date *time.Time `json:"x.date,omitempty"`
+	}{IntermediateSchema: *v.IntermediateSchema})
+}
+
+// Marshal serializes a document in the layout of its version. Only 1.0
+// documents take the detour through Versioned.
+func Marshal(is *IntermediateSchema) ([]byte, error) {
+	if isVersion1(is.Version) {
+		return json.Marshal(Versioned{is})
+	}
+	return json.Marshal(is)
+}
+
+// UnmarshalJSON reads both 0.9 and 1.0 documents.
+func (v *Versioned) UnmarshalJSON(p []byte) error {
+	if v.IntermediateSchema == nil {
+		v.IntermediateSchema = new(IntermediateSchema)
+	}
+	if err := json.Unmarshal(p, v.IntermediateSchema); err != nil {
+		return err
+	}
+	return v.SetVersionDate()
+}
+
+// SetVersionDate sets Date from "rft.date" for 1.0 documents, so consumers
+// can use Date regardless of the version. Readers, that decode plain
+// documents and may get 1.0 input, call it after decoding.
+func (is *IntermediateSchema) SetVersionDate() error {
+	if !isVersion1(is.Version) || is.RawDate == "" {
+		return nil
+	}
+	t, err := time.Parse(isoDate, is.RawDate)
+	if err != nil {
+		return fmt.Errorf("rft.date: %w", err)
+	}
+	is.Date = t
+	return nil
+}
+
+// isVersion1 returns true for 1.x versions.
+func isVersion1(v string) bool {
+	return strings.HasPrefix(v, "1.")
+}
+
+// Migrate09To10 upgrades a document from 0.9 to 1.0. The date is taken from
+// "x.date", since that is what all exports use, falling back to "rft.date".
+func Migrate09To10(is *IntermediateSchema) error {
+	if is.Version != Version09 {
+		return fmt.Errorf("cannot migrate version %q to %s", is.Version, Version10)
+	}
+	switch {
+	case !is.Date.IsZero():
+		is.RawDate = is.Date.Format(isoDate)
+	case is.RawDate != "":
+		t, err := time.Parse(isoDate, is.RawDate)
+		if err != nil {
+			return fmt.Errorf("rft.date: %w", err)
+		}
+		is.Date = t
+	}
+	is.Version = Version10
+	return nil
+}
+
+// Migrate10To09 downgrades a document from 1.0 to 0.9, where "x.date" is set
+// again, as long as there is a date.
+func Migrate10To09(is *IntermediateSchema) error {
+	if is.Version != Version10 {
+		return fmt.Errorf("cannot migrate version %q to %s", is.Version, Version09)
+	}
+	if is.RawDate != "" {
+		t, err := time.Parse(isoDate, is.RawDate)
+		if err != nil {
+			return fmt.Errorf("rft.date: %w", err)
+		}
+		is.Date = t
+	}
+	is.Version = Version09
+	return nil
+}
+
+// Migrate moves a document step by step to a given version. Documents
+// without version are taken as 0.9.
+func Migrate(is *IntermediateSchema, version string) error {
+	if is.Version == "" {
+		is.Version = Version09
+	}
+	target, current := versionIndex(version), versionIndex(is.Version)
+	if target == -1 {
+		return fmt.Errorf("unknown version: %s", version)
+	}
+	if current == -1 {
+		return fmt.Errorf("unknown version: %s", is.Version)
+	}
+	for current != target {
+		var (
+			m = migrations[is.Version]
+			f = m.up
+		)
+		if current > target {
+			f = m.down
+		}
+		if err := f(is); err != nil {
+			return err
+		}
+		current = versionIndex(is.Version)
+	}
+	return nil
+}
+
+// versionIndex returns the position of a version in Versions or -1.
+func versionIndex(v string) int {
+	for i, w := range Versions {
+		if v == w {
+			return i
+		}
+	}
+	return -1
+}
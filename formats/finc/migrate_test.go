@@ -0,0 +1,64 @@
+package finc
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/segmentio/encoding/json"
+)
+
+func TestMigrate(t *testing.T) {
+	doc := `{"finc.record_id":"1","rft.date":"2001-02-03","x.date":"2001-02-03T00:00:00Z","version":"0.9"}`
+	var is IntermediateSchema
+	if err := json.Unmarshal([]byte(doc), &is); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if err := Migrate(&is, Version10); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	b, err := json.Marshal(Versioned{&is})
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	want := `{"finc.record_id":"1","rft.date":"2001-02-03","version":"1.0"}`
+	if string(b) != want {
+		t.Fatalf("got %s, want %s", b, want)
+	}
+	// Consumers get a date from 1.0 documents, too.
+	var v IntermediateSchema
+	if err := json.Unmarshal(b, &Versioned{IntermediateSchema: &v}); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if !v.Date.Equal(time.Date(2001, 2, 3, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("got %v, want 2001-02-03", v.Date)
+	}
+	if err := Migrate(&v, Version09); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if b, err = json.Marshal(Versioned{&v}); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if string(b) != doc {
+		t.Fatalf("got %s, want %s", b, doc)
+	}
+}
+
+func TestMigrateErrors(t *testing.T) {
+	var cases = []struct {
+		about   string
+		is      IntermediateSchema
+		version string
+		err     string
+	}{
+		{"unknown target", IntermediateSchema{Version: Version09}, "2.0", "unknown version"},
+		{"unknown source", IntermediateSchema{Version: "0.8"}, Version10, "unknown version"},
+		{"invalid date", IntermediateSchema{Version: Version09, RawDate: "2001"}, Version10, "rft.date"},
+	}
+	for _, c := range cases {
+		err := Migrate(&c.is, c.version)
+		if err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Errorf("%s: got %v, want %s", c.about, err, c.err)
+		}
+	}
+}
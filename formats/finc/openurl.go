@@ -0,0 +1,160 @@
+package finc
+
+import (
+	"html"
+	"net/url"
+)
+
+const (
+	// KEVVersion is the version of the OpenURL context object format.
+	KEVVersion = "Z39.88-2004"
+	// DefaultReferrerID identifies span as the source of a context object.
+	DefaultReferrerID = "info:sid/finc.info:span"
+
+	// Metadata formats of the referent.
+	FormatJournal      = "info:ofi/fmt:kev:mtx:journal"
+	FormatBook         = "info:ofi/fmt:kev:mtx:book"
+	FormatDissertation = "info:ofi/fmt:kev:mtx:dissertation"
+)
+
+// MetadataFormat returns the KEV metadata format for a document, chosen from
+// rft.genre. Theses are dissertations, proceedings and unknown genres are
+// journal items, if there is a journal, books otherwise.
+func (is *IntermediateSchema) MetadataFormat() string {
+	if is.RefType == "THES" || is.Format == "ElectronicThesis" || is.Format == "Thesis" {
+		return FormatDissertation
+	}
+	switch is.Genre {
+	case "article", "issue", "journal", "preprint":
+		return FormatJournal
+	case "book", "bookitem", "document", "report":
+		return FormatBook
+	}
+	if is.JournalTitle != "" || len(is.ISSN) > 0 || len(is.EISSN) > 0 {
+		return FormatJournal
+	}
+	return FormatBook
+}
+
+// ContextObject returns a Z39.88-2004 KEV context object, suitable as query
+// string for a link resolver. The referrer id defaults to DefaultReferrerID.
+// The first author goes into aulast, aufirst and the like, all authors are
+// listed in au.
+func (is *IntermediateSchema) ContextObject(referrerID string) url.Values {
+	if referrerID == "" {
+		referrerID = DefaultReferrerID
+	}
+	var (
+		v      = url.Values{}
+		format = is.MetadataFormat()
+	)
+	add := func(key string, values ...string) {
+		for _, s := range values {
+			if s != "" {
+				v.Add(key, s)
+			}
+		}
+	}
+	add("ctx_ver", KEVVersion)
+	add("ctx_enc", "info:ofi/enc:UTF-8")
+	add("rfr_id", referrerID)
+	add("rft_val_fmt", format)
+	if is.DOI != "" {
+		add("rft_id", "info:doi/"+is.DOI)
+	}
+	add("rft_id", is.URL...)
+
+	date := is.RawDate
+	if date == "" && !is.Date.IsZero() {
+		date = is.Date.Format("2006-01-02")
+	}
+	add("rft.date", date)
+	if len(is.Authors) > 0 {
+		a := is.Authors[0]
+		add("rft.aulast", a.LastName)
+		add("rft.aufirst", a.FirstName)
+		add("rft.auinit", a.Initial)
+		add("rft.auinit1", a.FirstInitial)
+		add("rft.auinitm", a.MiddleName)
+		add("rft.ausuffix", a.Suffix)
+		add("rft.aucorp", a.Corporate)
+	}
+	for _, a := range is.Authors {
+		if a.Name != "" || a.LastName != "" {
+			add("rft.au", a.String())
+		}
+	}
+	add("rft.isbn", is.ISBN...)
+	add("rft.tpages", is.PageCount)
+
+	switch format {
+	case FormatDissertation:
+		title := is.ArticleTitle
+		if title == "" {
+			title = is.BookTitle
+		}
+		add("rft.title", title)
+		if len(is.Publishers) > 0 {
+			add("rft.inst", is.Publishers[0])
+		}
+		return v
+	case FormatJournal:
+		add("rft.genre", genreOrUnknown(is.Genre, "article", "issue", "journal", "preprint", "proceeding", "conference"))
+		add("rft.jtitle", is.JournalTitle)
+		add("rft.stitle", is.ShortTitle)
+		add("rft.chron", is.Chronology)
+		add("rft.ssn", is.Season)
+		add("rft.quarter", is.Quarter)
+		add("rft.part", is.Part)
+		add("rft.issue", is.Issue)
+		add("rft.artnum", is.ArticleNumber)
+	case FormatBook:
+		add("rft.genre", genreOrUnknown(is.Genre, "book", "bookitem", "conference", "proceeding", "report", "document"))
+		add("rft.btitle", is.BookTitle)
+		add("rft.place", is.Places...)
+		add("rft.pub", is.Publishers...)
+		add("rft.edition", is.Edition)
+		add("rft.series", is.Series)
+	}
+	add("rft.atitle", is.ArticleTitle)
+	add("rft.volume", is.Volume)
+	add("rft.spage", is.StartPage)
+	add("rft.epage", is.EndPage)
+	add("rft.pages", is.Pages)
+	add("rft.issn", is.ISSN...)
+	add("rft.eissn", is.EISSN...)
+	return v
+}
+
+// OpenURL returns the encoded KEV context object.
+func (is *IntermediateSchema) OpenURL() string {
+	return is.ContextObject("").Encode()
+}
+
+// COinS returns the context object embedded in an HTML span, see
+// https://en.wikipedia.org/wiki/COinS.
+func (is *IntermediateSchema) COinS() string {
+	return `<span class="Z3988" title="` + html.EscapeString(is.OpenURL()) + `"></span>`
+}
+
+// genreOrUnknown returns the genre, if it is allowed in the metadata format.
+func genreOrUnknown(genre string, allowed ...string) string {
+	if contains(allowed, genre) {
+		return genre
+	}
+	return "unknown"
+}
+
+// OpenURLExporter writes one tab separated line per document: the finc.id
+// and the OpenURL query string, or, if COinS is set, only the COinS span.
+type OpenURLExporter struct {
+	COinS bool
+}
+
+// Export formats the context object of a document.
+func (e *OpenURLExporter) Export(is IntermediateSchema, _ bool) ([]byte, error) {
+	if e.COinS {
+		return []byte(is.COinS()), nil
+	}
+	return []byte(is.ID + "\t" + is.OpenURL()), nil
+}
@@ -0,0 +1,104 @@
+package finc
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestContextObject(t *testing.T) {
+	var cases = []struct {
+		about string
+		is    IntermediateSchema
+		want  url.Values
+	}{
+		{
+			about: "journal article",
+			is: IntermediateSchema{
+				ArticleTitle: "On Tags",
+				Authors:      []Author{{LastName: "Doe", FirstName: "Jane"}, {Name: "John Smith"}},
+				DOI:          "10.1/x",
+				Genre:        "article",
+				ISSN:         []string{"1234-5678"},
+				JournalTitle: "Journal of Tags",
+				RawDate:      "2019-05-02",
+				StartPage:    "12",
+				Volume:       "3",
+			},
+			want: url.Values{
+				"rft_val_fmt": {FormatJournal},
+				"rft_id":      {"info:doi/10.1/x"},
+				"rft.genre":   {"article"},
+				"rft.atitle":  {"On Tags"},
+				"rft.jtitle":  {"Journal of Tags"},
+				"rft.aulast":  {"Doe"},
+				"rft.aufirst": {"Jane"},
+				"rft.au":      {"Doe, Jane", "John Smith"},
+				"rft.date":    {"2019-05-02"},
+				"rft.issn":    {"1234-5678"},
+				"rft.spage":   {"12"},
+				"rft.volume":  {"3"},
+			},
+		},
+		{
+			about: "book chapter",
+			is: IntermediateSchema{
+				ArticleTitle: "On Tags",
+				BookTitle:    "Tags and Formats",
+				Genre:        "bookitem",
+				ISBN:         []string{"978-3-16-148410-0"},
+				Publishers:   []string{"Tag Press"},
+			},
+			want: url.Values{
+				"rft_val_fmt": {FormatBook},
+				"rft.genre":   {"bookitem"},
+				"rft.atitle":  {"On Tags"},
+				"rft.btitle":  {"Tags and Formats"},
+				"rft.isbn":    {"978-3-16-148410-0"},
+				"rft.pub":     {"Tag Press"},
+			},
+		},
+		{
+			about: "proceeding without journal",
+			is:    IntermediateSchema{BookTitle: "Proceedings", Genre: "proceeding"},
+			want: url.Values{
+				"rft_val_fmt": {FormatBook},
+				"rft.genre":   {"proceeding"},
+				"rft.btitle":  {"Proceedings"},
+			},
+		},
+		{
+			about: "thesis",
+			is:    IntermediateSchema{ArticleTitle: "On Tags", Genre: "book", RefType: "THES", Publishers: []string{"Leipzig University"}},
+			want: url.Values{
+				"rft_val_fmt": {FormatDissertation},
+				"rft.title":   {"On Tags"},
+				"rft.inst":    {"Leipzig University"},
+			},
+		},
+	}
+	for _, c := range cases {
+		v := c.is.ContextObject("")
+		if v.Get("ctx_ver") != KEVVersion || v.Get("rfr_id") != DefaultReferrerID {
+			t.Errorf("%s: missing context object keys: %v", c.about, v)
+		}
+		for _, k := range []string{"ctx_ver", "ctx_enc", "rfr_id"} {
+			v.Del(k)
+		}
+		if !reflect.DeepEqual(v, c.want) {
+			t.Errorf("%s: got %v, want %v", c.about, v, c.want)
+		}
+	}
+}
+
+func TestCOinS(t *testing.T) {
+	is := IntermediateSchema{ArticleTitle: "Tags & Formats", Genre: "article"}
+	s := is.COinS()
+	if !strings.HasPrefix(s, `<span class="Z3988" title="ctx_enc=`) || strings.Contains(s, "&ctx") {
+		t.Errorf("unexpected COinS: %s", s)
+	}
+	if !strings.Contains(s, "rft.atitle=Tags+%26+Formats") {
+		t.Errorf("title not encoded: %s", s)
+	}
+}
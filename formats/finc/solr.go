@@ -6,14 +6,13 @@ import (
 	"strings"
 
 	"github.com/segmentio/encoding/json"
-
-	"github.com/kennygrant/sanitize"
-	"github.com/miku/span/container"
 )
 
 // Solr5Vufind3 is the basic solr 5 schema as of 2016-04-14. It is based on
 // VuFind 3. Same as Solr5Vufind3v12, but with fullrecord field, refs. #8031.
-// TODO(martin): add support for classfinc.toml
+//
+// Deprecated: Use a SolrExporter with Solr5Vufind3Mapping, which yields the
+// same documents; this struct is kept as a reference for the mapping.
 type Solr5Vufind3 struct {
 	AuthorFacet          []string `json:"author_facet,omitempty"`
 	AuthorCorporate      []string `json:"author_corporate,omitempty"`
@@ -82,6 +81,9 @@ type Solr5Vufind3 struct {
 	FormatFinc   []string `json:"format_finc,omitempty"`
 	FormatNrw    []string `json:"format_nrw,omitempty"`
 	BranchNrw    string   `json:"branch_nrw,omitempty"` // refs #11605
+
+	// Provenance is the serialized provenance block, stored, but not indexed.
+	Provenance string `json:"provenance_str,omitempty"`
 }
 
 // Export fulfuls finc.Exporter interface, so we can plug this into cmd/span-export. Takes
@@ -108,18 +110,7 @@ func (s *Solr5Vufind3) convert(is IntermediateSchema, withFullrecord bool) error
 	s.ISBN = is.ISBNList()
 	s.ISBNStrMv = s.ISBN
 	s.Edition = is.Edition
-	for _, name := range is.MegaCollections {
-		// As per 2020-06-30 try to keep tcids (sid-...) in SOLR collection
-		// field, and labels in SOLR mega_collection. Except with crossref
-		// (49), where we do not have tcids (yet). As of 2021-04-20, we want
-		// [49] collection names in solr mega collections.
-		if strings.HasPrefix(name, "sid-") {
-			s.Collections = append(s.Collections, name)
-		} else {
-			// refs. #18495
-			s.MegaCollections = append(s.MegaCollections, name)
-		}
-	}
+	s.Collections, s.MegaCollections = solrCollections(&is)
 	s.PublishDateSort = fmt.Sprintf("%d", is.Date.Year())
 	s.PublishDate = []string{is.Date.Format("2006")} // refs #18608
 	s.Publishers = is.Publishers
@@ -148,51 +139,17 @@ func (s *Solr5Vufind3) convert(is IntermediateSchema, withFullrecord bool) error
 	s.TitleSort = is.SortableTitle()
 	s.Topics = is.Subjects
 
-	// refs. #12127
-	s.URL = is.URL
-
+	s.URL = solrURL(&is)
 	// refs. #8709
 	if is.DOI != "" {
 		s.DOI = []string{is.DOI}
-		containsDOI := false
-		for _, u := range s.URL {
-			if strings.Contains(u, "doi") {
-				containsDOI = true
-			}
-		}
-		if !containsDOI {
-			// refs. GH #9
-			s.URL = append(s.URL, fmt.Sprintf("https://doi.org/%s", is.DOI))
-		}
-	}
-
-	classes := container.NewStringSet()
-	for _, s := range is.Subjects {
-		for _, class := range SubjectMapping.Lookup(s, []string{}) {
-			classes.Add(class)
-		}
 	}
-	s.FincClassFacet = classes.Values()
 
-	var sanitized string
-	switch {
-	// refs #13024, book title shall not shadow article title (if both are
-	// given, keep the article title).
-	case is.BookTitle != "" && is.ArticleTitle == "":
-		sanitized = sanitize.HTML(is.BookTitle)
-	default:
-		sanitized = sanitize.HTML(is.ArticleTitle)
-	}
+	s.FincClassFacet = solrClasses(&is)
 
+	sanitized := solrTitle(&is)
 	s.Title, s.TitleFull, s.TitleShort = sanitized, sanitized, sanitized
 
-	// In intermediate schema we do not have a title yet but rft.btitle is
-	// non-empty, use that.
-	if s.Title == "" && is.BookTitle != "" {
-		sanitized := sanitize.HTML(is.BookTitle)
-		s.Title, s.TitleFull, s.TitleShort = sanitized, sanitized, sanitized
-	}
-
 	for _, lang := range is.Languages {
 		s.Languages = append(s.Languages, LanguageMap.Lookup(lang, lang))
 	}
@@ -204,25 +161,7 @@ func (s *Solr5Vufind3) convert(is IntermediateSchema, withFullrecord bool) error
 	// Informationen enthalten bzw. referenzieren sollte zukünftig ggf. auch in
 	// author_corporate_ref umbenannt werden"
 
-	// Collect sanitized authors.
-	var authors []string
-
-	// Refs. https://github.com/miku/span/issues/12.
-	var authorCorporate []string
-
-	for _, author := range is.Authors {
-		sanitized := AuthorReplacer.Replace(author.String())
-		if sanitized == "" {
-			// Refs. https://github.com/miku/span/issues/12.
-			if author.Corporate != "" {
-				authorCorporate = append(authorCorporate, author.Corporate)
-			}
-			continue
-		}
-		authors = append(authors, sanitized)
-		s.AuthorFacet = append(s.AuthorFacet, sanitized)
-	}
-
+	authors, authorCorporate := solrAuthors(&is)
 	if len(authorCorporate) > 0 {
 		s.AuthorCorporate = authorCorporate
 	}
@@ -230,6 +169,7 @@ func (s *Solr5Vufind3) convert(is IntermediateSchema, withFullrecord bool) error
 	// refs #7092, gh #8, refs #12310
 	if len(authors) > 0 {
 		s.Authors = authors
+		s.AuthorFacet = authors
 		s.AuthorSort = strings.ToLower(authors[0])
 	}
 
@@ -267,6 +207,14 @@ func (s *Solr5Vufind3) convert(is IntermediateSchema, withFullrecord bool) error
 		s.Fullrecord = string(b)
 	}
 
+	if is.Provenance != nil {
+		b, err := json.Marshal(is.Provenance)
+		if err != nil {
+			return err
+		}
+		s.Provenance = string(b)
+	}
+
 	// Default facet for online contents, refs #11285.
 	s.FacetAvail = []string{"Online"}
 	if is.OpenAccess {
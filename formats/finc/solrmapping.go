@@ -0,0 +1,444 @@
+package finc
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/kennygrant/sanitize"
+	"github.com/miku/span"
+	"github.com/miku/span/container"
+	"github.com/segmentio/encoding/json"
+)
+
+// Solr5Vufind3Mapping is the bundled mapping for the solr5vu3 export.
+var Solr5Vufind3Mapping = MustLoadSolrMapping("assets/solr/solr5vu3.json")
+
+// SolrMapping describes the layout of a SOLR document: field names, whether
+// a field is multi-valued and where its values come from. A new schema or a
+// new ISIL specific format facet should only require a new mapping file.
+//
+//	{
+//	  "name": "example",
+//	  "version": "2024-01-01",
+//	  "fields": [
+//	    {"name": "id", "fields": ["finc.id"]},
+//	    {"name": "title", "value": "title"},
+//	    {"name": "format_de15", "fields": ["finc.format"], "multi": true, "lookup": "assets/finc/formats/de15.json"},
+//	    {"name": "recordtype", "const": ["ai"]}
+//	  ]
+//	}
+//
+// Fields are written in the order of the mapping.
+type SolrMapping struct {
+	Name        string      `json:"name"`
+	Version     string      `json:"version"`
+	Description string      `json:"description,omitempty"`
+	Fields      []SolrField `json:"fields"`
+}
+
+// SolrField is a single field of a SOLR document. Values come from one of
+// intermediate schema fields, by JSON name, a derived value (see SolrValues)
+// or constants. A field without a source is always empty.
+type SolrField struct {
+	// Name of the SOLR field.
+	Name string `json:"name"`
+	// Fields are intermediate schema fields, e.g. "rft.issn", values are
+	// concatenated.
+	Fields []string `json:"fields,omitempty"`
+	// Value is the name of a derived value, e.g. "title".
+	Value string `json:"value,omitempty"`
+	// Const are fixed values.
+	Const []string `json:"const,omitempty"`
+	// Multi fields are written as arrays, others use the first value.
+	Multi bool `json:"multi,omitempty"`
+	// KeepEmpty writes empty fields, which are left out by default.
+	KeepEmpty bool `json:"keep_empty,omitempty"`
+	// SkipEmptyValues drops empty strings from the values.
+	SkipEmptyValues bool `json:"skip_empty_values,omitempty"`
+	// Lookup is a JSON object, in the assets or in the filesystem, that
+	// replaces each value, e.g. a format with a format facet of an ISIL.
+	// Values not found become empty, unless LookupFallback is set.
+	Lookup         string `json:"lookup,omitempty"`
+	LookupFallback bool   `json:"lookup_fallback,omitempty"`
+	// ExceptSources leaves the field empty for these source ids.
+	ExceptSources []string `json:"except_sources,omitempty"`
+
+	lookup container.MapDefault
+}
+
+// SolrValueFunc derives values for a SOLR field from a document.
+type SolrValueFunc func(is *IntermediateSchema, withFullrecord bool) ([]string, error)
+
+// SolrValues are the values derived from a document, that can be used in a
+// mapping, where a single intermediate schema field is not enough.
+var SolrValues = map[string]SolrValueFunc{
+	"abstract": func(is *IntermediateSchema, _ bool) ([]string, error) {
+		return []string{is.AbstractCleaned()}, nil
+	},
+	"allfields": func(is *IntermediateSchema, _ bool) ([]string, error) {
+		return []string{is.Allfields()}, nil
+	},
+	"author_corporate": func(is *IntermediateSchema, _ bool) ([]string, error) {
+		_, corporate := solrAuthors(is)
+		return corporate, nil
+	},
+	"author_sort": func(is *IntermediateSchema, _ bool) ([]string, error) {
+		if authors, _ := solrAuthors(is); len(authors) > 0 {
+			return []string{strings.ToLower(authors[0])}, nil
+		}
+		return nil, nil
+	},
+	"authors": func(is *IntermediateSchema, _ bool) ([]string, error) {
+		authors, _ := solrAuthors(is)
+		return authors, nil
+	},
+	"collections": func(is *IntermediateSchema, _ bool) ([]string, error) {
+		collections, _ := solrCollections(is)
+		return collections, nil
+	},
+	"facet_avail": func(is *IntermediateSchema, _ bool) ([]string, error) {
+		// Default facet for online contents, refs #11285.
+		if is.OpenAccess {
+			return []string{"Online", "Free"}, nil
+		}
+		return []string{"Online"}, nil
+	},
+	"finc_class": func(is *IntermediateSchema, _ bool) ([]string, error) {
+		return solrClasses(is), nil
+	},
+	"fullrecord": func(is *IntermediateSchema, withFullrecord bool) ([]string, error) {
+		if !withFullrecord {
+			return []string{"blob:" + is.ID}, nil
+		}
+		// refs. #8031
+		b, err := json.Marshal(is)
+		if err != nil {
+			return nil, err
+		}
+		return []string{string(b)}, nil
+	},
+	"imprint": func(is *IntermediateSchema, _ bool) ([]string, error) {
+		return []string{is.Imprint()}, nil
+	},
+	"isbn": func(is *IntermediateSchema, _ bool) ([]string, error) {
+		return is.ISBNList(), nil
+	},
+	"issn": func(is *IntermediateSchema, _ bool) ([]string, error) {
+		return is.ISSNList(), nil
+	},
+	"mega_collections": func(is *IntermediateSchema, _ bool) ([]string, error) {
+		_, megaCollections := solrCollections(is)
+		return megaCollections, nil
+	},
+	"provenance": func(is *IntermediateSchema, _ bool) ([]string, error) {
+		if is.Provenance == nil {
+			return nil, nil
+		}
+		b, err := json.Marshal(is.Provenance)
+		if err != nil {
+			return nil, err
+		}
+		return []string{string(b)}, nil
+	},
+	"publish_date": func(is *IntermediateSchema, _ bool) ([]string, error) {
+		return []string{is.Date.Format("2006")}, nil // refs #18608
+	},
+	"publish_date_sort": func(is *IntermediateSchema, _ bool) ([]string, error) {
+		return []string{fmt.Sprintf("%d", is.Date.Year())}, nil
+	},
+	"record_type": func(is *IntermediateSchema, withFullrecord bool) ([]string, error) {
+		// refs #22746, #21605
+		if withFullrecord {
+			return []string{IntermediateSchemaRecordType}, nil
+		}
+		return []string{AIRecordType}, nil
+	},
+	"sortable_title": func(is *IntermediateSchema, _ bool) ([]string, error) {
+		return []string{is.SortableTitle()}, nil
+	},
+	"subtitle": func(is *IntermediateSchema, _ bool) ([]string, error) {
+		// refs #21429, avoid duplications, e.g. crossref assembles a title
+		// from both title and subtitle already
+		if len(is.ArticleSubtitle) > 20 {
+			return []string{is.ArticleSubtitle}, nil
+		}
+		return nil, nil
+	},
+	"title": func(is *IntermediateSchema, _ bool) ([]string, error) {
+		return []string{solrTitle(is)}, nil
+	},
+	"url": func(is *IntermediateSchema, _ bool) ([]string, error) {
+		return solrURL(is), nil
+	},
+}
+
+// Validate checks field names, sources and loads lookup tables.
+func (m *SolrMapping) Validate() error {
+	if m.Name == "" {
+		return fmt.Errorf("solr mapping: name missing")
+	}
+	seen := make(map[string]bool)
+	for i := range m.Fields {
+		f := &m.Fields[i]
+		if f.Name == "" {
+			return fmt.Errorf("solr mapping %s: field %d without name", m.Name, i)
+		}
+		if seen[f.Name] {
+			return fmt.Errorf("solr mapping %s: duplicate field %s", m.Name, f.Name)
+		}
+		seen[f.Name] = true
+		var sources int
+		for _, ok := range []bool{len(f.Fields) > 0, f.Value != "", len(f.Const) > 0} {
+			if ok {
+				sources++
+			}
+		}
+		if sources > 1 {
+			return fmt.Errorf("solr mapping %s: %s: use only one of fields, value or const", m.Name, f.Name)
+		}
+		for _, name := range f.Fields {
+			if !IsField(name) {
+				return fmt.Errorf("solr mapping %s: %s: unknown intermediate schema field: %s", m.Name, f.Name, name)
+			}
+		}
+		if _, ok := SolrValues[f.Value]; f.Value != "" && !ok {
+			return fmt.Errorf("solr mapping %s: %s: unknown value: %s", m.Name, f.Name, f.Value)
+		}
+		if f.Lookup != "" {
+			lookup, err := loadLookup(f.Lookup)
+			if err != nil {
+				return fmt.Errorf("solr mapping %s: %s: %w", m.Name, f.Name, err)
+			}
+			f.lookup = lookup
+		}
+	}
+	return nil
+}
+
+// loadLookup reads a JSON object from the assets or, if not found there, from
+// the filesystem.
+func loadLookup(path string) (container.MapDefault, error) {
+	b, err := span.Static.ReadFile(path)
+	if err != nil {
+		if b, err = os.ReadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	m := make(container.MapDefault)
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("lookup %s: %w", path, err)
+	}
+	return m, nil
+}
+
+// ReadSolrMapping reads and validates a JSON mapping.
+func ReadSolrMapping(r io.Reader) (*SolrMapping, error) {
+	var m SolrMapping
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		return nil, err
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// MustLoadSolrMapping loads a mapping from the embedded assets and panics on
+// failure.
+func MustLoadSolrMapping(path string) *SolrMapping {
+	f, err := span.Static.Open(path)
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	m, err := ReadSolrMapping(f)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// values returns the values of a field for a document. Derived values are
+// cached, since mappings use them in more than one field.
+func (f *SolrField) values(is *IntermediateSchema, withFullrecord bool, cache map[string][]string) ([]string, error) {
+	for _, sid := range f.ExceptSources {
+		if sid == is.SourceID {
+			return nil, nil
+		}
+	}
+	var values []string
+	switch {
+	case len(f.Fields) > 0:
+		for _, name := range f.Fields {
+			vs, err := is.Field(name)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, vs...)
+		}
+	case f.Value != "":
+		vs, ok := cache[f.Value]
+		if !ok {
+			var err error
+			if vs, err = SolrValues[f.Value](is, withFullrecord); err != nil {
+				return nil, err
+			}
+			cache[f.Value] = vs
+		}
+		values = append(values, vs...)
+	default:
+		values = append(values, f.Const...)
+	}
+	if f.lookup != nil {
+		for i, v := range values {
+			if f.LookupFallback {
+				values[i] = f.lookup.Lookup(v, v)
+			} else {
+				values[i] = f.lookup.Lookup(v, "")
+			}
+		}
+	}
+	if f.SkipEmptyValues {
+		var nonempty []string
+		for _, v := range values {
+			if v != "" {
+				nonempty = append(nonempty, v)
+			}
+		}
+		values = nonempty
+	}
+	return values, nil
+}
+
+// SolrExporter writes SOLR documents as described by a mapping.
+type SolrExporter struct {
+	Mapping *SolrMapping
+}
+
+// NewSolrExporter creates an exporter for a mapping.
+func NewSolrExporter(m *SolrMapping) *SolrExporter {
+	return &SolrExporter{Mapping: m}
+}
+
+// Export serializes a document as a JSON object with the fields of the
+// mapping, in order.
+func (s *SolrExporter) Export(is IntermediateSchema, withFullrecord bool) ([]byte, error) {
+	var (
+		buf   bytes.Buffer
+		cache = make(map[string][]string)
+		n     int
+	)
+	buf.WriteByte('{')
+	for i := range s.Mapping.Fields {
+		f := &s.Mapping.Fields[i]
+		values, err := f.values(&is, withFullrecord, cache)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		var v interface{}
+		switch {
+		case f.Multi && len(values) == 0 && !f.KeepEmpty:
+			continue
+		case f.Multi && len(values) == 0:
+			v = []string{}
+		case f.Multi:
+			v = values
+		case len(values) == 0 || values[0] == "":
+			if !f.KeepEmpty {
+				continue
+			}
+			v = ""
+		default:
+			v = values[0]
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		if n > 0 {
+			buf.WriteByte(',')
+		}
+		n++
+		name, err := json.Marshal(f.Name)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(b)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// solrAuthors returns cleaned author names and corporate authors, which are
+// only used, if there is no name, refs. https://github.com/miku/span/issues/12.
+func solrAuthors(is *IntermediateSchema) (authors, corporate []string) {
+	for _, author := range is.Authors {
+		sanitized := AuthorReplacer.Replace(author.String())
+		if sanitized == "" {
+			if author.Corporate != "" {
+				corporate = append(corporate, author.Corporate)
+			}
+			continue
+		}
+		authors = append(authors, sanitized)
+	}
+	return authors, corporate
+}
+
+// solrCollections splits collections into technical collection ids (sid-...)
+// and labels, refs. #18495.
+//
+// As per 2020-06-30 try to keep tcids (sid-...) in SOLR collection field, and
+// labels in SOLR mega_collection. Except with crossref (49), where we do not
+// have tcids (yet). As of 2021-04-20, we want [49] collection names in solr
+// mega collections.
+func solrCollections(is *IntermediateSchema) (collections, megaCollections []string) {
+	for _, name := range is.MegaCollections {
+		if strings.HasPrefix(name, "sid-") {
+			collections = append(collections, name)
+		} else {
+			megaCollections = append(megaCollections, name)
+		}
+	}
+	return collections, megaCollections
+}
+
+// solrClasses returns finc classes for the subjects of a document.
+func solrClasses(is *IntermediateSchema) []string {
+	classes := container.NewStringSet()
+	for _, s := range is.Subjects {
+		for _, class := range SubjectMapping.Lookup(s, []string{}) {
+			classes.Add(class)
+		}
+	}
+	return classes.SortedValues()
+}
+
+// solrTitle returns the sanitized title, refs #13024, book title shall not
+// shadow article title (if both are given, keep the article title).
+func solrTitle(is *IntermediateSchema) string {
+	if title := sanitize.HTML(is.ArticleTitle); title != "" {
+		return title
+	}
+	return sanitize.HTML(is.BookTitle)
+}
+
+// solrURL returns the links of a document and a DOI link, if there is a DOI,
+// but no link contains it, refs. #12127, #8709, GH #9.
+func solrURL(is *IntermediateSchema) []string {
+	urls := append([]string(nil), is.URL...)
+	if is.DOI == "" {
+		return urls
+	}
+	for _, u := range urls {
+		if strings.Contains(u, "doi") {
+			return urls
+		}
+	}
+	return append(urls, fmt.Sprintf("https://doi.org/%s", is.DOI))
+}
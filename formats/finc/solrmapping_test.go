@@ -0,0 +1,106 @@
+package finc
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/segmentio/encoding/json"
+)
+
+// TestSolr5Vufind3Mapping checks, that the bundled mapping yields the same
+// documents as the Solr5Vufind3 struct, for all golden files and a few edge
+// cases.
+func TestSolr5Vufind3Mapping(t *testing.T) {
+	files, err := filepath.Glob("../../fixtures/*/*.is.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no golden files found")
+	}
+	docs := []IntermediateSchema{
+		{ID: "ai-48-x", SourceID: "48", ISSN: []string{"1234-5678"}, Languages: []string{"eng"}, Publishers: []string{"P"}, Fulltext: "F"},
+		{ID: "ai-1-x", OpenAccess: true, DOI: "10.1/x", URL: []string{"http://example.org"}, ArticleSubtitle: "A subtitle, longer than twenty bytes"},
+		{ID: "ai-1-y", BookTitle: "<b>Book</b>", Authors: []Author{{Name: "Anonymous", Corporate: "ACME"}, {LastName: "Doe", FirstName: "Jane"}}},
+		{ID: "ai-1-z", MegaCollections: []string{"sid-1-col-x", "Some Collection"}, Provenance: &Provenance{Filename: "a.xml", Converted: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}},
+	}
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var vs []IntermediateSchema
+		if err := json.Unmarshal(b, &vs); err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		docs = append(docs, vs...)
+	}
+	exporter := NewSolrExporter(Solr5Vufind3Mapping)
+	for _, is := range docs {
+		for _, withFullrecord := range []bool{false, true} {
+			want, err := new(Solr5Vufind3).Export(is, withFullrecord)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := exporter.Export(is, withFullrecord)
+			if err != nil {
+				t.Fatalf("%s: %v", is.ID, err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("%s: got %s, want %s", is.ID, got, want)
+			}
+		}
+	}
+}
+
+func TestReadSolrMapping(t *testing.T) {
+	var cases = []struct {
+		about string
+		s     string
+		err   string
+	}{
+		{"ok", `{"name": "x", "fields": [{"name": "id", "fields": ["finc.id"]}, {"name": "rt", "const": ["ai"]}]}`, ""},
+		{"no name", `{"fields": []}`, "name missing"},
+		{"unknown field", `{"name": "x", "fields": [{"name": "id", "fields": ["finc.idx"]}]}`, "unknown intermediate schema field"},
+		{"unknown value", `{"name": "x", "fields": [{"name": "id", "value": "idx"}]}`, "unknown value"},
+		{"duplicate", `{"name": "x", "fields": [{"name": "id"}, {"name": "id"}]}`, "duplicate field"},
+		{"two sources", `{"name": "x", "fields": [{"name": "id", "value": "title", "const": ["x"]}]}`, "only one of"},
+		{"missing lookup", `{"name": "x", "fields": [{"name": "f", "fields": ["finc.format"], "lookup": "assets/finc/formats/xx.json"}]}`, "no such file"},
+	}
+	for _, c := range cases {
+		_, err := ReadSolrMapping(strings.NewReader(c.s))
+		switch {
+		case c.err == "" && err != nil:
+			t.Errorf("%s: got %v, want nil", c.about, err)
+		case c.err != "" && (err == nil || !strings.Contains(err.Error(), c.err)):
+			t.Errorf("%s: got %v, want %s", c.about, err, c.err)
+		}
+	}
+}
+
+func TestSolrExporter(t *testing.T) {
+	m, err := ReadSolrMapping(strings.NewReader(`{"name": "x", "fields": [
+		{"name": "id", "fields": ["finc.id"]},
+		{"name": "recordtype", "const": ["ai"]},
+		{"name": "format_de15", "fields": ["finc.format"], "multi": true, "lookup": "assets/finc/formats/de15.json"},
+		{"name": "issn", "fields": ["rft.issn", "rft.eissn"], "multi": true},
+		{"name": "empty", "multi": true, "keep_empty": true},
+		{"name": "missing", "fields": ["rft.volume"]}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	is := IntermediateSchema{ID: "ai-1-x", Format: "ElectronicArticle", ISSN: []string{"1"}, EISSN: []string{"2"}}
+	got, err := NewSolrExporter(m).Export(is, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"id":"ai-1-x","recordtype":"ai","format_de15":["Article, E-Article"],"issn":["1","2"],"empty":[]}`
+	if string(got) != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
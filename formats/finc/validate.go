@@ -0,0 +1,478 @@
+package finc
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miku/span"
+	"github.com/miku/span/schema"
+	"github.com/segmentio/encoding/json"
+	"golang.org/x/text/language"
+)
+
+// Rules a document can violate.
+const (
+	RuleJSON     = "json"     // not a JSON object
+	RuleVersion  = "version"  // no schema for this version
+	RuleSchema   = "schema"   // violates the JSON schema
+	RuleDate     = "date"     // rft.date not YYYY-MM-DD
+	RuleISSN     = "issn"     // wrong ISSN check digit
+	RuleLanguage = "language" // not an ISO 639-3 code
+	RuleID       = "id"       // finc.id not derived from source and record id
+)
+
+// Violation is a single problem found in a document.
+type Violation struct {
+	Rule    string `json:"rule"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// String formats the violation for humans.
+func (v Violation) String() string {
+	if v.Field == "" {
+		return fmt.Sprintf("%s: %s", v.Rule, v.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", v.Rule, v.Field, v.Message)
+}
+
+// Validator checks documents against the embedded JSON schema of their
+// version, see schema/is-*.json, and semantic rules a JSON schema cannot
+// express. Safe for concurrent use.
+type Validator struct {
+	schemas map[string]*jsonSchema
+}
+
+var (
+	defaultValidator     *Validator
+	defaultValidatorErr  error
+	defaultValidatorOnce sync.Once
+)
+
+// NewValidator returns a validator for all embedded schemas, which are only
+// loaded once.
+func NewValidator() (*Validator, error) {
+	defaultValidatorOnce.Do(func() {
+		defaultValidator, defaultValidatorErr = loadValidator(schema.FS)
+	})
+	return defaultValidator, defaultValidatorErr
+}
+
+// loadValidator reads is-<version>.json files.
+func loadValidator(fsys fs.FS) (*Validator, error) {
+	names, err := fs.Glob(fsys, "is-*.json")
+	if err != nil {
+		return nil, err
+	}
+	v := &Validator{schemas: make(map[string]*jsonSchema)}
+	for _, name := range names {
+		b, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		var s jsonSchema
+		if err := json.Unmarshal(b, &s); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if err := s.compile(); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		version := strings.TrimSuffix(strings.TrimPrefix(path.Base(name), "is-"), ".json")
+		v.schemas[version] = &s
+	}
+	return v, nil
+}
+
+// Validate checks a single serialized document and returns all violations,
+// an empty list for a valid document. Documents in version 0.9, which
+// converters still write, are migrated to 1.0 first, since the released 0.9
+// schema does not list all fields converters set, like finc.id.
+func (v *Validator) Validate(p []byte) (violations []Violation) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(p, &doc); err != nil {
+		return []Violation{{Rule: RuleJSON, Message: strings.TrimSpace(err.Error())}}
+	}
+	var is IntermediateSchema
+	if err := json.Unmarshal(p, &is); err != nil {
+		return []Violation{{Rule: RuleJSON, Message: strings.TrimSpace(err.Error())}}
+	}
+	if is.Version == Version09 {
+		if err := Migrate(&is, Version10); err != nil {
+			return []Violation{{Rule: RuleVersion, Field: "version", Message: err.Error()}}
+		}
+		migrate09(doc, &is)
+	}
+	version, _ := doc["version"].(string)
+	if s, ok := v.schemas[version]; ok {
+		s.validate("", doc, &violations)
+	} else {
+		violations = append(violations, Violation{
+			Rule:    RuleVersion,
+			Field:   "version",
+			Message: fmt.Sprintf("no schema for version %q", version),
+		})
+	}
+	return append(violations, CheckSemantics(&is)...)
+}
+
+// migrate09 applies the migration of a document to its decoded form, which
+// keeps fields unknown to the schema for validation.
+func migrate09(doc map[string]interface{}, is *IntermediateSchema) {
+	delete(doc, "x.date")
+	if is.RawDate != "" {
+		doc["rft.date"] = is.RawDate
+	}
+	doc["version"] = is.Version
+}
+
+// CheckSemantics checks rules, that a JSON schema cannot express: dates must
+// be ISO 8601, ISSN must have a valid check digit, languages must be ISO
+// 639-3 codes and the finc.id must be derived from source and record id, or
+// from the key registered for the format, see RegisterIDKey.
+func CheckSemantics(is *IntermediateSchema) (violations []Violation) {
+	if is.RawDate != "" {
+		if _, err := time.Parse(isoDate, is.RawDate); err != nil {
+			violations = append(violations, Violation{
+				Rule:    RuleDate,
+				Field:   "rft.date",
+				Message: fmt.Sprintf("%q is not YYYY-MM-DD", is.RawDate),
+			})
+		}
+	}
+	for field, issns := range map[string][]string{"rft.issn": is.ISSN, "rft.eissn": is.EISSN} {
+		for i, issn := range issns {
+			if !ValidISSN(issn) {
+				violations = append(violations, Violation{
+					Rule:    RuleISSN,
+					Field:   fmt.Sprintf("%s[%d]", field, i),
+					Message: fmt.Sprintf("%q is not a valid ISSN", issn),
+				})
+			}
+		}
+	}
+	for i, lang := range is.Languages {
+		if isISO6393(lang) {
+			continue
+		}
+		msg := fmt.Sprintf("%q is not an ISO 639-3 code", lang)
+		if code := span.LanguageIdentifier(lang); code != "" {
+			msg = fmt.Sprintf("%s, use %q", msg, code)
+		}
+		violations = append(violations, Violation{
+			Rule:    RuleLanguage,
+			Field:   fmt.Sprintf("languages[%d]", i),
+			Message: msg,
+		})
+	}
+	switch {
+	case is.ID == "":
+		violations = append(violations, Violation{
+			Rule:    RuleID,
+			Field:   "finc.id",
+			Message: "missing",
+		})
+	case !matchesFincID(is):
+		violations = append(violations, Violation{
+			Rule:    RuleID,
+			Field:   "finc.id",
+			Message: fmt.Sprintf("%q does not match source %q and record id %q", is.ID, is.SourceID, is.RecordID),
+		})
+	}
+	sort.SliceStable(violations, func(i, j int) bool {
+		return violations[i].Field < violations[j].Field
+	})
+	return violations
+}
+
+// ValidISSN checks format and check digit of an ISSN, like 0317-8471.
+func ValidISSN(s string) bool {
+	s = strings.Replace(s, "-", "", 1)
+	if len(s) != 8 {
+		return false
+	}
+	var sum int
+	for i := 0; i < 7; i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+		sum += int(s[i]-'0') * (8 - i)
+	}
+	check := (11 - sum%11) % 11
+	switch {
+	case check == 10:
+		return s[7] == 'X'
+	default:
+		return int(s[7]-'0') == check
+	}
+}
+
+var (
+	iso6393     map[string]bool
+	iso6393Once sync.Once
+)
+
+// isISO6393 returns true, if s is a known ISO 639-3 code, either from the
+// language tables or the CLDR.
+func isISO6393(s string) bool {
+	iso6393Once.Do(func() {
+		iso6393 = make(map[string]bool)
+		for _, m := range []map[string]string{span.ISO639NameToThree, span.ISO639OneToThree, span.ISO639BibliographicToThree} {
+			for _, v := range m {
+				iso6393[v] = true
+			}
+		}
+	})
+	if iso6393[s] {
+		return true
+	}
+	base, err := language.ParseBase(s)
+	return err == nil && base.ISO3() == s
+}
+
+// IDKeyFunc returns the values a format may derive the finc.id from, if that
+// is not the record id, e.g. a link.
+type IDKeyFunc func(is *IntermediateSchema) []string
+
+var (
+	idKeysMu sync.RWMutex
+	idKeys   = make(map[string]IDKeyFunc)
+)
+
+// RegisterIDKey registers the id key function of a format, which is used to
+// check the finc.id of documents of this format.
+func RegisterIDKey(format string, f IDKeyFunc) {
+	idKeysMu.Lock()
+	defer idKeysMu.Unlock()
+	idKeys[format] = f
+}
+
+// matchesFincID checks, whether the finc.id is GenFincID(sid, key), where key
+// is the record id or the value the format of the document derives the id
+// from. Without provenance, the keys of all formats are tried.
+func matchesFincID(is *IntermediateSchema) bool {
+	if is.SourceID == "" {
+		return false
+	}
+	keys := []string{is.RecordID}
+	idKeysMu.RLock()
+	if is.Provenance != nil && is.Provenance.Format != "" {
+		if f, ok := idKeys[is.Provenance.Format]; ok {
+			keys = append(keys, f(is)...)
+		}
+	} else {
+		for _, f := range idKeys {
+			keys = append(keys, f(is)...)
+		}
+	}
+	idKeysMu.RUnlock()
+	for _, key := range keys {
+		if key != "" && is.ID == span.GenFincID(is.SourceID, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// Report aggregates violations over many documents. Not safe for concurrent
+// use.
+type Report struct {
+	Total      int            `json:"total"`
+	Invalid    int            `json:"invalid"`
+	Violations map[string]int `json:"violations"`
+}
+
+// Add counts the violations of a single document, by rule and field.
+func (r *Report) Add(violations []Violation) {
+	r.Total++
+	if len(violations) == 0 {
+		return
+	}
+	r.Invalid++
+	if r.Violations == nil {
+		r.Violations = make(map[string]int)
+	}
+	for _, v := range violations {
+		key := v.Rule
+		if v.Field != "" {
+			// Group array elements, e.g. languages[0] and languages[1].
+			key += " " + indexPattern.ReplaceAllString(v.Field, "[]")
+		}
+		r.Violations[key]++
+	}
+}
+
+// indexPattern finds array indices in field names.
+var indexPattern = regexp.MustCompile(`\[[0-9]+\]`)
+
+// jsonSchema is the subset of JSON schema (draft 4) used by the intermediate
+// schema files.
+type jsonSchema struct {
+	Type                 string                 `json:"type"`
+	Required             []string               `json:"required"`
+	AnyOf                []*jsonSchema          `json:"anyOf"`
+	AdditionalProperties *bool                  `json:"additionalProperties"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	Items                *jsonSchema            `json:"items"`
+	UniqueItems          bool                   `json:"uniqueItems"`
+	Enum                 []interface{}          `json:"enum"`
+	Pattern              string                 `json:"pattern"`
+	Format               string                 `json:"format"`
+
+	enum    map[interface{}]bool
+	pattern *regexp.Regexp
+}
+
+// compile prepares patterns and enums.
+func (s *jsonSchema) compile() error {
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return err
+		}
+		s.pattern = re
+	}
+	if len(s.Enum) > 0 {
+		s.enum = make(map[interface{}]bool)
+		for _, v := range s.Enum {
+			s.enum[v] = true
+		}
+	}
+	for _, t := range s.Properties {
+		if err := t.compile(); err != nil {
+			return err
+		}
+	}
+	for _, t := range s.AnyOf {
+		if err := t.compile(); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		return s.Items.compile()
+	}
+	return nil
+}
+
+// validate appends violations of a decoded JSON value at a given field.
+func (s *jsonSchema) validate(field string, v interface{}, violations *[]Violation) {
+	add := func(format string, args ...interface{}) {
+		*violations = append(*violations, Violation{
+			Rule:    RuleSchema,
+			Field:   field,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+	if t := jsonType(v); s.Type != "" && t != s.Type && !(s.Type == "number" && t == "integer") {
+		add("got %s, want %s", t, s.Type)
+		return
+	}
+	if s.enum != nil && !s.enum[v] {
+		b, _ := json.Marshal(v)
+		add("value %s not allowed", b)
+	}
+	switch w := v.(type) {
+	case map[string]interface{}:
+		for _, k := range s.Required {
+			if _, ok := w[k]; !ok {
+				*violations = append(*violations, Violation{
+					Rule:    RuleSchema,
+					Field:   joinField(field, k),
+					Message: "missing required field",
+				})
+			}
+		}
+		if len(s.AnyOf) > 0 {
+			var first []Violation
+			for i, t := range s.AnyOf {
+				var vs []Violation
+				t.validate(field, v, &vs)
+				if len(vs) == 0 {
+					first = nil
+					break
+				}
+				if i == 0 {
+					first = vs
+				}
+			}
+			*violations = append(*violations, first...)
+		}
+		keys := make([]string, 0, len(w))
+		for k := range w {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if t, ok := s.Properties[k]; ok {
+				t.validate(joinField(field, k), w[k], violations)
+			} else if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				add("unknown field %s", k)
+			}
+		}
+	case []interface{}:
+		for i, item := range w {
+			if s.UniqueItems {
+				for _, prev := range w[:i] {
+					if reflect.DeepEqual(prev, item) {
+						add("duplicate item %v", item)
+						break
+					}
+				}
+			}
+			if s.Items != nil {
+				s.Items.validate(fmt.Sprintf("%s[%d]", field, i), item, violations)
+			}
+		}
+	case string:
+		if s.pattern != nil && !s.pattern.MatchString(w) {
+			add("%q does not match %s", w, s.Pattern)
+		}
+		switch s.Format {
+		case "date":
+			if _, err := time.Parse(isoDate, w); err != nil {
+				add("%q is not a date", w)
+			}
+		case "date-time":
+			if _, err := time.Parse(time.RFC3339, w); err != nil {
+				add("%q is not a date-time", w)
+			}
+		}
+	}
+}
+
+// joinField returns the name of a nested field.
+func joinField(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+// jsonType returns the JSON schema type name of a decoded value.
+func jsonType(v interface{}) string {
+	switch w := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if w == float64(int64(w)) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
@@ -0,0 +1,232 @@
+package finc
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miku/span"
+	"github.com/segmentio/encoding/json"
+)
+
+func TestValidISSN(t *testing.T) {
+	var cases = []struct {
+		issn string
+		want bool
+	}{
+		{"0317-8471", true},
+		{"2167-8359", true},
+		{"1050-124X", true},
+		{"0317-8472", false},
+		{"0317847", false},
+		{"A317-8471", false},
+	}
+	for _, c := range cases {
+		if got := ValidISSN(c.issn); got != c.want {
+			t.Errorf("%s: got %v, want %v", c.issn, got, c.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	v, err := NewValidator()
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	RegisterIDKey("test", func(is *IntermediateSchema) []string {
+		return []string{"https://doi.org/" + is.RecordID}
+	})
+	is := NewIntermediateSchema()
+	is.Version = Version10
+	is.SourceID = "1"
+	is.RecordID = "10.1/x"
+	is.ID = span.GenFincID("1", "https://doi.org/10.1/x")
+	is.MegaCollections = []string{"Example"}
+	is.ArticleTitle = "Hello"
+	is.Genre = "article"
+	is.RefType = "EJOUR"
+	is.Languages = []string{"eng"}
+	is.ISSN = []string{"2167-8359"}
+	is.RawDate = "2001-01-01"
+	b, err := Marshal(is)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if vs := v.Validate(b); len(vs) > 0 {
+		t.Fatalf("got %v, want no violations", vs)
+	}
+	tombstone := NewTombstone(span.GenFincID("1", "2"), "1", "2")
+	tombstone.Version = Version10
+	if b, err = Marshal(tombstone); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if vs := v.Validate(b); len(vs) > 0 {
+		t.Fatalf("got %v, want no violations", vs)
+	}
+
+	is.ID = span.GenFincID("2", "https://doi.org/10.1/x")
+	is.ISSN = []string{"2167-8358"}
+	is.Languages = []string{"en"}
+	is.Genre = "news"
+	if b, err = Marshal(is); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	var got []string
+	for _, violation := range v.Validate(b) {
+		got = append(got, violation.Rule+" "+violation.Field)
+	}
+	want := []string{
+		"schema languages[0]",
+		"schema rft.genre",
+		"id finc.id",
+		"language languages[0]",
+		"issn rft.issn[0]",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// Documents in 0.9, as converters write them, are checked as 1.0, since
+	// the released 0.9 schema does not list fields like finc.id or x.date.
+	is = NewIntermediateSchema()
+	is.Version = Version09
+	is.SourceID = "1"
+	is.RecordID = "10.1/x"
+	is.ID = span.GenFincID("1", "10.1/x")
+	is.MegaCollections = []string{"Example"}
+	is.ArticleTitle = "Hello"
+	is.Genre = "article"
+	is.RefType = "EJOUR"
+	is.Languages = []string{"eng"}
+	is.Date = time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+	is.RawDate = "2001-01-01"
+	if b, err = json.Marshal(is); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if vs := v.Validate(b); len(vs) > 0 {
+		t.Errorf("got %v, want no violations for version %s", vs, Version09)
+	}
+	// Fields unknown to the schema are still reported.
+	b = append(b[:len(b)-1], []byte(`,"x.unknown":1}`)...)
+	if vs := v.Validate(b); len(vs) != 1 || !strings.Contains(vs[0].Message, "x.unknown") {
+		t.Errorf("got %v, want a single violation for x.unknown", vs)
+	}
+}
+
+func TestMatchesFincID(t *testing.T) {
+	RegisterIDKey("test", func(is *IntermediateSchema) []string {
+		return []string{"https://doi.org/" + is.RecordID}
+	})
+	RegisterIDKey("test-url", func(is *IntermediateSchema) []string {
+		return is.URL
+	})
+	var cases = []struct {
+		about string
+		is    IntermediateSchema
+		want  bool
+	}{
+		{"record id", IntermediateSchema{ID: span.GenFincID("1", "x"), SourceID: "1", RecordID: "x"}, true},
+		{"other source", IntermediateSchema{ID: span.GenFincID("2", "x"), SourceID: "1", RecordID: "x"}, false},
+		{"prefix of record id", IntermediateSchema{ID: span.GenFincID("1", "x"), SourceID: "1", RecordID: "xy"}, false},
+		{"contains record id", IntermediateSchema{ID: span.GenFincID("1", "ax"), SourceID: "1", RecordID: "x"}, false},
+		{"registered key", IntermediateSchema{ID: span.GenFincID("1", "https://doi.org/10.1/x"), SourceID: "1", RecordID: "10.1/x"}, true},
+		{"registered key, other record", IntermediateSchema{ID: span.GenFincID("1", "https://doi.org/10.1/xy"), SourceID: "1", RecordID: "10.1/x"}, false},
+		{
+			"key of other format",
+			IntermediateSchema{
+				ID:         span.GenFincID("1", "https://doi.org/10.1/x"),
+				SourceID:   "1",
+				RecordID:   "10.1/x",
+				Provenance: &Provenance{Format: "ris"},
+			},
+			false,
+		},
+		{
+			"key of format",
+			IntermediateSchema{
+				ID:         span.GenFincID("1", "https://doi.org/10.1/x"),
+				SourceID:   "1",
+				RecordID:   "10.1/x",
+				Provenance: &Provenance{Format: "test"},
+			},
+			true,
+		},
+		{"no record id", IntermediateSchema{ID: span.GenFincID("1", ""), SourceID: "1"}, false},
+		{"no record id, registered key", IntermediateSchema{ID: span.GenFincID("1", "http://x.org/1"), SourceID: "1", URL: []string{"http://x.org/1"}}, true},
+	}
+	for _, c := range cases {
+		if got := matchesFincID(&c.is); got != c.want {
+			t.Errorf("%s: got %v, want %v", c.about, got, c.want)
+		}
+	}
+}
+
+func TestValidateFixtures(t *testing.T) {
+	v, err := NewValidator()
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	for _, name := range []string{"crossref.is", "jats.is"} {
+		b, err := os.ReadFile("../../schema/fixtures/1.0/" + name)
+		if err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+		// The examples predate finc.id.
+		var got []string
+		for _, violation := range v.Validate(b) {
+			got = append(got, violation.Rule+" "+violation.Field)
+		}
+		if want := []string{"id finc.id"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestProvenance(t *testing.T) {
+	v, err := NewValidator()
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	is := NewTombstone(span.GenFincID("1", "2"), "1", "2")
+	is.Version = Version10
+	is.Provenance = &Provenance{
+		Filename:  "a.ris",
+		Line:      7,
+		Format:    "ris",
+		Version:   span.AppVersion,
+		Converted: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	b, err := Marshal(is)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if vs := v.Validate(b); len(vs) > 0 {
+		t.Fatalf("got %v, want no violations", vs)
+	}
+	var got IntermediateSchema
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if !reflect.DeepEqual(got.Provenance, is.Provenance) {
+		t.Errorf("got %+v, want %+v", got.Provenance, is.Provenance)
+	}
+	if b, err = new(Solr5Vufind3).Export(*is, false); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	want := `"provenance_str":"{\"filename\":\"a.ris\",\"line\":7,`
+	if !strings.Contains(string(b), want) {
+		t.Errorf("got %s, want %s", b, want)
+	}
+}
+
+func TestReport(t *testing.T) {
+	var r Report
+	r.Add(nil)
+	r.Add([]Violation{{Rule: RuleLanguage, Field: "languages[0]"}, {Rule: RuleLanguage, Field: "languages[1]"}})
+	want := Report{Total: 2, Invalid: 1, Violations: map[string]int{"language languages[]": 2}}
+	if !reflect.DeepEqual(r, want) {
+		t.Errorf("got %+v, want %+v", r, want)
+	}
+}
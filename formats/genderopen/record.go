@@ -99,6 +99,9 @@ func (record Record) ToIntermediateSchema() (*finc.IntermediateSchema, error) {
 	encodedRecordID := base64.RawURLEncoding.EncodeToString([]byte(record.Header.Identifier))
 	output.RecordID = encodedRecordID
 	output.ID = fmt.Sprintf("ai-%s-%s", output.SourceID, output.RecordID)
+	if record.Header.Status == "deleted" {
+		return finc.NewTombstone(output.ID, output.SourceID, output.RecordID), nil
+	}
 	output.MegaCollections = append(output.MegaCollections, "Gender Open")
 	output.Genre = "article"
 	output.RefType = "EJOUR"
@@ -133,10 +136,19 @@ func (record Record) ToIntermediateSchema() (*finc.IntermediateSchema, error) {
 	}
 
 	if record.Metadata.Dc.Date == "" {
-		return output, span.Skip{Reason: "empty date"}
+		return output, span.Skip{
+			Code:     span.SkipMissingDate,
+			RecordID: record.Header.Identifier,
+			Field:    "date",
+		}
 	}
 	if len(record.Metadata.Dc.Date) < 4 {
-		return output, span.Skip{Reason: "short date"}
+		return output, span.Skip{
+			Code:     span.SkipInvalidDate,
+			RecordID: record.Header.Identifier,
+			Field:    "date",
+			Value:    record.Metadata.Dc.Date,
+		}
 	}
 	if record.Metadata.Dc.Date != "" {
 		s := record.Metadata.Dc.Date[:4] // XXX: Check.
@@ -0,0 +1,16 @@
+package genderopen
+
+import "github.com/miku/span/formats"
+
+func init() {
+	formats.Register(formats.Format{
+		Name:    "genderopen",
+		Framing: formats.FramingXML,
+		New:     func() interface{} { return new(Record) },
+		Signature: &formats.Signature{
+			Elements:   []string{"record"},
+			Namespaces: []string{formats.NamespaceOAIDC},
+			Contains:   []string{"oai:www.genderopen.de"},
+		},
+	})
+}
@@ -216,7 +216,7 @@ func (doc Document) ISSNList() []string {
 	for _, s := range strutil.ISSNPattern.FindAllString(doc.ISSN, -1) {
 		issns.Add(s)
 	}
-	return issns.Values()
+	return issns.SortedValues()
 }
 
 // Languages returns the given and guessed languages found in abstract and
@@ -237,7 +237,7 @@ func (doc Document) Languages() []string {
 		}
 		set.Add(lang)
 	}
-	return set.Values()
+	return set.SortedValues()
 }
 
 // ToIntermediateSchema converts a genios document into an intermediate schema document.
@@ -246,7 +246,13 @@ func (doc Document) ToIntermediateSchema() (output *finc.IntermediateSchema, err
 	output = finc.NewIntermediateSchema()
 	output.Date, err = doc.Date()
 	if err != nil {
-		return output, span.Skip{Reason: err.Error()}
+		return output, span.Skip{
+			Code:     span.SkipInvalidDate,
+			RecordID: doc.ID,
+			Field:    "date",
+			Value:    doc.Year,
+			Reason:   err.Error(),
+		}
 	}
 	output.RawDate = output.Date.Format("2006-01-02")
 	output.Authors = doc.Authors()
@@ -257,7 +263,11 @@ func (doc Document) ToIntermediateSchema() (output *finc.IntermediateSchema, err
 	}
 	output.ArticleTitle = strings.TrimSpace(doc.Title)
 	if len(output.ArticleTitle) > maxTitleLength {
-		return output, span.Skip{Reason: fmt.Sprintf("article title too long: %d", len(output.ArticleTitle))}
+		return output, span.Skip{
+			Code:     span.SkipTitleTooLong,
+			RecordID: doc.ID,
+			Reason:   fmt.Sprintf("%d characters", len(output.ArticleTitle)),
+		}
 	}
 	// TODO(miku): Find DB names where this is relevant.
 	output.JournalTitle = strings.Replace(strings.TrimSpace(doc.PublicationTitle), "\n", " ", -1)
@@ -308,7 +318,7 @@ func (doc Document) ToIntermediateSchema() (output *finc.IntermediateSchema, err
 	// UgwrdEYW5mb3NzLVN5c3RlbXBhcnRuZXIgwrdEYW5mb3NzIERyaX\
 	// ZlcyBDZW50ZXIgwrdNYXJ0aW4gU2ljaGVyaGVpdHN0ZWNobmlr
 	if len(id) > span.KeyLengthLimit {
-		return output, span.Skip{Reason: fmt.Sprintf("id too long: %s", id)}
+		return output, span.Skip{Code: span.SkipIDTooLong, RecordID: id}
 	}
 	output.ID = id
 	output.RecordID = doc.ID
@@ -0,0 +1,14 @@
+package genios
+
+import "github.com/miku/span/formats"
+
+func init() {
+	formats.Register(formats.Format{
+		Name:    "genios",
+		Framing: formats.FramingXML,
+		New:     func() interface{} { return new(Document) },
+		Signature: &formats.Signature{
+			Elements: []string{"GENIOS", "Document"},
+		},
+	})
+}
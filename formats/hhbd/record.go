@@ -21,13 +21,15 @@ var (
 	RefTypes    = assetutil.MustLoadStringMap("assets/hhbd/reftypes.json")
 )
 
+// uniqueStrings drops duplicates, keeping the first occurrence.
 func uniqueStrings(s []string) (result []string) {
 	m := make(map[string]bool)
 	for _, v := range s {
+		if m[v] {
+			continue
+		}
 		m[v] = true
-	}
-	for k := range m {
-		result = append(result, k)
+		result = append(result, v)
 	}
 	return
 }
@@ -126,6 +128,9 @@ func (record Record) ToIntermediateSchema() (*finc.IntermediateSchema, error) {
 	output.RecordID = record.Header.Identifier.Text
 	output.SourceID = "107"
 	output.ID = fmt.Sprintf("ai-%s-%s", output.SourceID, base64.RawURLEncoding.EncodeToString([]byte(output.RecordID)))
+	if record.Header.Status == "deleted" {
+		return finc.NewTombstone(output.ID, output.SourceID, output.RecordID), nil
+	}
 	output.ArticleTitle = record.Metadata.Dc.Title.Text
 	output.MegaCollections = []string{"sid-107-col-heidelberg"}
 
@@ -140,12 +145,22 @@ func (record Record) ToIntermediateSchema() (*finc.IntermediateSchema, error) {
 	// Date.
 	date, err := record.date()
 	if err != nil {
-		return nil, span.Skip{Reason: fmt.Sprintf("Cannot parse date: %s", record.Metadata.Dc.Date.Text)}
+		return nil, span.Skip{
+			Code:     span.SkipInvalidDate,
+			RecordID: record.Header.Identifier.Text,
+			Field:    "date",
+			Value:    record.Metadata.Dc.Date.Text,
+		}
 	}
 	output.Date = date
 	output.RawDate = date.Format("2006-01-02")
 	if output.Date.IsZero() {
-		return nil, span.Skip{Reason: fmt.Sprintf("Zero date: %s", record.Metadata.Dc.Date.Text)}
+		return nil, span.Skip{
+			Code:     span.SkipMissingDate,
+			RecordID: record.Header.Identifier.Text,
+			Field:    "date",
+			Value:    record.Metadata.Dc.Date.Text,
+		}
 	}
 
 	// Authors.
@@ -160,7 +175,7 @@ func (record Record) ToIntermediateSchema() (*finc.IntermediateSchema, error) {
 			"[Red.]", "", "[Komm.]", "", "[Samml.]", "", "[Komment.]", "",
 			"[Korres.]", "", "[Begr.]", "", "[Verstorb.]", "", "[Vorredn.]", "",
 			"[Komp.]", "")
-		creator = r.Replace(creator)
+		creator = strings.TrimSpace(r.Replace(creator))
 		output.Authors = append(output.Authors, finc.Author{Name: creator})
 	}
 
@@ -0,0 +1,15 @@
+package hhbd
+
+import "github.com/miku/span/formats"
+
+func init() {
+	formats.Register(formats.Format{
+		Name:    "hhbd",
+		Framing: formats.FramingXML,
+		New:     func() interface{} { return new(Record) },
+		Signature: &formats.Signature{
+			Elements: []string{"record"},
+			Contains: []string{"oai:digi.ub.uni-heidelberg.de"},
+		},
+	})
+}
@@ -54,6 +54,9 @@ func (r Record) ToIntermediateSchema() (*finc.IntermediateSchema, error) {
 	output.ID = fmt.Sprintf("ai-%s-%s", SourceIdentifier, encodedIdentifier)
 	output.RecordID = r.Header.Identifier
 	output.SourceID = SourceIdentifier
+	if r.Header.Status == "deleted" {
+		return finc.NewTombstone(output.ID, output.SourceID, output.RecordID), nil
+	}
 	output.Genre = Genre
 	output.Format = Format
 	output.RefType = DefaultRefType
@@ -0,0 +1,11 @@
+package highwire
+
+import "github.com/miku/span/formats"
+
+func init() {
+	formats.Register(formats.Format{
+		Name:    "highwire",
+		Framing: formats.FramingXML,
+		New:     func() interface{} { return new(Record) },
+	})
+}
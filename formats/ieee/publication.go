@@ -280,7 +280,7 @@ func (p Publication) ToIntermediateSchema() (*finc.IntermediateSchema, error) {
 	is.ArticleTitle = p.Volume.Article.Title
 
 	if strings.HasPrefix(is.ArticleTitle, "[") {
-		return is, span.Skip{Reason: fmt.Sprintf("extra content: %s", is.ArticleTitle)}
+		return is, span.Skip{Code: span.SkipExtraContent, Field: "title", Value: is.ArticleTitle}
 	}
 
 	is.ISSN = p.PaperISSN()
@@ -292,7 +292,7 @@ func (p Publication) ToIntermediateSchema() (*finc.IntermediateSchema, error) {
 	date, err := p.Date()
 	if err != nil {
 		log.Printf("date problem: %s: %s", err, is.ArticleTitle)
-		return is, span.Skip{Reason: err.Error()}
+		return is, span.Skip{Code: span.SkipInvalidDate, Field: "date", Reason: err.Error()}
 	}
 	is.Date = date
 	is.RawDate = date.Format("2006-01-02")
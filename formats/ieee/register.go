@@ -0,0 +1,14 @@
+package ieee
+
+import "github.com/miku/span/formats"
+
+func init() {
+	formats.Register(formats.Format{
+		Name:    "ieee",
+		Framing: formats.FramingXML,
+		New:     func() interface{} { return new(Publication) },
+		Signature: &formats.Signature{
+			Elements: []string{"publication", "publicationinfo"},
+		},
+	})
+}
@@ -9,7 +9,10 @@ import (
 )
 
 // SourceIdentifier of IMSLP.
-const SourceIdentifier = "15"
+const (
+	SourceIdentifier = "15"
+	Collection       = "IMSLP (Petrucci Library)"
+)
 
 // Data is just the raw bytes.
 type Data []byte
@@ -28,6 +31,7 @@ func (data *Data) ToIntermediateSchema() (*finc.IntermediateSchema, error) {
 	}
 	output := finc.NewIntermediateSchema()
 	output.SourceID = SourceIdentifier
+	output.MegaCollections = []string{Collection}
 	for _, t := range doc.FindElements("//var/recordId") {
 		encoded := base64.RawURLEncoding.EncodeToString([]byte(t.Text()))
 		output.ID = fmt.Sprintf("ai-%s-%s", SourceIdentifier, encoded)
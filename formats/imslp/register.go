@@ -0,0 +1,11 @@
+package imslp
+
+import "github.com/miku/span/formats"
+
+func init() {
+	formats.Register(formats.Format{
+		Name:    "imslp",
+		Framing: formats.FramingText,
+		New:     func() interface{} { return new(Data) },
+	})
+}
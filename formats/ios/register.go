@@ -0,0 +1,11 @@
+package ios
+
+import "github.com/miku/span/formats"
+
+func init() {
+	formats.Register(formats.Format{
+		Name:    "ios",
+		Framing: formats.FramingXML,
+		New:     func() interface{} { return new(Article) },
+	})
+}
@@ -52,10 +52,14 @@ var (
 	policy = bluemonday.StrictPolicy()
 )
 
-// PubDate represents a publication date. Typical type values are ppub and epub.
+// PubDate represents a publication date. Typical type values are ppub and epub
+// (NLM 2.3), JATS 1.x uses date-type and publication-format (print,
+// electronic) instead.
 type PubDate struct {
-	Type  string `xml:"pub-type,attr"`
-	Month struct {
+	Type              string `xml:"pub-type,attr"`
+	DateType          string `xml:"date-type,attr"`
+	PublicationFormat string `xml:"publication-format,attr"`
+	Month             struct {
 		XMLName xml.Name `xml:"month"`
 		Value   string   `xml:",chardata"`
 	}
@@ -97,6 +101,11 @@ type Article struct {
 					Type    string   `xml:"abbrev-type,attr"`
 				}
 			}
+			// JournalTitle without title group, NLM 2.3.
+			JournalTitle struct {
+				XMLName xml.Name `xml:"journal-title"`
+				Title   string   `xml:",chardata"`
+			}
 			AbbreviatedTitle struct {
 				XMLName xml.Name `xml:"abbrev-journal-title"`
 				Title   string   `xml:",chardata"`
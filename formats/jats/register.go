@@ -0,0 +1,31 @@
+package jats
+
+import (
+	"io"
+
+	"github.com/miku/span/formats"
+	"github.com/miku/span/formats/finc"
+)
+
+func init() {
+	formats.Register(formats.Format{
+		Name:    "jats",
+		Framing: formats.FramingXML,
+		New:     func() interface{} { return &Record{source: DefaultSource} },
+		Signature: &formats.Signature{
+			Elements: []string{"article", "front"},
+			Generic:  true,
+		},
+		RequiresMapping: true,
+		IDKey: func(is *finc.IntermediateSchema) []string {
+			return is.URL
+		},
+		Configure: func(r io.Reader) (formats.Factory, error) {
+			s, err := ReadSource(r)
+			if err != nil {
+				return nil, err
+			}
+			return func() interface{} { return &Record{source: s} }, nil
+		},
+	})
+}
@@ -0,0 +1,134 @@
+package jats
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/miku/span"
+	"github.com/miku/span/formats"
+	"github.com/miku/span/formats/finc"
+	"github.com/segmentio/encoding/json"
+)
+
+// DefaultSource holds the defaults for settings, that are not configured. It
+// has no source id, so records converted with it are skipped.
+var DefaultSource = &Source{
+	Format:      "ElectronicArticle",
+	URLTemplate: "https://doi.org/{doi}",
+}
+
+// Source holds per-source settings for the generic JATS format, so a new
+// JATS publisher only needs a small configuration file.
+//
+//	{
+//	  "source_id": "50",
+//	  "mega_collections": ["De Gruyter Journals", "sid-50-col-degruyterssh"],
+//	  "url_template": "http://dx.doi.org/{doi}"
+//	}
+type Source struct {
+	formats.Source
+	// Format, defaults to ElectronicArticle.
+	Format string `json:"format"`
+	// URLTemplate is used to build the record URL, which is also the basis
+	// for the record id; "{doi}" is replaced by the DOI.
+	URLTemplate string `json:"url_template"`
+	// DOIPrefix is prepended to DOIs, which are only a suffix, e.g.
+	// "10.1515" for "abc-2019-0001".
+	DOIPrefix string `json:"doi_prefix"`
+}
+
+// ReadSource reads source settings from JSON, unset values are taken from the
+// default source.
+func ReadSource(r io.Reader) (*Source, error) {
+	s := *DefaultSource
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, err
+	}
+	if !strings.Contains(s.URLTemplate, "{doi}") {
+		return nil, errors.New("jats: url_template must contain {doi}")
+	}
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// NormalizeDOI removes resolver and scheme prefixes and adds the configured
+// DOI prefix to bare suffixes.
+func (s *Source) NormalizeDOI(doi string) string {
+	doi = formats.TrimDOI(doi)
+	if s.DOIPrefix != "" && !strings.HasPrefix(doi, "10.") {
+		doi = strings.TrimRight(s.DOIPrefix, "/") + "/" + doi
+	}
+	return doi
+}
+
+// Record is a JATS article (JATS 1.x or NLM 2.3), converted with source
+// specific settings.
+type Record struct {
+	XMLName xml.Name `xml:"article"`
+	Article
+	source *Source
+}
+
+// ToIntermediateSchema converts the article, identifiers are derived from the
+// DOI.
+func (r *Record) ToIntermediateSchema() (*finc.IntermediateSchema, error) {
+	output, err := r.Article.ToIntermediateSchema()
+	if err != nil {
+		return output, err
+	}
+	doi, err := r.DOI()
+	if err != nil {
+		return output, span.Skip{Code: span.SkipMissingDOI, Reason: err.Error()}
+	}
+	doi = r.source.NormalizeDOI(doi)
+	link := strings.Replace(r.source.URLTemplate, "{doi}", doi, -1)
+	output.DOI = doi
+	output.RecordID = doi
+	output.URL = append(output.URL, link)
+	output.Format = r.source.Format
+	output.JournalTitle = r.JournalTitle()
+	output.Date = r.Date()
+	output.RawDate = output.Date.Format("2006-01-02")
+	sort.Strings(output.Languages)
+	if err := r.source.Apply(output, link); err != nil {
+		return output, err
+	}
+	if output.Date.IsZero() {
+		return output, span.Skip{Code: span.SkipMissingDate, RecordID: doi}
+	}
+	return output, nil
+}
+
+// JournalTitle falls back to a journal title without title group (NLM 2.3),
+// before the abbreviated title.
+func (r *Record) JournalTitle() string {
+	journal := r.Front.Journal
+	if journal.TitleGroup.JournalTitle.Title == "" &&
+		journal.TitleGroup.AbbreviatedTitle.Title == "" &&
+		journal.JournalTitle.Title != "" {
+		return journal.JournalTitle.Title
+	}
+	return r.Article.JournalTitle()
+}
+
+// Date prefers the print date, which JATS 1.x marks with publication-format
+// and NLM 2.3 with pub-type ppub.
+func (r *Record) Date() time.Time {
+	dates := r.Front.Article.PubDates
+	if len(dates) < 2 {
+		return r.Article.Date()
+	}
+	var index int
+	for i, pd := range dates {
+		if pd.Type == "ppub" || pd.PublicationFormat == "print" {
+			index = i
+		}
+	}
+	return r.parsePubDate(dates[index])
+}
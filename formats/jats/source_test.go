@@ -0,0 +1,125 @@
+package jats
+
+import (
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/miku/span/formats"
+)
+
+const (
+	// nlm23 is an article following the NLM 2.3 journal publishing DTD.
+	nlm23 = `<article xmlns:xlink="http://www.w3.org/1999/xlink" article-type="research-article">
+	<front>
+	  <journal-meta>
+	    <journal-title>Journal of Tests</journal-title>
+	    <issn pub-type="ppub">1234-5678</issn>
+	    <publisher><publisher-name>De Gruyter</publisher-name></publisher>
+	  </journal-meta>
+	  <article-meta>
+	    <article-id pub-id-type="doi">doi:10.1515/jot-2019-0001</article-id>
+	    <title-group><article-title>On Tests</article-title></title-group>
+	    <pub-date pub-type="epub"><day>02</day><month>01</month><year>2020</year></pub-date>
+	    <pub-date pub-type="ppub"><month>12</month><year>2019</year></pub-date>
+	    <volume>7</volume>
+	  </article-meta>
+	</front>
+	</article>`
+	// jats12 is an article following the JATS 1.2 archiving DTD, with a DOI
+	// suffix only.
+	jats12 = `<article xmlns:xlink="http://www.w3.org/1999/xlink" dtd-version="1.2" article-type="research-article">
+	<front>
+	  <journal-meta>
+	    <journal-title-group><journal-title>Journal of Tests</journal-title></journal-title-group>
+	    <issn publication-format="print">1234-5678</issn>
+	  </journal-meta>
+	  <article-meta>
+	    <article-id pub-id-type="doi">jot-2019-0002</article-id>
+	    <title-group><article-title>More Tests</article-title></title-group>
+	    <pub-date date-type="pub" publication-format="electronic"><day>02</day><month>01</month><year>2020</year></pub-date>
+	    <pub-date date-type="pub" publication-format="print"><month>11</month><year>2019</year></pub-date>
+	  </article-meta>
+	</front>
+	</article>`
+)
+
+func TestRecord(t *testing.T) {
+	source, err := ReadSource(strings.NewReader(`{
+		"source_id": "50",
+		"mega_collections": ["De Gruyter"],
+		"url_template": "http://dx.doi.org/{doi}",
+		"doi_prefix": "10.1515"
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cases = []struct {
+		about   string
+		input   string
+		id      string
+		doi     string
+		rawDate string
+	}{
+		{"nlm 2.3", nlm23, "ai-50-aHR0cDovL2R4LmRvaS5vcmcvMTAuMTUxNS9qb3QtMjAxOS0wMDAx", "10.1515/jot-2019-0001", "2019-12-01"},
+		{"jats 1.2", jats12, "ai-50-aHR0cDovL2R4LmRvaS5vcmcvMTAuMTUxNS9qb3QtMjAxOS0wMDAy", "10.1515/jot-2019-0002", "2019-11-01"},
+	}
+	for _, c := range cases {
+		r := &Record{source: source}
+		if err := xml.Unmarshal([]byte(c.input), r); err != nil {
+			t.Fatalf("%s: unmarshal failed: %v", c.about, err)
+		}
+		output, err := r.ToIntermediateSchema()
+		if err != nil {
+			t.Fatalf("%s: got %v, want nil", c.about, err)
+		}
+		if output.ID != c.id {
+			t.Errorf("%s: got %v, want %v", c.about, output.ID, c.id)
+		}
+		if output.DOI != c.doi {
+			t.Errorf("%s: got %v, want %v", c.about, output.DOI, c.doi)
+		}
+		if output.RawDate != c.rawDate {
+			t.Errorf("%s: got %v, want %v", c.about, output.RawDate, c.rawDate)
+		}
+		if output.JournalTitle != "Journal of Tests" {
+			t.Errorf("%s: got %v, want journal title", c.about, output.JournalTitle)
+		}
+	}
+}
+
+// TestArticle checks, that the shared article, which other formats embed,
+// keeps its behaviour; the overrides are specific to Record.
+func TestArticle(t *testing.T) {
+	var a Article
+	if err := xml.Unmarshal([]byte(nlm23), &a); err != nil {
+		t.Fatal(err)
+	}
+	if got := a.JournalTitle(); got != "" {
+		t.Errorf("got %q, want no journal title", got)
+	}
+	a = Article{}
+	if err := xml.Unmarshal([]byte(jats12), &a); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := a.Date().Format("2006-01-02"), "2020-01-02"; got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestReadSource(t *testing.T) {
+	if _, err := ReadSource(strings.NewReader(`{"url_template": "http://example.org/"}`)); err == nil {
+		t.Errorf("expected error on url template without {doi}")
+	}
+	if _, err := ReadSource(strings.NewReader(`{"url_template": "http://example.org/{doi}"}`)); !errors.Is(err, formats.ErrMissingSourceID) {
+		t.Errorf("got %v, want %v", err, formats.ErrMissingSourceID)
+	}
+	s, err := ReadSource(strings.NewReader(`{"source_id": "1"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.URLTemplate != DefaultSource.URLTemplate || s.Format != DefaultSource.Format {
+		t.Errorf("got %+v, want defaults", s)
+	}
+}
@@ -95,7 +95,7 @@ func (article *Article) Languages() []string {
 			}
 		}
 	}
-	return set.Values()
+	return set.SortedValues()
 }
 
 // ReviewedProduct returns the string of the reviewed thing in a best-effort way.
@@ -129,10 +129,10 @@ func (article *Article) ToIntermediateSchema() (*finc.IntermediateSchema, error)
 
 	id := ids.ID
 	if len(id) > span.KeyLengthLimit {
-		return output, span.Skip{Reason: fmt.Sprintf("id too long: %s", id)}
+		return output, span.Skip{Code: span.SkipIDTooLong, RecordID: id}
 	}
 	if len(id) == 0 {
-		return nil, span.Skip{Reason: fmt.Sprintf("no doi or identifier: %v", article)}
+		return nil, span.Skip{Code: span.SkipMissingID, Field: "article-id"}
 	}
 	output.ID = id
 	output.RecordID = ids.DOI
@@ -155,13 +155,18 @@ func (article *Article) ToIntermediateSchema() (*finc.IntermediateSchema, error)
 
 	// refs #5686
 	if output.Date.IsZero() {
-		return output, span.Skip{Reason: fmt.Sprintf("zero date: %s", output.ID)}
+		return output, span.Skip{Code: span.SkipMissingDate, RecordID: output.ID, Field: "date"}
 	}
 
 	// refs #5686
 	for _, p := range ArticleTitleBlockPatterns {
 		if p.MatchString(output.ArticleTitle) {
-			return output, span.Skip{Reason: fmt.Sprintf("title blacklisted: %s", output.ArticleTitle)}
+			return output, span.Skip{
+				Code:     span.SkipBlockedArticleTitle,
+				RecordID: output.ID,
+				Field:    "title",
+				Value:    output.ArticleTitle,
+			}
 		}
 	}
 
@@ -170,7 +175,12 @@ func (article *Article) ToIntermediateSchema() (*finc.IntermediateSchema, error)
 	case "book-review", "book-reviews", "Book Review":
 		output.ArticleTitle = fmt.Sprintf("Review: %s", article.ReviewedProduct())
 	case "misc", "other", "front-matter", "back-matter", "announcement", "font-matter", "fm", "fornt-matter":
-		return output, span.Skip{Reason: fmt.Sprintf("suppressed format: %s", article.Type)}
+		return output, span.Skip{
+			Code:     span.SkipSuppressedFormat,
+			RecordID: output.ID,
+			Field:    "article-type",
+			Value:    article.Type,
+		}
 	}
 
 	return output, nil
@@ -0,0 +1,21 @@
+package jstor
+
+import (
+	"github.com/miku/span/formats"
+	"github.com/miku/span/formats/finc"
+)
+
+func init() {
+	formats.Register(formats.Format{
+		Name:    "jstor",
+		Framing: formats.FramingXML,
+		New:     func() interface{} { return new(Article) },
+		Signature: &formats.Signature{
+			Elements: []string{"article", "front"},
+			Contains: []string{`journal-id-type="jstor"`},
+		},
+		IDKey: func(is *finc.IntermediateSchema) []string {
+			return is.URL
+		},
+	})
+}
@@ -0,0 +1,298 @@
+package marc
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/miku/span"
+	"github.com/miku/span/formats/finc"
+)
+
+// DefaultOrganizationCode goes into 003, the identifier of the control
+// number in 001, which is the finc.id.
+const DefaultOrganizationCode = "finc"
+
+// now is the date the record is entered on file, in 008.
+var now = time.Now
+
+// maxAbstractLength keeps the abstract within the ISO 2709 field limit.
+const maxAbstractLength = 8000
+
+// genreTerms are used in 655, the genre or form of a document.
+var genreTerms = map[string]string{
+	"article":    "Article",
+	"book":       "Book",
+	"bookitem":   "Book chapter",
+	"conference": "Conference",
+	"document":   "Document",
+	"issue":      "Journal issue",
+	"journal":    "Journal",
+	"preprint":   "Preprint",
+	"proceeding": "Conference paper",
+	"report":     "Report",
+	"unknown":    "Document",
+}
+
+// Exporter exports intermediate schema documents as MARC21, either as
+// MARCXML records in a collection or, if Binary is set, as ISO 2709 records.
+type Exporter struct {
+	// Binary selects ISO 2709 instead of MARCXML.
+	Binary bool
+	// OrganizationCode for 003, defaults to DefaultOrganizationCode.
+	OrganizationCode string
+}
+
+// Export serializes a document as a single MARC record.
+func (e *Exporter) Export(is finc.IntermediateSchema, _ bool) ([]byte, error) {
+	d, err := e.Record(is)
+	if err != nil {
+		return nil, err
+	}
+	if e.Binary {
+		return d.MarshalBinary()
+	}
+	return xml.Marshal(d)
+}
+
+// Header starts a MARCXML collection.
+func (e *Exporter) Header() []byte {
+	if e.Binary {
+		return nil
+	}
+	return []byte(xml.Header + `<collection xmlns="` + Namespace + `">` + "\n")
+}
+
+// Footer ends a MARCXML collection.
+func (e *Exporter) Footer() []byte {
+	if e.Binary {
+		return nil
+	}
+	return []byte("</collection>\n")
+}
+
+// SelfDelimiting is true for ISO 2709, where each record ends with a record
+// terminator.
+func (e *Exporter) SelfDelimiting() bool {
+	return e.Binary
+}
+
+// Record maps a document to MARC21 bibliographic fields: leader, 001/003,
+// 007, 008, 020, 022, 024 (DOI), 041, 100/700, 245, 264, 520, 653, 655, 773 (host item
+// with volume, issue and pages) and 856. The finc.id is required, as it is
+// the control number.
+func (e *Exporter) Record(is finc.IntermediateSchema) (Data, error) {
+	if is.ID == "" {
+		return Data{}, fmt.Errorf("%w: missing finc.id for record %q", ErrInvalidRecord, is.RecordID)
+	}
+	orgCode := e.OrganizationCode
+	if orgCode == "" {
+		orgCode = DefaultOrganizationCode
+	}
+	d := Data{Leader: leader(is)}
+	control := func(tag, value string) {
+		if value != "" {
+			d.Fields = append(d.Fields, Field{Tag: tag, Value: value})
+		}
+	}
+	data := func(tag, ind1, ind2 string, sfs ...string) {
+		f := Field{Tag: tag, Ind1: ind1, Ind2: ind2}
+		for i := 0; i+1 < len(sfs); i += 2 {
+			if v := strings.TrimSpace(sfs[i+1]); v != "" {
+				f.Subfields = append(f.Subfields, Subfield{Code: sfs[i], Value: v})
+			}
+		}
+		// Fields with only a source ($2) carry no information.
+		if len(f.Subfields) > 0 && f.Subfields[0].Code != "2" {
+			d.Fields = append(d.Fields, f)
+		}
+	}
+	control("001", is.ID)
+	control("003", orgCode)
+	control("007", "cr")
+	control("008", fixedLength(is, now()))
+	for _, isbn := range is.ISBNList() {
+		data("020", " ", " ", "a", isbn)
+	}
+	for _, issn := range is.ISSNList() {
+		data("022", " ", " ", "a", issn)
+	}
+	data("024", "7", " ", "a", is.DOI, "2", "doi")
+	if len(is.Languages) > 0 {
+		f := Field{Tag: "041", Ind1: " ", Ind2: " "}
+		for _, l := range is.Languages {
+			f.Subfields = append(f.Subfields, Subfield{Code: "a", Value: l})
+		}
+		d.Fields = append(d.Fields, f)
+	}
+	for i, author := range is.Authors {
+		tag := "700"
+		if i == 0 {
+			tag = "100"
+		}
+		data(tag, "1", " ", "a", authorName(author))
+	}
+	titleInd1 := "0"
+	if len(is.Authors) > 0 {
+		titleInd1 = "1"
+	}
+	title := is.ArticleTitle
+	if title == "" {
+		title = is.BookTitle
+	}
+	data("245", titleInd1, "0", "a", title, "b", is.ArticleSubtitle)
+	var year string
+	if !is.Date.IsZero() {
+		year = fmt.Sprintf("%d", is.Date.Year())
+	}
+	data("264", " ", "1", "a", strings.Join(is.Places, "; "), "b", strings.Join(is.Publishers, "; "), "c", year)
+	data("520", " ", " ", "a", truncate(is.AbstractCleaned(), maxAbstractLength))
+	for _, s := range is.Subjects {
+		data("653", " ", " ", "a", s)
+	}
+	if term, ok := genreTerms[is.Genre]; ok {
+		data("655", " ", "7", "a", term, "2", "local")
+	}
+	if container := hostTitle(is); container != "" {
+		data("773", "0", "8",
+			"t", container,
+			"d", strings.Join(is.Publishers, "; "),
+			"g", enumeration(is, year),
+			"x", first(is.ISSNList()),
+			"z", first(is.ISBNList()),
+			"q", sequence(is))
+	}
+	for _, u := range is.URL {
+		data("856", "4", "0", "u", u)
+	}
+	return d, nil
+}
+
+// leader returns a leader for an online resource in Unicode; length and
+// base address are filled in by MarshalBinary.
+func leader(is finc.IntermediateSchema) string {
+	var level byte = 'a' // component part, e.g. an article
+	switch is.Genre {
+	case "book", "proceeding", "report", "document":
+		if is.JournalTitle == "" {
+			level = 'm'
+		}
+	case "journal":
+		level = 's'
+	}
+	return fmt.Sprintf("00000na%c a2200000 u 4500", level)
+}
+
+// fixedLength returns 008 for an online resource: date entered, publication
+// year, if known, and language. Material specific positions are not coded.
+func fixedLength(is finc.IntermediateSchema, entered time.Time) string {
+	dateType, year := "n", "uuuu"
+	if !is.Date.IsZero() {
+		dateType, year = "s", fmt.Sprintf("%04d", is.Date.Year())
+	}
+	material := []byte(strings.Repeat("|", 17))
+	material[23-18] = 'o' // form of item: online
+	return entered.Format("060102") + dateType + year + "    " + "xx " + string(material) + language008(is.Languages) + " d"
+}
+
+// language008 returns the first language as MARC (ISO 639-2/B) code, or
+// "und".
+func language008(languages []string) string {
+	if len(languages) == 0 || len(languages[0]) != 3 {
+		return "und"
+	}
+	code := languages[0]
+	for b, t := range span.ISO639BibliographicToThree {
+		if t == code {
+			return b
+		}
+	}
+	return code
+}
+
+// hostTitle is the title of the host item: the journal or, for chapters, the
+// book.
+func hostTitle(is finc.IntermediateSchema) string {
+	if is.JournalTitle != "" {
+		return is.JournalTitle
+	}
+	if is.ArticleTitle != "" && is.BookTitle != "" && is.BookTitle != is.ArticleTitle {
+		return is.BookTitle
+	}
+	return ""
+}
+
+// enumeration returns a human readable volume, issue, year and pages, e.g.
+// "Vol. 12, no. 3 (2019), p. 1-10".
+func enumeration(is finc.IntermediateSchema, year string) string {
+	var parts []string
+	if is.Volume != "" {
+		parts = append(parts, "Vol. "+is.Volume)
+	}
+	if is.Issue != "" {
+		parts = append(parts, "no. "+is.Issue)
+	}
+	s := strings.Join(parts, ", ")
+	if year != "" {
+		s = strings.TrimSpace(s + " (" + year + ")")
+	}
+	switch {
+	case is.StartPage != "" && is.EndPage != "":
+		s += fmt.Sprintf(", p. %s-%s", is.StartPage, is.EndPage)
+	case is.StartPage != "":
+		s += ", p. " + is.StartPage
+	case is.Pages != "":
+		s += ", p. " + is.Pages
+	}
+	return strings.TrimPrefix(s, ", ")
+}
+
+// sequence returns the machine readable enumeration for 773 $q, e.g.
+// "12:3<1".
+func sequence(is finc.IntermediateSchema) string {
+	var s string
+	switch {
+	case is.Volume != "" && is.Issue != "":
+		s = is.Volume + ":" + is.Issue
+	case is.Volume != "":
+		s = is.Volume
+	case is.Issue != "":
+		s = is.Issue
+	}
+	if is.StartPage != "" {
+		s += "<" + is.StartPage
+	}
+	return s
+}
+
+// authorName returns a name in inverted order, if possible.
+func authorName(a finc.Author) string {
+	if a.LastName != "" {
+		if a.FirstName != "" {
+			return a.LastName + ", " + a.FirstName
+		}
+		return a.LastName
+	}
+	return a.String()
+}
+
+// first returns the first value or the empty string.
+func first(ss []string) string {
+	if len(ss) == 0 {
+		return ""
+	}
+	return ss[0]
+}
+
+// truncate shortens s to at most n bytes, without splitting runes.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
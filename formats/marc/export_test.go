@@ -0,0 +1,159 @@
+package marc
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/miku/span/formats/finc"
+)
+
+func TestExport(t *testing.T) {
+	defer func(f func() time.Time) { now = f }(now)
+	now = func() time.Time { return time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC) }
+	is := finc.IntermediateSchema{
+		ID:           "ai-49-1",
+		ArticleTitle: "On Tags",
+		Authors:      []finc.Author{{FirstName: "Ada", LastName: "Lovelace"}, {Name: "Charles Babbage"}},
+		DOI:          "10.1/1",
+		Date:         time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+		Genre:        "article",
+		ISSN:         []string{"0001-0002"},
+		JournalTitle: "Journal of Tags",
+		Languages:    []string{"deu"},
+		Volume:       "12",
+		Issue:        "3",
+		StartPage:    "1",
+		EndPage:      "10",
+		URL:          []string{"https://doi.org/10.1/1"},
+	}
+	var cases = []struct {
+		spec   string
+		result []string
+	}{
+		{"001", []string{"ai-49-1"}},
+		{"003", []string{"finc"}},
+		{"022.a", []string{"0001-0002"}},
+		{"024.a", []string{"10.1/1"}},
+		{"024.2", []string{"doi"}},
+		{"008", []string{"240102s2019    xx |||||o|||||||||||ger d"}},
+		{"041.a", []string{"deu"}},
+		{"100.a", []string{"Lovelace, Ada"}},
+		{"700.a", []string{"Charles Babbage"}},
+		{"245.a", []string{"On Tags"}},
+		{"655.a", []string{"Article"}},
+		{"773.t", []string{"Journal of Tags"}},
+		{"773.g", []string{"Vol. 12, no. 3 (2019), p. 1-10"}},
+		{"773.q", []string{"12:3<1"}},
+		{"856.u", []string{"https://doi.org/10.1/1"}},
+	}
+	for _, binary := range []bool{false, true} {
+		e := &Exporter{Binary: binary}
+		b, err := e.Export(is, false)
+		if err != nil {
+			t.Fatalf("binary=%v: export failed: %v", binary, err)
+		}
+		var d Data
+		if binary {
+			err = d.UnmarshalBinary(b)
+		} else {
+			err = xml.Unmarshal(b, &d)
+		}
+		if err != nil {
+			t.Fatalf("binary=%v: cannot read exported record: %v", binary, err)
+		}
+		if d.Leader[6:8] != "aa" || d.Leader[9] != 'a' {
+			t.Errorf("binary=%v: unexpected leader %q", binary, d.Leader)
+		}
+		for _, c := range cases {
+			if result := d.Values(c.spec); !reflect.DeepEqual(result, c.result) {
+				t.Errorf("binary=%v: %s: got %v, want %v", binary, c.spec, result, c.result)
+			}
+		}
+		if e.SelfDelimiting() != binary {
+			t.Errorf("binary=%v: got self delimiting %v", binary, e.SelfDelimiting())
+		}
+	}
+}
+
+func TestExportCollection(t *testing.T) {
+	e := new(Exporter)
+	var buf bytes.Buffer
+	buf.Write(e.Header())
+	for _, id := range []string{"ai-49-1", "ai-49-2"} {
+		b, err := e.Export(finc.IntermediateSchema{ID: id, ArticleTitle: "On Tags"}, false)
+		if err != nil {
+			t.Fatalf("export failed: %v", err)
+		}
+		buf.Write(b)
+		buf.WriteString("\n")
+	}
+	buf.Write(e.Footer())
+	var collection struct {
+		XMLName xml.Name `xml:"http://www.loc.gov/MARC21/slim collection"`
+		Records []Data   `xml:"record"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &collection); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if len(collection.Records) != 2 {
+		t.Fatalf("got %d records, want 2", len(collection.Records))
+	}
+	if v := collection.Records[1].Values("008/35-37"); !reflect.DeepEqual(v, []string{"und"}) {
+		t.Errorf("got %v, want [und]", v)
+	}
+	binary := &Exporter{Binary: true}
+	if binary.Header() != nil || binary.Footer() != nil {
+		t.Errorf("got header and footer for ISO 2709")
+	}
+}
+
+func TestMarshalBinary(t *testing.T) {
+	b := encode("001 123", "245 10$aHello$bWorld", "856 40$uhttp://x.org")
+	var d Data
+	if err := d.UnmarshalBinary(b); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	result, err := d.MarshalBinary()
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	if string(result) != string(b) {
+		t.Errorf("got %q, want %q", result, b)
+	}
+	if _, err := (Data{Leader: "short"}).MarshalBinary(); err == nil {
+		t.Errorf("expected error on short leader")
+	}
+	d = Data{Leader: d.Leader, Fields: []Field{
+		{Tag: "001", Value: "1\x1e2"},
+		{Tag: "245", Ind1: "1", Ind2: "0", Subfields: []Subfield{{Code: "a", Value: "Hello\x1fbWorld\x1d"}}},
+	}}
+	if result, err = d.MarshalBinary(); err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	var got Data
+	if err := got.UnmarshalBinary(result); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	if v := got.Values("001"); !reflect.DeepEqual(v, []string{"1 2"}) {
+		t.Errorf("001: got %q, want [\"1 2\"]", v)
+	}
+	if v := got.Values("245.a"); !reflect.DeepEqual(v, []string{"Hello bWorld "}) {
+		t.Errorf("245.a: got %q, want [\"Hello bWorld \"]", v)
+	}
+	if v := got.Values("245.b"); len(v) > 0 {
+		t.Errorf("245.b: got %q, want none", v)
+	}
+}
+
+func TestExportMissingID(t *testing.T) {
+	is := finc.IntermediateSchema{RecordID: "1", ArticleTitle: "On Tags"}
+	for _, binary := range []bool{false, true} {
+		if _, err := (&Exporter{Binary: binary}).Export(is, false); !errors.Is(err, ErrInvalidRecord) {
+			t.Errorf("binary=%v: got %v, want %v", binary, err, ErrInvalidRecord)
+		}
+	}
+}
@@ -0,0 +1,386 @@
+package marc
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	// RecordTerminator ends an ISO 2709 record.
+	RecordTerminator = 0x1d
+	// FieldTerminator ends the directory and each variable field.
+	FieldTerminator = 0x1e
+	// SubfieldDelimiter starts a subfield, followed by the subfield code.
+	SubfieldDelimiter = 0x1f
+)
+
+// Namespace of MARCXML.
+const Namespace = "http://www.loc.gov/MARC21/slim"
+
+// ErrInvalidRecord is returned for malformed ISO 2709 data.
+var ErrInvalidRecord = errors.New("marc: invalid record")
+
+// Subfield is a code and a value.
+type Subfield struct {
+	Code  string
+	Value string
+}
+
+// Field is either a control field with a value or a data field with
+// indicators and subfields.
+type Field struct {
+	Tag       string
+	Ind1      string
+	Ind2      string
+	Value     string
+	Subfields []Subfield
+}
+
+// IsControl returns true for control fields, 001 to 009.
+func (f Field) IsControl() bool {
+	return strings.HasPrefix(f.Tag, "00")
+}
+
+// Data is a MARC record independent of its serialization.
+type Data struct {
+	Leader string
+	Fields []Field
+	// OAI envelope, if any.
+	Identifier string
+	Status     string
+}
+
+// Values returns the values for a spec. A spec is either a tag ("001",
+// "245"), a tag with subfield code ("245.a") or a control field with a
+// character range ("008/35-37"). Data fields without subfield code yield all
+// subfields joined by space.
+func (d Data) Values(spec string) []string {
+	var (
+		tag, code = spec, ""
+		from, to  = -1, -1
+	)
+	if i := strings.Index(spec, "."); i > 0 {
+		tag, code = spec[:i], spec[i+1:]
+	}
+	if i := strings.Index(spec, "/"); i > 0 {
+		tag = spec[:i]
+		parts := strings.SplitN(spec[i+1:], "-", 2)
+		from, _ = strconv.Atoi(parts[0])
+		to = from
+		if len(parts) == 2 {
+			to, _ = strconv.Atoi(parts[1])
+		}
+	}
+	if tag == "LDR" {
+		return []string{substr(d.Leader, from, to)}
+	}
+	var result []string
+	for _, f := range d.Fields {
+		if f.Tag != tag {
+			continue
+		}
+		switch {
+		case f.IsControl():
+			result = append(result, substr(f.Value, from, to))
+		case code == "":
+			var vs []string
+			for _, sf := range f.Subfields {
+				vs = append(vs, sf.Value)
+			}
+			result = append(result, strings.Join(vs, " "))
+		default:
+			for _, sf := range f.Subfields {
+				if sf.Code == code {
+					result = append(result, sf.Value)
+				}
+			}
+		}
+	}
+	return result
+}
+
+// substr returns characters from-to (inclusive) of s, or s, if from is
+// negative.
+func substr(s string, from, to int) string {
+	if from < 0 {
+		return s
+	}
+	if from >= len(s) {
+		return ""
+	}
+	if to >= len(s) {
+		to = len(s) - 1
+	}
+	return s[from : to+1]
+}
+
+// digits parses a number of ASCII digits only, unlike strconv.Atoi, which
+// accepts signs.
+func digits(b []byte) (int, bool) {
+	var n int
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+		n = n*10 + int(c-'0')
+	}
+	return n, len(b) > 0
+}
+
+// UnmarshalBinary parses a single ISO 2709 record, the record terminator is
+// optional.
+func (d *Data) UnmarshalBinary(b []byte) error {
+	b = bytes.TrimLeft(b, "\r\n\t ")
+	b = bytes.TrimRight(b, "\r\n\x1d")
+	if len(b) < 24 {
+		return ErrInvalidRecord
+	}
+	d.Leader = string(b[:24])
+	// The directory ends with a field terminator, so the base address is
+	// greater than the leader length.
+	base, ok := digits(b[12:17])
+	if !ok || base <= 24 || base > len(b) {
+		return fmt.Errorf("%w: base address %q", ErrInvalidRecord, b[12:17])
+	}
+	if b[base-1] != FieldTerminator {
+		return fmt.Errorf("%w: missing directory terminator", ErrInvalidRecord)
+	}
+	directory := b[24 : base-1]
+	if len(directory)%12 != 0 {
+		return fmt.Errorf("%w: directory length %d", ErrInvalidRecord, len(directory))
+	}
+	d.Fields = nil
+	for i := 0; i < len(directory); i += 12 {
+		entry := directory[i : i+12]
+		length, ok := digits(entry[3:7])
+		if !ok {
+			return fmt.Errorf("%w: field length %q", ErrInvalidRecord, entry[3:7])
+		}
+		start, ok := digits(entry[7:12])
+		if !ok {
+			return fmt.Errorf("%w: field start %q", ErrInvalidRecord, entry[7:12])
+		}
+		if start+length > len(b)-base {
+			return fmt.Errorf("%w: field out of bounds", ErrInvalidRecord)
+		}
+		end := base + start + length
+		raw := bytes.TrimRight(b[base+start:end], "\x1e")
+		field := Field{Tag: string(entry[:3])}
+		if field.IsControl() {
+			field.Value = string(raw)
+			d.Fields = append(d.Fields, field)
+			continue
+		}
+		parts := bytes.Split(raw, []byte{SubfieldDelimiter})
+		if len(parts[0]) > 0 {
+			field.Ind1 = string(parts[0][:1])
+		}
+		if len(parts[0]) > 1 {
+			field.Ind2 = string(parts[0][1:2])
+		}
+		for _, p := range parts[1:] {
+			if len(p) == 0 {
+				continue
+			}
+			field.Subfields = append(field.Subfields, Subfield{
+				Code:  string(p[:1]),
+				Value: string(p[1:]),
+			})
+		}
+		d.Fields = append(d.Fields, field)
+	}
+	return nil
+}
+
+// Limits of ISO 2709.
+const (
+	maxFieldLength  = 9999
+	maxRecordLength = 99999
+)
+
+// ErrRecordTooLong is returned, if a record does not fit into ISO 2709.
+var ErrRecordTooLong = errors.New("marc: record too long")
+
+// delimiters replaces terminators and delimiters in values, which would
+// otherwise break the record structure.
+var delimiters = strings.NewReplacer(
+	string(rune(RecordTerminator)), " ",
+	string(rune(FieldTerminator)), " ",
+	string(rune(SubfieldDelimiter)), " ",
+)
+
+// MarshalBinary serializes the record as ISO 2709. Record length and base
+// address in the leader are computed, the leader must be 24 bytes long.
+// Delimiters in values are replaced with spaces.
+func (d Data) MarshalBinary() ([]byte, error) {
+	if len(d.Leader) != 24 {
+		return nil, fmt.Errorf("%w: leader %q", ErrInvalidRecord, d.Leader)
+	}
+	var dir, data bytes.Buffer
+	for _, f := range d.Fields {
+		if len(f.Tag) != 3 {
+			return nil, fmt.Errorf("%w: tag %q", ErrInvalidRecord, f.Tag)
+		}
+		start := data.Len()
+		if f.IsControl() {
+			data.WriteString(delimiters.Replace(f.Value))
+		} else {
+			data.WriteString(indicator(f.Ind1))
+			data.WriteString(indicator(f.Ind2))
+			for _, sf := range f.Subfields {
+				data.WriteByte(SubfieldDelimiter)
+				data.WriteString(sf.Code)
+				data.WriteString(delimiters.Replace(sf.Value))
+			}
+		}
+		data.WriteByte(FieldTerminator)
+		length := data.Len() - start
+		if length > maxFieldLength {
+			return nil, fmt.Errorf("%w: field %s has %d bytes", ErrRecordTooLong, f.Tag, length)
+		}
+		fmt.Fprintf(&dir, "%s%04d%05d", f.Tag, length, start)
+	}
+	dir.WriteByte(FieldTerminator)
+	var (
+		base   = 24 + dir.Len()
+		length = base + data.Len() + 1
+	)
+	if length > maxRecordLength {
+		return nil, fmt.Errorf("%w: %d bytes", ErrRecordTooLong, length)
+	}
+	var buf bytes.Buffer
+	buf.Grow(length)
+	fmt.Fprintf(&buf, "%05d%s%05d%s", length, d.Leader[5:12], base, d.Leader[17:])
+	buf.Write(dir.Bytes())
+	buf.Write(data.Bytes())
+	buf.WriteByte(RecordTerminator)
+	return buf.Bytes(), nil
+}
+
+// indicator returns a blank for an empty indicator.
+func indicator(s string) string {
+	if s == "" {
+		return " "
+	}
+	return s
+}
+
+// MarshalXML writes a MARCXML record element.
+func (d Data) MarshalXML(enc *xml.Encoder, start xml.StartElement) error {
+	start.Name = xml.Name{Space: Namespace, Local: "record"}
+	start.Attr = nil
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+	if err := enc.EncodeElement(d.Leader, xml.StartElement{Name: xml.Name{Local: "leader"}}); err != nil {
+		return err
+	}
+	for _, f := range d.Fields {
+		tag := xml.Attr{Name: xml.Name{Local: "tag"}, Value: f.Tag}
+		if f.IsControl() {
+			se := xml.StartElement{Name: xml.Name{Local: "controlfield"}, Attr: []xml.Attr{tag}}
+			if err := enc.EncodeElement(f.Value, se); err != nil {
+				return err
+			}
+			continue
+		}
+		se := xml.StartElement{Name: xml.Name{Local: "datafield"}, Attr: []xml.Attr{
+			tag,
+			{Name: xml.Name{Local: "ind1"}, Value: indicator(f.Ind1)},
+			{Name: xml.Name{Local: "ind2"}, Value: indicator(f.Ind2)},
+		}}
+		if err := enc.EncodeToken(se); err != nil {
+			return err
+		}
+		for _, sf := range f.Subfields {
+			code := xml.Attr{Name: xml.Name{Local: "code"}, Value: sf.Code}
+			if err := enc.EncodeElement(sf.Value, xml.StartElement{Name: xml.Name{Local: "subfield"}, Attr: []xml.Attr{code}}); err != nil {
+				return err
+			}
+		}
+		if err := enc.EncodeToken(se.End()); err != nil {
+			return err
+		}
+	}
+	return enc.EncodeToken(start.End())
+}
+
+// UnmarshalXML collects leader, control and data fields from a MARCXML
+// record, which may be wrapped in an OAI record. The decoder signals io.EOF at
+// the end of the start element.
+func (d *Data) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
+	var (
+		field  *Field
+		text   strings.Builder
+		header bool
+	)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			text.Reset()
+			switch t.Name.Local {
+			case "header":
+				header = true
+				d.Status = attr(t, "status")
+			case "controlfield":
+				field = &Field{Tag: attr(t, "tag")}
+			case "datafield":
+				field = &Field{Tag: attr(t, "tag"), Ind1: attr(t, "ind1"), Ind2: attr(t, "ind2")}
+			case "subfield":
+				if field != nil {
+					field.Subfields = append(field.Subfields, Subfield{Code: attr(t, "code")})
+				}
+			}
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "header":
+				header = false
+			case "identifier":
+				if header {
+					d.Identifier = strings.TrimSpace(text.String())
+				}
+			case "leader":
+				d.Leader = text.String()
+			case "controlfield":
+				if field != nil {
+					field.Value = text.String()
+					d.Fields = append(d.Fields, *field)
+					field = nil
+				}
+			case "subfield":
+				if field != nil && len(field.Subfields) > 0 {
+					field.Subfields[len(field.Subfields)-1].Value = text.String()
+				}
+			case "datafield":
+				if field != nil {
+					d.Fields = append(d.Fields, *field)
+					field = nil
+				}
+			}
+		}
+	}
+}
+
+// attr returns the value of an attribute by local name.
+func attr(se xml.StartElement, name string) string {
+	for _, a := range se.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,179 @@
+package marc
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/miku/span/formats"
+)
+
+// encode builds a minimal ISO 2709 record from fields; control fields are
+// given as "001 value", data fields as "245 10$aTitle$bSub".
+func encode(fields ...string) []byte {
+	var dir, data bytes.Buffer
+	for _, f := range fields {
+		tag, value := f[:3], f[4:]
+		value = strings.Replace(value, "$", "\x1f", -1) + "\x1e"
+		fmt.Fprintf(&dir, "%s%04d%05d", tag, len(value), data.Len())
+		data.WriteString(value)
+	}
+	dir.WriteByte(FieldTerminator)
+	base := 24 + dir.Len()
+	length := base + data.Len() + 1
+	leader := fmt.Sprintf("%05dnam a22%05d u 4500", length, base)
+	return []byte(leader + dir.String() + data.String() + "\x1d")
+}
+
+func TestUnmarshalBinary(t *testing.T) {
+	b := encode("001 123", "008 200101s2001    gw            000 0 ger d", "245 10$aHello$bWorld", "856 40$uhttp://x.org")
+	if !isBinaryMARC(b) {
+		t.Fatalf("expected binary marc leader: %q", b[:24])
+	}
+	var d Data
+	if err := d.UnmarshalBinary(b); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	var cases = []struct {
+		spec   string
+		result []string
+	}{
+		{"001", []string{"123"}},
+		{"008/35-37", []string{"ger"}},
+		{"245.a", []string{"Hello"}},
+		{"245", []string{"Hello World"}},
+		{"856.u", []string{"http://x.org"}},
+		{"999.a", nil},
+	}
+	for _, c := range cases {
+		if result := d.Values(c.spec); !reflect.DeepEqual(result, c.result) {
+			t.Errorf("%s: got %v, want %v", c.spec, result, c.result)
+		}
+	}
+	if err := d.UnmarshalBinary([]byte("00010nam")); err == nil {
+		t.Errorf("expected error on short record")
+	}
+	for _, s := range []string{
+		"00024nam a2200024   4500",
+		"00025nam a2200023   4500\x1e",
+		"00030nam a2200099   4500\x1e12345",
+		"00030nam a2200030   4500\x1e12345",
+		"00049nam a2200037   45002450010-9999\x1eabcdefghijkl\x1d",
+		"00049nam a2200037   4500245-99900000\x1eabcdefghijkl\x1d",
+		"00049nam a2200037   4500245001300000\x1eabcdefghijkl\x1d",
+		"00049nam a2200037   4500245+01200000\x1eabcdefghijkl\x1d",
+	} {
+		if err := d.UnmarshalBinary([]byte(s)); !errors.Is(err, ErrInvalidRecord) {
+			t.Errorf("%q: got %v, want ErrInvalidRecord", s, err)
+		}
+	}
+}
+
+func TestLanguages(t *testing.T) {
+	m := &Mapping{
+		ID:        []string{"001"},
+		Constants: map[string][]string{"finc.source_id": {"1"}},
+		Fields: map[string][]string{
+			"languages": {"041.a", "008/35-37"},
+			"x.date":    {"008/07-10"},
+		},
+	}
+	var cases = []struct {
+		fields []string
+		want   []string
+	}{
+		{[]string{"001 1", "008 200101s2001    gw            000 0 ger d", "041 0 $afre$ager"}, []string{"fra", "deu"}},
+		{[]string{"001 1", "008 200101s2001    gw            000 0 ||| d"}, nil},
+		{[]string{"001 1", "008 200101s2001    gw            000 0     d"}, nil},
+	}
+	for _, c := range cases {
+		var d Data
+		if err := d.UnmarshalBinary(encode(c.fields...)); err != nil {
+			t.Fatalf("unmarshal failed: %v", err)
+		}
+		output, err := m.Convert(d)
+		if err != nil {
+			t.Fatalf("conversion failed: %v", err)
+		}
+		if !reflect.DeepEqual(output.Languages, c.want) {
+			t.Errorf("%v: got %v, want %v", c.fields, output.Languages, c.want)
+		}
+	}
+}
+
+func TestXMLRecordWithEnvelope(t *testing.T) {
+	s := `<record xmlns="http://www.openarchives.org/OAI/2.0/">
+	<header><identifier>oai:example.org:1</identifier></header>
+	<metadata>
+	  <marc:record xmlns:marc="http://www.loc.gov/MARC21/slim">
+	    <marc:leader>00000nab a2200000 u 4500</marc:leader>
+	    <marc:controlfield tag="001">1</marc:controlfield>
+	    <marc:datafield tag="022" ind1=" " ind2=" "><marc:subfield code="a">1234-5678</marc:subfield></marc:datafield>
+	    <marc:datafield tag="245" ind1="1" ind2="0"><marc:subfield code="a">Hello</marc:subfield></marc:datafield>
+	    <marc:datafield tag="264" ind1=" " ind2="1"><marc:subfield code="c">[ca. 2019]</marc:subfield></marc:datafield>
+	  </marc:record>
+	</metadata>
+	</record>`
+	r := &XMLRecord{mapping: &Mapping{
+		ID:        []string{"001"},
+		Constants: map[string][]string{"finc.source_id": {"1"}},
+		Fields: map[string][]string{
+			"rft.atitle": {"245.a"},
+			"rft.issn":   {"022.a"},
+			"x.date":     {"264.c"},
+		},
+	}}
+	if err := xml.Unmarshal([]byte(s), r); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	if r.Identifier != "oai:example.org:1" {
+		t.Errorf("got %q, want oai identifier", r.Identifier)
+	}
+	output, err := r.ToIntermediateSchema()
+	if err != nil {
+		t.Fatalf("conversion failed: %v", err)
+	}
+	if output.ArticleTitle != "Hello" || output.RawDate != "2019-01-01" ||
+		!reflect.DeepEqual(output.ISSN, []string{"1234-5678"}) || output.ID != "ai-1-MQ" {
+		t.Errorf("unexpected output: %+v", output)
+	}
+}
+
+func TestDefaultMapping(t *testing.T) {
+	if err := DefaultMapping.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadMapping(strings.NewReader(`{"fields": {"rft.unknown": ["245.a"]}}`)); err == nil {
+		t.Errorf("expected error on unknown field")
+	}
+	// Configured mappings need a source id.
+	if _, err := readMapping(strings.NewReader(`{"id": ["001"]}`)); !errors.Is(err, formats.ErrMissingSourceID) {
+		t.Errorf("got %v, want %v", err, formats.ErrMissingSourceID)
+	}
+}
+
+func TestDeletedRecord(t *testing.T) {
+	s := `<record xmlns="http://www.openarchives.org/OAI/2.0/">
+	<header status="deleted"><identifier>oai:example.org:1</identifier></header>
+	</record>`
+	r := &XMLRecord{mapping: &Mapping{
+		ID:        []string{"001"},
+		Constants: map[string][]string{"finc.source_id": {"1"}},
+		Fields:    map[string][]string{"x.date": {"264.c"}},
+	}}
+	if err := xml.Unmarshal([]byte(s), r); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	output, err := r.ToIntermediateSchema()
+	if err != nil {
+		t.Fatalf("got %v, want tombstone", err)
+	}
+	if !output.Deleted || output.RecordID != "oai:example.org:1" || output.SourceID != "1" ||
+		output.ID != "ai-1-b2FpOmV4YW1wbGUub3JnOjE" {
+		t.Errorf("unexpected tombstone: %+v", output)
+	}
+}
@@ -0,0 +1,196 @@
+package marc
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/miku/span"
+	"github.com/miku/span/formats"
+	"github.com/miku/span/formats/finc"
+	"github.com/segmentio/encoding/json"
+)
+
+// DefaultMapping is used, if no mapping is configured.
+var DefaultMapping = MustLoadMapping("assets/marc/default.json")
+
+// Mapping describes how to map MARC fields to intermediate schema fields,
+// keyed by their JSON names. Onboarding a new MARC source should only require
+// a new mapping file.
+//
+//	{
+//	  "id": ["001"],
+//	  "constants": {"finc.source_id": ["1234"]},
+//	  "fields": {"rft.atitle": ["245.a"], "rft.issn": ["022.a"]}
+//	}
+type Mapping struct {
+	// ID lists specs for the record identifier, first non-empty wins.
+	ID []string `json:"id"`
+	// Constants are set on every record.
+	Constants map[string][]string `json:"constants"`
+	// Fields maps intermediate schema fields to MARC specs, like "245.a",
+	// "001" or "008/35-37".
+	Fields map[string][]string `json:"fields"`
+}
+
+// Validate checks, whether all target fields exist.
+func (m *Mapping) Validate() error {
+	for _, fields := range []map[string][]string{m.Constants, m.Fields} {
+		for k := range fields {
+			if !finc.IsField(k) {
+				return fmt.Errorf("marc: unknown intermediate schema field in mapping: %s", k)
+			}
+		}
+	}
+	return nil
+}
+
+// SourceID returns the source id set by the mapping, if any.
+func (m *Mapping) SourceID() string {
+	for _, v := range m.Constants["finc.source_id"] {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// ReadMapping reads and validates a JSON mapping.
+func ReadMapping(r io.Reader) (*Mapping, error) {
+	var m Mapping
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		return nil, err
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// MustLoadMapping loads a mapping from the embedded assets and panics on
+// failure.
+func MustLoadMapping(path string) *Mapping {
+	f, err := span.Static.Open(path)
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	m, err := ReadMapping(f)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// Convert applies the mapping to a record.
+func (m *Mapping) Convert(d Data) (*finc.IntermediateSchema, error) {
+	output := finc.NewIntermediateSchema()
+	for _, spec := range m.ID {
+		if vs := d.Values(spec); len(vs) > 0 && vs[0] != "" {
+			output.RecordID = vs[0]
+			break
+		}
+	}
+	if output.RecordID == "" {
+		output.RecordID = d.Identifier
+	}
+	for _, k := range sortedKeys(m.Constants) {
+		if err := output.SetField(k, m.Constants[k]...); err != nil {
+			return output, err
+		}
+	}
+	if output.SourceID == "" {
+		return output, formats.MissingSourceID(output.RecordID)
+	}
+	if d.Status == "deleted" {
+		// Deleted records carry no metadata, the record id is the OAI
+		// identifier then.
+		if output.RecordID == "" {
+			return output, span.Skip{Code: span.SkipMissingID, Field: "id"}
+		}
+		return finc.NewTombstone(span.GenFincID(output.SourceID, output.RecordID), output.SourceID, output.RecordID), nil
+	}
+	for _, k := range sortedKeys(m.Fields) {
+		var values []string
+		for _, spec := range m.Fields[k] {
+			values = append(values, d.Values(spec)...)
+		}
+		if k == "languages" {
+			values = languageCodes(values)
+		}
+		if err := output.SetField(k, values...); err != nil {
+			if k != "x.date" {
+				return output, err
+			}
+			return output, span.Skip{
+				Code:     span.SkipInvalidDate,
+				RecordID: output.RecordID,
+				Field:    k,
+				Reason:   err.Error(),
+			}
+		}
+	}
+	if output.RecordID == "" {
+		return output, span.Skip{Code: span.SkipMissingID, Field: "id"}
+	}
+	output.ID = span.GenFincID(output.SourceID, output.RecordID)
+	if output.Date.IsZero() {
+		return output, span.Skip{Code: span.SkipMissingDate, RecordID: output.RecordID}
+	}
+	return output, nil
+}
+
+// sortedKeys makes conversions deterministic.
+func sortedKeys(m map[string][]string) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// languageCodes returns ISO 639-3 codes for MARC language codes, like "ger"
+// or "fre", once each; fill characters, blanks and unknown codes are
+// dropped.
+func languageCodes(vs []string) (result []string) {
+	seen := make(map[string]bool)
+	for _, v := range vs {
+		if code := formats.LanguageCode(v); code != "" && !seen[code] {
+			seen[code] = true
+			result = append(result, code)
+		}
+	}
+	return result
+}
+
+// XMLRecord is a MARCXML record, with or without OAI envelope.
+type XMLRecord struct {
+	XMLName xml.Name `xml:"record"`
+	Data
+	mapping *Mapping
+}
+
+// UnmarshalXML decodes the record data.
+func (r *XMLRecord) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
+	return r.Data.UnmarshalXML(dec, start)
+}
+
+// ToIntermediateSchema converts a MARCXML record using the configured mapping.
+func (r *XMLRecord) ToIntermediateSchema() (*finc.IntermediateSchema, error) {
+	return r.mapping.Convert(r.Data)
+}
+
+// BinaryRecord is an ISO 2709 record.
+type BinaryRecord struct {
+	Data
+	mapping *Mapping
+}
+
+// ToIntermediateSchema converts a binary MARC record using the configured
+// mapping.
+func (r *BinaryRecord) ToIntermediateSchema() (*finc.IntermediateSchema, error) {
+	return r.mapping.Convert(r.Data)
+}
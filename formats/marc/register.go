@@ -0,0 +1,73 @@
+package marc
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/miku/span/formats"
+)
+
+// isBinaryMARC checks for a plausible ISO 2709 leader.
+func isBinaryMARC(p []byte) bool {
+	if len(p) < 24 {
+		return false
+	}
+	for _, c := range p[:5] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return p[10] == '2' && p[11] == '2' && bytes.Equal(p[20:24], []byte("4500"))
+}
+
+// readMapping reads a mapping, which must set a source id.
+func readMapping(r io.Reader) (*Mapping, error) {
+	m, err := ReadMapping(r)
+	if err != nil {
+		return nil, err
+	}
+	if m.SourceID() == "" {
+		return nil, fmt.Errorf("marc: %w, add it to constants", formats.ErrMissingSourceID)
+	}
+	return m, nil
+}
+
+func init() {
+	formats.Register(formats.Format{
+		Name:    "marcxml",
+		Framing: formats.FramingXML,
+		New:     func() interface{} { return &XMLRecord{mapping: DefaultMapping} },
+		Signature: &formats.Signature{
+			Elements:   []string{"record"},
+			Namespaces: []string{formats.NamespaceMARCXML},
+			Generic:    true,
+		},
+		RequiresMapping: true,
+		Configure: func(r io.Reader) (formats.Factory, error) {
+			m, err := readMapping(r)
+			if err != nil {
+				return nil, err
+			}
+			return func() interface{} { return &XMLRecord{mapping: m} }, nil
+		},
+	})
+	formats.Register(formats.Format{
+		Name:      "marc21",
+		Framing:   formats.FramingDelimited,
+		Separator: RecordTerminator,
+		New:       func() interface{} { return &BinaryRecord{mapping: DefaultMapping} },
+		Signature: &formats.Signature{
+			Match:   isBinaryMARC,
+			Generic: true,
+		},
+		RequiresMapping: true,
+		Configure: func(r io.Reader) (formats.Factory, error) {
+			m, err := readMapping(r)
+			if err != nil {
+				return nil, err
+			}
+			return func() interface{} { return &BinaryRecord{mapping: m} }, nil
+		},
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/miku/span"
 	"github.com/miku/span/formats/finc"
 )
 
@@ -85,6 +86,8 @@ func (r *Dim) ToIntermediateSchema() (*finc.IntermediateSchema, error) {
 	output := finc.NewIntermediateSchema()
 
 	output.SourceID = "170"
+	output.RecordID = r.Header.Identifier
+	output.ID = span.GenFincID(output.SourceID, output.RecordID)
 	output.MegaCollections = []string{"sid-170-col-mediarep"}
 
 	output.ArticleTitle = r.FieldValue("dc", "title", "")
@@ -118,6 +121,7 @@ func (r *Dim) ToIntermediateSchema() (*finc.IntermediateSchema, error) {
 	}
 
 	output.Date = date
+	output.RawDate = date.Format("2006-01-02")
 
 	for _, c := range r.FieldValues("dc", "creator", "") {
 		output.Authors = append(output.Authors, finc.Author{Name: c})
@@ -0,0 +1,15 @@
+package mediarep
+
+import "github.com/miku/span/formats"
+
+func init() {
+	formats.Register(formats.Format{
+		Name:    "mediarep-dim",
+		Framing: formats.FramingXML,
+		New:     func() interface{} { return new(Dim) },
+		Signature: &formats.Signature{
+			Elements:   []string{"record"},
+			Namespaces: []string{formats.NamespaceDIM},
+		},
+	})
+}
@@ -97,10 +97,19 @@ func (record Record) ToIntermediateSchema() (*finc.IntermediateSchema, error) {
 
 	output.Publishers = append(output.Publishers, record.Metadata.Dc.Publisher.Text)
 	if record.Metadata.Dc.Date.Text == "" {
-		return output, span.Skip{Reason: "empty date"}
+		return output, span.Skip{
+			Code:     span.SkipMissingDate,
+			RecordID: record.Header.Identifier.Text,
+			Field:    "date",
+		}
 	}
 	if len(record.Metadata.Dc.Date.Text) < 4 {
-		return output, span.Skip{Reason: "short date"}
+		return output, span.Skip{
+			Code:     span.SkipInvalidDate,
+			RecordID: record.Header.Identifier.Text,
+			Field:    "date",
+			Value:    record.Metadata.Dc.Date.Text,
+		}
 	}
 	if record.Metadata.Dc.Date.Text != "" {
 		// <dc:date>19787</dc:date> --
@@ -0,0 +1,26 @@
+package olms
+
+import "github.com/miku/span/formats"
+
+func init() {
+	formats.Register(formats.Format{
+		Name:    "olms",
+		Framing: formats.FramingXML,
+		New:     func() interface{} { return new(Record) },
+		Signature: &formats.Signature{
+			Elements:   []string{"record"},
+			Namespaces: []string{formats.NamespaceOAIDC},
+			Contains:   []string{"olmsonline"},
+		},
+	})
+	formats.Register(formats.Format{
+		Name:    "olms-mets",
+		Framing: formats.FramingXML,
+		New:     func() interface{} { return new(MetsRecord) },
+		Signature: &formats.Signature{
+			Elements:   []string{"record"},
+			Namespaces: []string{formats.NamespaceMETS},
+			Contains:   []string{"olmsonline"},
+		},
+	})
+}
@@ -0,0 +1,31 @@
+package openalex
+
+import (
+	"io"
+
+	"github.com/miku/span/formats"
+	"github.com/miku/span/formats/finc"
+)
+
+func init() {
+	formats.Register(formats.Format{
+		Name:    "openalex",
+		Framing: formats.FramingNDJSON,
+		New:     func() interface{} { return &Work{} },
+		Signature: &formats.Signature{
+			Keys:     []string{"id"},
+			Contains: []string{`"https://openalex.org/W`},
+		},
+		RequiresMapping: true,
+		IDKey: func(is *finc.IntermediateSchema) []string {
+			return []string{"https://openalex.org/" + is.RecordID}
+		},
+		Configure: func(r io.Reader) (formats.Factory, error) {
+			s, err := formats.ReadSource(r)
+			if err != nil {
+				return nil, err
+			}
+			return func() interface{} { return &Work{source: s} }, nil
+		},
+	})
+}
@@ -0,0 +1,264 @@
+// Package openalex implements OpenAlex works as input format, as found in the
+// snapshot partitions (data/works/updated_date=.../part_000.gz), one work per
+// line: https://docs.openalex.org/download-all-data/openalex-snapshot
+//
+// Types are mapped via the Crossref type of a work, so genres and formats
+// line up with the crossref source.
+package openalex
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/miku/span"
+	"github.com/miku/span/formats"
+	"github.com/miku/span/formats/crossref"
+	"github.com/miku/span/formats/finc"
+)
+
+// Venue is a journal or repository, as host venue (older snapshots) or as
+// source of a location.
+type Venue struct {
+	DisplayName string   `json:"display_name"`
+	ISSNL       string   `json:"issn_l"`
+	ISSN        []string `json:"issn"`
+	Publisher   string   `json:"publisher"`
+	// HostOrganizationName is the publisher of a source.
+	HostOrganizationName string `json:"host_organization_name"`
+	Type                 string `json:"type"`
+	License              string `json:"license"`
+}
+
+// Location is a place, where a work can be found.
+type Location struct {
+	IsOA           bool   `json:"is_oa"`
+	LandingPageURL string `json:"landing_page_url"`
+	License        string `json:"license"`
+	Source         *Venue `json:"source"`
+}
+
+// Work is a single OpenAlex work. Only fields used in the conversion are
+// decoded, which keeps decoding of the snapshot fast.
+type Work struct {
+	ID              string    `json:"id"`
+	DOI             string    `json:"doi"`
+	Title           string    `json:"title"`
+	DisplayName     string    `json:"display_name"`
+	PublicationYear int       `json:"publication_year"`
+	PublicationDate string    `json:"publication_date"`
+	Language        string    `json:"language"`
+	Type            string    `json:"type"`
+	TypeCrossref    string    `json:"type_crossref"`
+	HostVenue       *Venue    `json:"host_venue"`
+	PrimaryLocation *Location `json:"primary_location"`
+	BestOALocation  *Location `json:"best_oa_location"`
+	OpenAccess      struct {
+		IsOA     bool   `json:"is_oa"`
+		OAStatus string `json:"oa_status"`
+		OAURL    string `json:"oa_url"`
+	} `json:"open_access"`
+	Authorships []struct {
+		Author struct {
+			DisplayName string `json:"display_name"`
+			ORCID       string `json:"orcid"`
+		} `json:"author"`
+	} `json:"authorships"`
+	Biblio struct {
+		Volume    string `json:"volume"`
+		Issue     string `json:"issue"`
+		FirstPage string `json:"first_page"`
+		LastPage  string `json:"last_page"`
+	} `json:"biblio"`
+	Concepts []struct {
+		DisplayName string `json:"display_name"`
+	} `json:"concepts"`
+	Topics []struct {
+		DisplayName string `json:"display_name"`
+	} `json:"topics"`
+	AbstractInvertedIndex map[string][]int `json:"abstract_inverted_index"`
+	source                *formats.Source
+}
+
+// Abstract reconstructs the abstract from the inverted index, which maps
+// each word to its positions.
+func (w *Work) Abstract() string {
+	if len(w.AbstractInvertedIndex) == 0 {
+		return ""
+	}
+	var n int
+	for _, positions := range w.AbstractInvertedIndex {
+		for _, p := range positions {
+			if p >= n {
+				n = p + 1
+			}
+		}
+	}
+	words := make([]string, n)
+	for word, positions := range w.AbstractInvertedIndex {
+		for _, p := range positions {
+			if p >= 0 {
+				words[p] = word
+			}
+		}
+	}
+	var sb strings.Builder
+	for _, word := range words {
+		if word == "" {
+			continue
+		}
+		if sb.Len() > 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(word)
+	}
+	return sb.String()
+}
+
+// Venue returns the host venue, or the source of the primary location in
+// newer snapshots.
+func (w *Work) Venue() *Venue {
+	if w.HostVenue != nil {
+		return w.HostVenue
+	}
+	if w.PrimaryLocation != nil && w.PrimaryLocation.Source != nil {
+		return w.PrimaryLocation.Source
+	}
+	return &Venue{}
+}
+
+// License returns the license of the primary or best open access location.
+func (w *Work) License() string {
+	if v := w.Venue().License; v != "" {
+		return v
+	}
+	for _, loc := range []*Location{w.PrimaryLocation, w.BestOALocation} {
+		if loc != nil && loc.License != "" {
+			return loc.License
+		}
+	}
+	return ""
+}
+
+// Date returns the publication date or the first day of the publication
+// year.
+func (w *Work) Date() (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", w.PublicationDate); err == nil {
+		return t, nil
+	}
+	if w.PublicationYear == 0 {
+		return time.Time{}, span.Skip{Code: span.SkipMissingDate, RecordID: w.ID}
+	}
+	return time.Date(w.PublicationYear, 1, 1, 0, 0, 0, 0, time.UTC), nil
+}
+
+// Subjects returns the distinct names of topics and concepts.
+func (w *Work) Subjects() (result []string) {
+	seen := make(map[string]bool)
+	add := func(s string) {
+		if s = strings.TrimSpace(s); s != "" && !seen[s] {
+			seen[s] = true
+			result = append(result, s)
+		}
+	}
+	for _, t := range w.Topics {
+		add(t.DisplayName)
+	}
+	for _, c := range w.Concepts {
+		add(c.DisplayName)
+	}
+	return result
+}
+
+// ToIntermediateSchema converts a work, the record id is the OpenAlex id,
+// like W2741809807.
+func (w *Work) ToIntermediateSchema() (*finc.IntermediateSchema, error) {
+	output := finc.NewIntermediateSchema()
+	if w.ID == "" {
+		return output, span.Skip{Code: span.SkipMissingID}
+	}
+	output.RecordID = strings.TrimPrefix(w.ID, "https://openalex.org/")
+	if err := w.source.Apply(output, w.ID); err != nil {
+		return output, err
+	}
+	output.ArticleTitle = strings.TrimSpace(w.Title)
+	if output.ArticleTitle == "" {
+		output.ArticleTitle = strings.TrimSpace(w.DisplayName)
+	}
+	if output.ArticleTitle == "" {
+		return output, span.Skip{Code: span.SkipMissingArticleTitle, RecordID: output.RecordID}
+	}
+	date, err := w.Date()
+	if err != nil {
+		return output, err
+	}
+	output.Date = date
+	output.RawDate = date.Format("2006-01-02")
+
+	// Older snapshots use Crossref types as type.
+	kind := w.TypeCrossref
+	if kind == "" {
+		kind = w.Type
+	}
+	output.Type = kind
+	output.Format = crossref.Formats.Lookup(kind, crossref.DefaultFormat)
+	output.Genre = crossref.Genres.Lookup(kind, "unknown")
+	output.RefType = crossref.RefTypes.Lookup(kind, "GEN")
+
+	venue := w.Venue()
+	switch output.Genre {
+	case "bookitem", "proceeding":
+		output.BookTitle = strings.TrimSpace(venue.DisplayName)
+	default:
+		output.JournalTitle = strings.TrimSpace(venue.DisplayName)
+	}
+	issns := make(map[string]bool)
+	for _, issn := range append([]string{venue.ISSNL}, venue.ISSN...) {
+		if issn != "" && !issns[issn] {
+			issns[issn] = true
+			output.ISSN = append(output.ISSN, issn)
+		}
+	}
+	sort.Strings(output.ISSN)
+	for _, p := range []string{venue.Publisher, venue.HostOrganizationName} {
+		if p != "" {
+			output.Publishers = append(output.Publishers, p)
+			break
+		}
+	}
+
+	output.DOI = strings.TrimPrefix(w.DOI, "https://doi.org/")
+	switch {
+	case w.DOI != "":
+		output.URL = append(output.URL, w.DOI)
+	case w.PrimaryLocation != nil && w.PrimaryLocation.LandingPageURL != "":
+		output.URL = append(output.URL, w.PrimaryLocation.LandingPageURL)
+	default:
+		output.URL = append(output.URL, w.ID)
+	}
+	for _, a := range w.Authorships {
+		output.Authors = append(output.Authors, finc.Author{
+			ID:   a.Author.ORCID,
+			Name: strings.TrimSpace(a.Author.DisplayName),
+		})
+	}
+	output.Volume = w.Biblio.Volume
+	output.Issue = w.Biblio.Issue
+	output.StartPage = w.Biblio.FirstPage
+	output.EndPage = w.Biblio.LastPage
+	if output.StartPage != "" && output.EndPage != "" {
+		output.Pages = output.StartPage + "-" + output.EndPage
+	}
+	output.Abstract = w.Abstract()
+	if w.Language != "" {
+		if lang := span.LanguageIdentifier(w.Language); lang != "" {
+			output.Languages = []string{lang}
+		}
+	}
+	output.Subjects = w.Subjects()
+	output.OpenAccess = w.OpenAccess.IsOA || (w.OpenAccess.OAStatus != "" && w.OpenAccess.OAStatus != "closed")
+	if license := w.License(); license != "" {
+		output.License = []string{license}
+	}
+	return output, nil
+}
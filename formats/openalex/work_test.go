@@ -0,0 +1,108 @@
+package openalex
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/miku/span/formats"
+	"github.com/miku/span/formats/finc"
+	"github.com/segmentio/encoding/json"
+)
+
+const work = `{
+  "id": "https://openalex.org/W2741809807",
+  "doi": "https://doi.org/10.7717/peerj.4375",
+  "title": "The state of OA",
+  "display_name": "The state of OA",
+  "publication_year": 2018,
+  "publication_date": "2018-02-13",
+  "language": "en",
+  "type": "article",
+  "type_crossref": "journal-article",
+  "primary_location": {
+    "is_oa": true,
+    "landing_page_url": "https://doi.org/10.7717/peerj.4375",
+    "license": "cc-by",
+    "source": {
+      "display_name": "PeerJ",
+      "issn_l": "2167-8359",
+      "issn": ["2167-8359"],
+      "host_organization_name": "PeerJ, Inc.",
+      "type": "journal"
+    }
+  },
+  "open_access": {"is_oa": true, "oa_status": "gold", "oa_url": "https://doi.org/10.7717/peerj.4375"},
+  "authorships": [
+    {"author_position": "first", "author": {"id": "https://openalex.org/A1", "display_name": "Heather Piwowar", "orcid": "https://orcid.org/0000-0003-1613-5981"}},
+    {"author_position": "last", "author": {"id": "https://openalex.org/A2", "display_name": "Jason Priem", "orcid": null}}
+  ],
+  "biblio": {"volume": "6", "issue": null, "first_page": "e4375", "last_page": "e4375"},
+  "concepts": [{"display_name": "Open access"}, {"display_name": "Citation"}],
+  "topics": [{"display_name": "Open access"}],
+  "abstract_inverted_index": {"Despite": [0], "growing": [1], "interest": [2], "in": [3, 5], "OA": [4], "practice": [6]},
+  "referenced_works": ["https://openalex.org/W1"],
+  "updated_date": "2024-01-01T00:00:00"
+}`
+
+func TestToIntermediateSchema(t *testing.T) {
+	w := &Work{source: &formats.Source{SourceID: "1", MegaCollections: []string{"OpenAlex"}}}
+	if err := json.Unmarshal([]byte(work), w); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	output, err := w.ToIntermediateSchema()
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	want := &finc.IntermediateSchema{
+		Format:          "ElectronicArticle",
+		MegaCollections: []string{"OpenAlex"},
+		ID:              "ai-1-aHR0cHM6Ly9vcGVuYWxleC5vcmcvVzI3NDE4MDk4MDc",
+		RecordID:        "W2741809807",
+		SourceID:        "1",
+		RefType:         "EJOUR",
+		ArticleTitle:    "The state of OA",
+		EndPage:         "e4375",
+		Genre:           "article",
+		ISSN:            []string{"2167-8359"},
+		JournalTitle:    "PeerJ",
+		Pages:           "e4375-e4375",
+		Publishers:      []string{"PeerJ, Inc."},
+		RawDate:         "2018-02-13",
+		Date:            output.Date,
+		StartPage:       "e4375",
+		Volume:          "6",
+		Abstract:        "Despite growing interest in OA in practice",
+		Authors: []finc.Author{
+			{ID: "https://orcid.org/0000-0003-1613-5981", Name: "Heather Piwowar"},
+			{Name: "Jason Priem"},
+		},
+		DOI:        "10.7717/peerj.4375",
+		Languages:  []string{"eng"},
+		URL:        []string{"https://doi.org/10.7717/peerj.4375"},
+		Version:    finc.IntermediateSchemaVersion,
+		Subjects:   []string{"Open access", "Citation"},
+		Type:       "journal-article",
+		OpenAccess: true,
+		License:    []string{"cc-by"},
+	}
+	if !reflect.DeepEqual(output, want) {
+		t.Errorf("got %+v, want %+v", output, want)
+	}
+}
+
+func BenchmarkToIntermediateSchema(b *testing.B) {
+	data := []byte(work)
+	for i := 0; i < b.N; i++ {
+		w := &Work{source: &formats.Source{SourceID: "1"}}
+		if err := json.Unmarshal(data, w); err != nil {
+			b.Fatal(err)
+		}
+		output, err := w.ToIntermediateSchema()
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := json.Marshal(output); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
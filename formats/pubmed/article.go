@@ -0,0 +1,428 @@
+// Package pubmed implements PubMed/MEDLINE XML as input format, as found in
+// the baseline and update files: https://www.nlm.nih.gov/databases/download/pubmed_medline.html
+//
+// Update files contain DeleteCitation elements, which are converted into
+// tombstones.
+package pubmed
+
+import (
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/miku/span"
+	"github.com/miku/span/formats"
+	"github.com/miku/span/formats/finc"
+)
+
+var (
+	orcidPattern = regexp.MustCompile(`[0-9]{4}-[0-9]{4}-[0-9]{4}-[0-9]{3}[0-9X]`)
+	yearPattern  = regexp.MustCompile(`\b[12][0-9]{3}\b`)
+
+	// months are MEDLINE month abbreviations and seasons, which map to a
+	// month within the season.
+	months = map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+		"winter": 1, "spring": 4, "summer": 7, "fall": 10, "autumn": 10,
+	}
+	seasons = map[string]string{
+		"winter": "Winter", "spring": "Spring", "summer": "Summer", "fall": "Fall", "autumn": "Fall",
+	}
+)
+
+// Link returns the PubMed page of a PMID.
+func Link(pmid string) string {
+	return fmt.Sprintf("https://pubmed.ncbi.nlm.nih.gov/%s/", pmid)
+}
+
+// Text is element content with inline markup, like <i> or <sup>, removed.
+type Text string
+
+// UnmarshalXML collects the character data of an element and its children.
+func (t *Text) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var sb strings.Builder
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch v := tok.(type) {
+		case xml.CharData:
+			sb.Write(v)
+		case xml.EndElement:
+			if v.Name == start.Name {
+				*t = Text(strings.Join(strings.Fields(sb.String()), " "))
+				return nil
+			}
+		}
+	}
+}
+
+// AbstractText is a part of an abstract, with an optional label in
+// structured abstracts.
+type AbstractText struct {
+	Label string
+	Text  Text
+}
+
+// UnmarshalXML reads the label and the text.
+func (a *AbstractText) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for _, attr := range start.Attr {
+		if attr.Name.Local == "Label" {
+			a.Label = attr.Value
+		}
+	}
+	return a.Text.UnmarshalXML(d, start)
+}
+
+// PubDate is a structured date or a free text MedlineDate, like "1998
+// Dec-1999 Jan" or "2000 Spring".
+type PubDate struct {
+	Year        string `xml:"Year"`
+	Month       string `xml:"Month"`
+	Day         string `xml:"Day"`
+	Season      string `xml:"Season"`
+	MedlineDate string `xml:"MedlineDate"`
+}
+
+// Date returns the date and a season, if the date names one.
+func (p PubDate) Date() (time.Time, string, error) {
+	if p.Year == "" {
+		return ParseMedlineDate(p.MedlineDate)
+	}
+	year, err := strconv.Atoi(strings.TrimSpace(p.Year))
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	var (
+		month  = 1
+		day    = 1
+		season string
+	)
+	if p.Month != "" {
+		if m, ok := months[strings.ToLower(strings.TrimSpace(p.Month))]; ok {
+			month = m
+		} else if m, err := strconv.Atoi(strings.TrimSpace(p.Month)); err == nil && m > 0 && m < 13 {
+			month = m
+		}
+		if d, err := strconv.Atoi(strings.TrimSpace(p.Day)); err == nil && d > 0 && d < 32 {
+			day = d
+		}
+	} else if s := strings.ToLower(strings.TrimSpace(p.Season)); s != "" {
+		month = months[s]
+		season = seasons[s]
+	}
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC), season, nil
+}
+
+// ParseMedlineDate returns the start of a free text date range, like "1998
+// Dec-1999 Jan", "2000 Nov-Dec", "2001 Jan 15-21" or "2000 Spring".
+func ParseMedlineDate(s string) (time.Time, string, error) {
+	loc := yearPattern.FindStringIndex(s)
+	if loc == nil {
+		return time.Time{}, "", fmt.Errorf("no year in %q", s)
+	}
+	year, _ := strconv.Atoi(s[loc[0]:loc[1]])
+	var (
+		month  = 1
+		day    = 1
+		season string
+		fields = strings.FieldsFunc(s[loc[1]:], func(r rune) bool {
+			return r == ' ' || r == '-' || r == '/' || r == ','
+		})
+	)
+	if len(fields) > 0 {
+		key := strings.ToLower(fields[0])
+		if len(key) > 3 && seasons[key] == "" {
+			key = key[:3]
+		}
+		if m, ok := months[key]; ok {
+			month = m
+			season = seasons[key]
+			if len(fields) > 1 && season == "" {
+				if d, err := strconv.Atoi(fields[1]); err == nil && d > 0 && d < 32 {
+					day = d
+				}
+			}
+		}
+	}
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC), season, nil
+}
+
+// Article is a PubmedArticle element.
+type Article struct {
+	MedlineCitation struct {
+		PMID    string `xml:"PMID"`
+		Article struct {
+			Journal struct {
+				ISSN []struct {
+					Type  string `xml:"IssnType,attr"`
+					Value string `xml:",chardata"`
+				} `xml:"ISSN"`
+				JournalIssue struct {
+					Volume  string  `xml:"Volume"`
+					Issue   string  `xml:"Issue"`
+					PubDate PubDate `xml:"PubDate"`
+				} `xml:"JournalIssue"`
+				Title           string `xml:"Title"`
+				ISOAbbreviation string `xml:"ISOAbbreviation"`
+			} `xml:"Journal"`
+			ArticleTitle Text `xml:"ArticleTitle"`
+			Pagination   struct {
+				MedlinePgn string `xml:"MedlinePgn"`
+			} `xml:"Pagination"`
+			ELocationID []struct {
+				Type  string `xml:"EIdType,attr"`
+				Value string `xml:",chardata"`
+			} `xml:"ELocationID"`
+			Abstract   []AbstractText `xml:"Abstract>AbstractText"`
+			AuthorList []struct {
+				LastName       string `xml:"LastName"`
+				ForeName       string `xml:"ForeName"`
+				Suffix         string `xml:"Suffix"`
+				CollectiveName Text   `xml:"CollectiveName"`
+				Identifier     []struct {
+					Source string `xml:"Source,attr"`
+					Value  string `xml:",chardata"`
+				} `xml:"Identifier"`
+			} `xml:"AuthorList>Author"`
+			Language []string `xml:"Language"`
+		} `xml:"Article"`
+		MedlineJournalInfo struct {
+			ISSNLinking string `xml:"ISSNLinking"`
+		} `xml:"MedlineJournalInfo"`
+		MeshHeadingList []struct {
+			DescriptorName string `xml:"DescriptorName"`
+		} `xml:"MeshHeadingList>MeshHeading"`
+		Keywords []Text `xml:"KeywordList>Keyword"`
+	} `xml:"MedlineCitation"`
+	PubmedData struct {
+		ArticleIDs []struct {
+			Type  string `xml:"IdType,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"ArticleIdList>ArticleId"`
+	} `xml:"PubmedData"`
+}
+
+// ArticleID returns the first article id of a given type, like doi or pmc.
+func (a *Article) ArticleID(kind string) string {
+	for _, id := range a.PubmedData.ArticleIDs {
+		if strings.EqualFold(id.Type, kind) {
+			return strings.TrimSpace(id.Value)
+		}
+	}
+	return ""
+}
+
+// DOI returns the DOI from the article id list or the electronic location.
+func (a *Article) DOI() string {
+	if doi := a.ArticleID("doi"); doi != "" {
+		return doi
+	}
+	for _, loc := range a.MedlineCitation.Article.ELocationID {
+		if strings.EqualFold(loc.Type, "doi") {
+			return strings.TrimSpace(loc.Value)
+		}
+	}
+	return ""
+}
+
+// Abstract joins structured abstracts, like "BACKGROUND: ... METHODS: ...".
+func (a *Article) Abstract() string {
+	var parts []string
+	for _, t := range a.MedlineCitation.Article.Abstract {
+		switch {
+		case t.Text == "":
+		case t.Label != "":
+			parts = append(parts, fmt.Sprintf("%s: %s", t.Label, t.Text))
+		default:
+			parts = append(parts, string(t.Text))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// Authors returns persons and groups, with ORCID, if given.
+func (a *Article) Authors() (authors []finc.Author) {
+	for _, au := range a.MedlineCitation.Article.AuthorList {
+		author := finc.Author{
+			LastName:  strings.TrimSpace(au.LastName),
+			FirstName: strings.TrimSpace(au.ForeName),
+			Suffix:    strings.TrimSpace(au.Suffix),
+			Corporate: string(au.CollectiveName),
+		}
+		for _, id := range au.Identifier {
+			if !strings.EqualFold(id.Source, "ORCID") {
+				continue
+			}
+			if v := orcidPattern.FindString(id.Value); v != "" {
+				author.ID = "https://orcid.org/" + v
+			}
+		}
+		authors = append(authors, author)
+	}
+	return authors
+}
+
+// Pages returns start and end page, abbreviated end pages like "123-9" are
+// expanded to "129".
+func (a *Article) Pages() (start, end string) {
+	pgn := strings.TrimSpace(a.MedlineCitation.Article.Pagination.MedlinePgn)
+	// Multiple ranges, like "12-4, 20", keep the first.
+	pgn = strings.TrimSpace(strings.SplitN(pgn, ",", 2)[0])
+	parts := strings.SplitN(pgn, "-", 2)
+	start = strings.TrimSpace(parts[0])
+	if len(parts) == 1 {
+		return start, ""
+	}
+	end = strings.TrimSpace(parts[1])
+	if len(end) < len(start) {
+		if _, err := strconv.Atoi(start); err == nil {
+			if _, err := strconv.Atoi(end); err == nil {
+				end = start[:len(start)-len(end)] + end
+			}
+		}
+	}
+	return start, end
+}
+
+// ToIntermediateSchema converts an article, given source settings.
+func (a *Article) ToIntermediateSchema(source *formats.Source) (*finc.IntermediateSchema, error) {
+	var (
+		output = finc.NewIntermediateSchema()
+		mc     = a.MedlineCitation
+	)
+	pmid := strings.TrimSpace(mc.PMID)
+	if pmid == "" {
+		return output, span.Skip{Code: span.SkipMissingID}
+	}
+	output.RecordID = pmid
+	if err := source.Apply(output, Link(pmid)); err != nil {
+		return output, err
+	}
+	output.ArticleTitle = strings.TrimSpace(string(mc.Article.ArticleTitle))
+	if output.ArticleTitle == "" {
+		return output, span.Skip{Code: span.SkipMissingArticleTitle, RecordID: pmid}
+	}
+	pubDate := mc.Article.Journal.JournalIssue.PubDate
+	date, season, err := pubDate.Date()
+	if err != nil {
+		return output, span.Skip{
+			Code:     span.SkipMissingDate,
+			RecordID: pmid,
+			Field:    "PubDate",
+			Value:    pubDate.MedlineDate,
+		}
+	}
+	output.Date = date
+	output.RawDate = date.Format("2006-01-02")
+	output.Season = season
+
+	output.Format = "ElectronicArticle"
+	output.Genre = "article"
+	output.RefType = "JOUR"
+	output.JournalTitle = strings.TrimSpace(mc.Article.Journal.Title)
+	output.ShortTitle = strings.TrimSpace(mc.Article.Journal.ISOAbbreviation)
+	for _, issn := range mc.Article.Journal.ISSN {
+		v := strings.TrimSpace(issn.Value)
+		if v == "" {
+			continue
+		}
+		if issn.Type == "Electronic" {
+			output.EISSN = append(output.EISSN, v)
+		} else {
+			output.ISSN = append(output.ISSN, v)
+		}
+	}
+	if v := strings.TrimSpace(mc.MedlineJournalInfo.ISSNLinking); v != "" {
+		var found bool
+		for _, issn := range output.ISSNList() {
+			found = found || issn == v
+		}
+		if !found {
+			output.ISSN = append(output.ISSN, v)
+		}
+	}
+	output.Volume = strings.TrimSpace(mc.Article.Journal.JournalIssue.Volume)
+	output.Issue = strings.TrimSpace(mc.Article.Journal.JournalIssue.Issue)
+	output.StartPage, output.EndPage = a.Pages()
+	output.Pages = strings.TrimSpace(mc.Article.Pagination.MedlinePgn)
+	output.Authors = a.Authors()
+	output.Abstract = a.Abstract()
+	output.DOI = a.DOI()
+	output.URL = append(output.URL, Link(pmid))
+	if pmc := a.ArticleID("pmc"); pmc != "" {
+		output.URL = append(output.URL, fmt.Sprintf("https://www.ncbi.nlm.nih.gov/pmc/articles/%s/", pmc))
+	}
+	for _, lang := range mc.Article.Language {
+		// Mostly ISO 639-2/B codes, like eng or ger.
+		if code := formats.LanguageCode(lang); code != "" {
+			output.Languages = append(output.Languages, code)
+		}
+	}
+	for _, h := range mc.MeshHeadingList {
+		if v := strings.TrimSpace(h.DescriptorName); v != "" {
+			output.Headings = append(output.Headings, v)
+		}
+	}
+	for _, kw := range mc.Keywords {
+		if kw != "" {
+			output.Subjects = append(output.Subjects, string(kw))
+		}
+	}
+	return output, nil
+}
+
+// Record is either a PubmedArticle or a DeleteCitation, which lists the
+// PMIDs of deleted citations.
+type Record struct {
+	Article *Article
+	Deleted []string
+	source  *formats.Source
+}
+
+// UnmarshalXML decodes an article or a deletion.
+func (r *Record) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	switch start.Name.Local {
+	case "PubmedArticle":
+		r.Article = new(Article)
+		return d.DecodeElement(r.Article, &start)
+	case "DeleteCitation":
+		var v struct {
+			PMID []string `xml:"PMID"`
+		}
+		if err := d.DecodeElement(&v, &start); err != nil {
+			return err
+		}
+		for _, pmid := range v.PMID {
+			if pmid = strings.TrimSpace(pmid); pmid != "" {
+				r.Deleted = append(r.Deleted, pmid)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("pubmed: unexpected element %s", start.Name.Local)
+	}
+}
+
+// ToIntermediateSchemaList returns a single article or a tombstone for each
+// deleted citation. On error, the partially converted article is returned.
+func (r *Record) ToIntermediateSchemaList() ([]*finc.IntermediateSchema, error) {
+	if r.Article != nil {
+		output, err := r.Article.ToIntermediateSchema(r.source)
+		return []*finc.IntermediateSchema{output}, err
+	}
+	var result []*finc.IntermediateSchema
+	for _, pmid := range r.Deleted {
+		tombstone, err := r.source.Tombstone(pmid, Link(pmid))
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, tombstone)
+	}
+	return result, nil
+}
@@ -0,0 +1,165 @@
+package pubmed
+
+import (
+	"encoding/xml"
+	"reflect"
+	"testing"
+
+	"github.com/miku/span"
+	"github.com/miku/span/formats"
+	"github.com/miku/span/formats/finc"
+)
+
+const article = `<PubmedArticle>
+  <MedlineCitation Status="MEDLINE" Owner="NLM">
+    <PMID Version="1">10000001</PMID>
+    <Article PubModel="Print">
+      <Journal>
+        <ISSN IssnType="Print">0001-0002</ISSN>
+        <JournalIssue CitedMedium="Print">
+          <Volume>12</Volume>
+          <Issue>3</Issue>
+          <PubDate><MedlineDate>1998 Dec-1999 Jan</MedlineDate></PubDate>
+        </JournalIssue>
+        <Title>Journal of Tags</Title>
+        <ISOAbbreviation>J Tags</ISOAbbreviation>
+      </Journal>
+      <ArticleTitle>On <i>tags</i> in H<sub>2</sub>O.</ArticleTitle>
+      <Pagination><MedlinePgn>123-9</MedlinePgn></Pagination>
+      <Abstract>
+        <AbstractText Label="BACKGROUND" NlmCategory="BACKGROUND">Tags matter.</AbstractText>
+        <AbstractText Label="RESULTS">They <b>do</b>.</AbstractText>
+      </Abstract>
+      <AuthorList CompleteYN="Y">
+        <Author ValidYN="Y">
+          <LastName>Doe</LastName><ForeName>Jane</ForeName><Initials>J</Initials>
+          <Identifier Source="ORCID">0000-0002-1825-0097</Identifier>
+        </Author>
+        <Author ValidYN="Y"><CollectiveName>Tag Study Group</CollectiveName></Author>
+      </AuthorList>
+      <Language>eng</Language>
+    </Article>
+    <MedlineJournalInfo><ISSNLinking>0001-0002</ISSNLinking></MedlineJournalInfo>
+    <MeshHeadingList>
+      <MeshHeading><DescriptorName UI="D006801" MajorTopicYN="N">Humans</DescriptorName></MeshHeading>
+      <MeshHeading>
+        <DescriptorName UI="D014867" MajorTopicYN="N">Water</DescriptorName>
+        <QualifierName UI="Q000737" MajorTopicYN="Y">chemistry</QualifierName>
+      </MeshHeading>
+    </MeshHeadingList>
+    <CommentsCorrectionsList>
+      <CommentsCorrections RefType="CommentIn"><PMID Version="1">9</PMID></CommentsCorrections>
+    </CommentsCorrectionsList>
+  </MedlineCitation>
+  <PubmedData>
+    <ArticleIdList>
+      <ArticleId IdType="pubmed">10000001</ArticleId>
+      <ArticleId IdType="doi">10.1000/tags.1</ArticleId>
+      <ArticleId IdType="pmc">PMC123</ArticleId>
+    </ArticleIdList>
+  </PubmedData>
+</PubmedArticle>`
+
+func TestArticle(t *testing.T) {
+	r := &Record{source: &formats.Source{SourceID: "1"}}
+	if err := xml.Unmarshal([]byte(article), r); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	outputs, err := r.ToIntermediateSchemaList()
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if len(outputs) != 1 {
+		t.Fatalf("got %d documents, want 1", len(outputs))
+	}
+	output := outputs[0]
+	want := &finc.IntermediateSchema{
+		Format:       "ElectronicArticle",
+		ID:           "ai-1-aHR0cHM6Ly9wdWJtZWQubmNiaS5ubG0ubmloLmdvdi8xMDAwMDAwMS8",
+		RecordID:     "10000001",
+		SourceID:     "1",
+		RefType:      "JOUR",
+		ArticleTitle: "On tags in H2O.",
+		EndPage:      "129",
+		Genre:        "article",
+		ISSN:         []string{"0001-0002"},
+		Issue:        "3",
+		JournalTitle: "Journal of Tags",
+		Pages:        "123-9",
+		RawDate:      "1998-12-01",
+		Date:         output.Date,
+		ShortTitle:   "J Tags",
+		StartPage:    "123",
+		Volume:       "12",
+		Abstract:     "BACKGROUND: Tags matter. RESULTS: They do.",
+		Authors: []finc.Author{
+			{ID: "https://orcid.org/0000-0002-1825-0097", LastName: "Doe", FirstName: "Jane"},
+			{Corporate: "Tag Study Group"},
+		},
+		DOI:       "10.1000/tags.1",
+		Languages: []string{"eng"},
+		URL: []string{
+			"https://pubmed.ncbi.nlm.nih.gov/10000001/",
+			"https://www.ncbi.nlm.nih.gov/pmc/articles/PMC123/",
+		},
+		Version:  finc.IntermediateSchemaVersion,
+		Headings: []string{"Humans", "Water"},
+	}
+	if !reflect.DeepEqual(output, want) {
+		t.Errorf("got %+v, want %+v", output, want)
+	}
+}
+
+func TestDeleteCitation(t *testing.T) {
+	r := &Record{source: &formats.Source{SourceID: "1"}}
+	doc := `<DeleteCitation><PMID Version="1">1</PMID><PMID Version="1">2</PMID></DeleteCitation>`
+	if err := xml.Unmarshal([]byte(doc), r); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	outputs, err := r.ToIntermediateSchemaList()
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if len(outputs) != 2 {
+		t.Fatalf("got %d documents, want 2", len(outputs))
+	}
+	for i, pmid := range []string{"1", "2"} {
+		want := finc.NewTombstone(span.GenFincID("1", Link(pmid)), "1", pmid)
+		if !reflect.DeepEqual(outputs[i], want) {
+			t.Errorf("got %+v, want %+v", outputs[i], want)
+		}
+	}
+}
+
+func TestParseMedlineDate(t *testing.T) {
+	var cases = []struct {
+		s      string
+		date   string
+		season string
+		err    bool
+	}{
+		{"1998 Dec-1999 Jan", "1998-12-01", "", false},
+		{"2000 Nov-Dec", "2000-11-01", "", false},
+		{"2001 Jan 15-21", "2001-01-15", "", false},
+		{"2000 Spring", "2000-04-01", "Spring", false},
+		{"1999-2000", "1999-01-01", "", false},
+		{"2002 June", "2002-06-01", "", false},
+		{"Unknown", "", "", true},
+	}
+	for _, c := range cases {
+		date, season, err := ParseMedlineDate(c.s)
+		if c.err {
+			if err == nil {
+				t.Errorf("%q: got nil, want error", c.s)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: got %v, want nil", c.s, err)
+			continue
+		}
+		if got := date.Format("2006-01-02"); got != c.date || season != c.season {
+			t.Errorf("%q: got %v %q, want %v %q", c.s, got, season, c.date, c.season)
+		}
+	}
+}
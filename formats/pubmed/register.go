@@ -0,0 +1,31 @@
+package pubmed
+
+import (
+	"io"
+
+	"github.com/miku/span/formats"
+	"github.com/miku/span/formats/finc"
+)
+
+func init() {
+	formats.Register(formats.Format{
+		Name:     "pubmed",
+		Framing:  formats.FramingXML,
+		New:      func() interface{} { return &Record{} },
+		Elements: []string{"PubmedArticle", "DeleteCitation"},
+		Signature: &formats.Signature{
+			Elements: []string{"PubmedArticleSet"},
+		},
+		RequiresMapping: true,
+		IDKey: func(is *finc.IntermediateSchema) []string {
+			return []string{Link(is.RecordID)}
+		},
+		Configure: func(r io.Reader) (formats.Factory, error) {
+			s, err := formats.ReadSource(r)
+			if err != nil {
+				return nil, err
+			}
+			return func() interface{} { return &Record{source: s} }, nil
+		},
+	})
+}
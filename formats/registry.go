@@ -0,0 +1,225 @@
+// Package formats keeps a registry of input formats. Each format package
+// registers itself in an init function with a name, the way records are
+// framed in the input and a factory for new, empty records. Commands like
+// span-import only need to import the format packages for side effects, e.g.
+// via formats/all.
+package formats
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/miku/span/formats/finc"
+)
+
+// Framing describes how records are delimited in the input.
+type Framing int
+
+const (
+	// FramingXML is a stream of XML elements, one record per element.
+	FramingXML Framing = iota
+	// FramingNDJSON is newline delimited JSON, one record per line.
+	FramingNDJSON
+	// FramingText reads the whole input as a single record.
+	FramingText
+	// FramingTar is a tar shipment, converted by the format as a stream.
+	FramingTar
+	// FramingDelimited are binary records, terminated by a separator byte.
+	FramingDelimited
+	// FramingStream are records, that can only be separated by parsing the
+	// input, e.g. RIS or BibTeX with string macros.
+	FramingStream
+)
+
+// String returns a short name of the framing.
+func (f Framing) String() string {
+	switch f {
+	case FramingXML:
+		return "xml"
+	case FramingNDJSON:
+		return "ndjson"
+	case FramingText:
+		return "text"
+	case FramingTar:
+		return "tar"
+	case FramingDelimited:
+		return "delimited"
+	case FramingStream:
+		return "stream"
+	default:
+		return fmt.Sprintf("framing(%d)", int(f))
+	}
+}
+
+// IntermediateSchemaer wrap a basic conversion method.
+type IntermediateSchemaer interface {
+	ToIntermediateSchema() (*finc.IntermediateSchema, error)
+}
+
+// IntermediateSchemaLister converts a single record into any number of
+// documents, e.g. a list of deletions. On error, the list may contain the
+// partially converted document, like with IntermediateSchemaer.
+type IntermediateSchemaLister interface {
+	ToIntermediateSchemaList() ([]*finc.IntermediateSchema, error)
+}
+
+// Factory creates a new, empty record, typically a pointer to a struct.
+type Factory func() interface{}
+
+// EmitFunc receives the documents of a shipment, as soon as they are
+// converted. A failed record is passed as err, which may be a *RecordError or
+// a span.Skip, together with the partially converted document, if any.
+// Returning an error stops the conversion.
+type EmitFunc func(doc *finc.IntermediateSchema, err error) error
+
+// BatchFunc converts a whole shipment, passing documents to emit one by one,
+// so a shipment does not need to fit into memory.
+type BatchFunc func(r io.Reader, emit EmitFunc) error
+
+// ConfigureFunc reads a format specific configuration, e.g. a field mapping,
+// and returns a factory for records using this configuration.
+type ConfigureFunc func(r io.Reader) (Factory, error)
+
+// Decoder reads consecutive records from a stream, like json.Decoder.
+type Decoder interface {
+	// Decode reads the next record into v, which is created by the format
+	// factory. It returns io.EOF at the end of the stream and a
+	// *RecordError for a malformed record, after which decoding can go on.
+	Decode(v interface{}) error
+	// Raw returns the raw bytes of the last record, e.g. for a rejects file.
+	Raw() []byte
+}
+
+// LineDecoder is a decoder, that knows the line the last record started on,
+// e.g. for provenance, optional.
+type LineDecoder interface {
+	Decoder
+	Line() int
+}
+
+// DecoderFunc returns a decoder for a stream of records.
+type DecoderFunc func(r io.Reader) Decoder
+
+// RecordError is a malformed record in a stream or in a shipment.
+type RecordError struct {
+	Line int
+	// Name is the file of the record in a shipment, if any.
+	Name string
+	Err  error
+}
+
+// Error returns the line or file and the cause.
+func (e *RecordError) Error() string {
+	if e.Name != "" {
+		return fmt.Sprintf("%s: %v", e.Name, e.Err)
+	}
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+// Unwrap returns the cause.
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
+// Format describes a registered input format.
+type Format struct {
+	// Name is the value passed to span-import -i.
+	Name string
+	// Framing of records in the input.
+	Framing Framing
+	// New returns a new record, for all framings except FramingTar.
+	New Factory
+	// Batch converts a shipment, only used with FramingTar.
+	Batch BatchFunc
+	// Elements are the names of record elements, only used with FramingXML
+	// and only needed, if there is more than one kind of record element.
+	// Defaults to the XMLName of the record.
+	Elements []string
+	// Separator terminates records, only used with FramingDelimited.
+	Separator byte
+	// NewDecoder splits a stream into records, only used with FramingStream.
+	NewDecoder DecoderFunc
+	// Signature is used for format detection, optional. Formats without a
+	// signature are never detected automatically.
+	Signature *Signature
+	// Configure is set for formats, that can be customized, optional.
+	Configure ConfigureFunc
+	// RequiresMapping is set for formats, whose records do not tell where
+	// they come from, so a configuration with a source id is needed, e.g.
+	// RIS or MARC. Without it, every record would be skipped.
+	RequiresMapping bool
+	// IDKey returns the values the finc.id may be derived from, if that is
+	// not the record id, e.g. a link, optional. It is used by the validator.
+	IDKey finc.IDKeyFunc
+}
+
+var (
+	mu       sync.RWMutex
+	registry = make(map[string]Format)
+)
+
+// Register makes a format available by name. Register panics, if the name is
+// already taken or the format is incomplete, since this is a programming error.
+func Register(f Format) {
+	mu.Lock()
+	defer mu.Unlock()
+	if f.Name == "" {
+		panic("formats: register with empty name")
+	}
+	if _, dup := registry[f.Name]; dup {
+		panic("formats: register called twice for " + f.Name)
+	}
+	switch f.Framing {
+	case FramingTar:
+		if f.Batch == nil {
+			panic("formats: missing batch function for " + f.Name)
+		}
+	case FramingStream:
+		if f.New == nil || f.NewDecoder == nil {
+			panic("formats: missing factory or decoder for " + f.Name)
+		}
+	default:
+		if f.New == nil {
+			panic("formats: missing factory for " + f.Name)
+		}
+	}
+	if f.RequiresMapping && f.Configure == nil {
+		panic("formats: missing configure function for " + f.Name)
+	}
+	if f.IDKey != nil {
+		finc.RegisterIDKey(f.Name, f.IDKey)
+	}
+	registry[f.Name] = f
+}
+
+// Lookup returns the format registered under a given name.
+func Lookup(name string) (Format, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	f, ok := registry[name]
+	return f, ok
+}
+
+// Names returns the sorted names of all registered formats.
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	var names []string
+	for k := range registry {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// All returns all registered formats, sorted by name.
+func All() []Format {
+	var result []Format
+	for _, name := range Names() {
+		f, _ := Lookup(name)
+		result = append(result, f)
+	}
+	return result
+}
@@ -0,0 +1,121 @@
+package ris
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/miku/span/formats"
+)
+
+// tagLine matches "TY  - JOUR", some exporters omit the trailing space after
+// the dash for empty values or use a single space before the dash.
+var tagLine = regexp.MustCompile(`^([A-Z][A-Z0-9]) {1,2}-(?: (.*))?$`)
+
+// Decoder reads RIS records from a stream. Lines, which do not start with a
+// tag, continue the value of the previous tag. Records start with TY and end
+// with ER, anything between records is ignored.
+type Decoder struct {
+	scanner *bufio.Scanner
+	line    int
+	start   int
+	raw     bytes.Buffer
+	// pending is a line, that has been read, but belongs to the next record.
+	pending *string
+}
+
+// NewDecoder returns a decoder, that reads from r.
+func NewDecoder(r io.Reader) *Decoder {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	return &Decoder{scanner: scanner}
+}
+
+// Raw returns the lines of the last record.
+func (d *Decoder) Raw() []byte {
+	return d.raw.Bytes()
+}
+
+// Line returns the line, the last record started on.
+func (d *Decoder) Line() int {
+	return d.start
+}
+
+// readLine returns the next line, if any.
+func (d *Decoder) readLine() (string, bool) {
+	if d.pending != nil {
+		line := *d.pending
+		d.pending = nil
+		return line, true
+	}
+	if !d.scanner.Scan() {
+		return "", false
+	}
+	d.line++
+	line := strings.TrimRight(d.scanner.Text(), "\r")
+	if d.line == 1 {
+		line = strings.TrimPrefix(line, "\ufeff")
+	}
+	return line, true
+}
+
+// Decode reads the next record into v, which must be a *Record.
+func (d *Decoder) Decode(v interface{}) error {
+	record, ok := v.(*Record)
+	if !ok {
+		return fmt.Errorf("ris: cannot decode into %T", v)
+	}
+	d.raw.Reset()
+	var (
+		inRecord bool
+		last     string
+		start    int
+	)
+	for {
+		line, ok := d.readLine()
+		if !ok {
+			break
+		}
+		m := tagLine.FindStringSubmatch(line)
+		if !inRecord {
+			if m == nil || m[1] != "TY" {
+				if m != nil {
+					d.raw.WriteString(line + "\n")
+					return &formats.RecordError{Line: d.line, Err: fmt.Errorf("ris: %s outside of record", m[1])}
+				}
+				continue
+			}
+			inRecord, start = true, d.line
+			d.start = start
+		} else if m != nil && m[1] == "TY" {
+			d.pending = &line
+			return &formats.RecordError{Line: start, Err: errors.New("ris: record without ER")}
+		}
+		d.raw.WriteString(line + "\n")
+		switch {
+		case m == nil && strings.TrimSpace(line) == "":
+			continue
+		case m == nil:
+			// Continuation of a multi-line value.
+			values := record.Fields[last]
+			values[len(values)-1] = strings.TrimSpace(values[len(values)-1] + " " + strings.TrimSpace(line))
+		case m[1] == "ER":
+			return nil
+		default:
+			last = m[1]
+			record.Add(last, strings.TrimSpace(m[2]))
+		}
+	}
+	if err := d.scanner.Err(); err != nil {
+		return err
+	}
+	if inRecord {
+		// Be lenient with a missing ER at the end of the file.
+		return nil
+	}
+	return io.EOF
+}
@@ -0,0 +1,151 @@
+package ris
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/miku/span/container"
+	"github.com/miku/span/formats/finc"
+)
+
+// RefTypes maps rft.genre to reference types, for documents that carry no
+// RIS type of their own.
+var RefTypes = container.MapDefault{
+	"article":    "JOUR",
+	"book":       "BOOK",
+	"bookitem":   "CHAP",
+	"conference": "CONF",
+	"document":   "GEN",
+	"issue":      "JFULL",
+	"journal":    "JFULL",
+	"preprint":   "INPR",
+	"proceeding": "CPAPER",
+	"report":     "RPRT",
+}
+
+// tagOrder is the order of tags in exported records; TY comes first, ER is
+// added at the end.
+var tagOrder = []string{
+	"TY", "ID", "TI", "ST", "AU", "JF", "T2", "T3", "PY", "DA", "VL", "IS",
+	"SP", "EP", "SN", "DO", "UR", "AB", "KW", "LA", "PB", "CY", "ET", "DB",
+	"DP", "N1",
+}
+
+// FromIntermediateSchema creates a record from a document, mostly the
+// reverse of ToIntermediateSchema.
+func FromIntermediateSchema(is finc.IntermediateSchema) *Record {
+	r := NewRecord(nil, nil)
+	add := func(tag string, values ...string) {
+		for _, v := range values {
+			if v = strings.TrimSpace(v); v != "" {
+				r.Add(tag, v)
+			}
+		}
+	}
+	ty := is.RefType
+	if _, ok := Genres[ty]; !ok {
+		ty = RefTypes.Lookup(is.Genre, "GEN")
+	}
+	add("TY", ty)
+	add("ID", is.RecordID)
+	if is.ArticleTitle != "" {
+		add("TI", is.ArticleTitle)
+	} else {
+		add("TI", is.BookTitle)
+	}
+	add("ST", is.ShortTitle)
+	for _, a := range is.Authors {
+		add("AU", authorName(a))
+	}
+	switch {
+	case is.JournalTitle != "":
+		add("JF", is.JournalTitle)
+	case is.ArticleTitle != "" && is.BookTitle != is.ArticleTitle:
+		add("T2", is.BookTitle)
+	}
+	add("T3", is.Series)
+	// PY is the year, DA the date as far as it is known, e.g. "2019/05//".
+	if parts := is.DateParts(); parts != nil {
+		add("PY", strconv.Itoa(parts[0]))
+		if len(parts) > 1 {
+			da := fmt.Sprintf("%04d/%02d/", parts[0], parts[1])
+			if len(parts) > 2 {
+				da += fmt.Sprintf("%02d", parts[2])
+			}
+			add("DA", da+"/")
+		}
+	}
+	add("VL", is.Volume)
+	add("IS", is.Issue)
+	add("SP", is.StartPage)
+	add("EP", is.EndPage)
+	add("SN", is.ISSNList()...)
+	add("SN", is.ISBNList()...)
+	add("DO", is.DOI)
+	add("UR", is.URL...)
+	add("AB", is.AbstractCleaned())
+	add("KW", is.Subjects...)
+	add("LA", is.Languages...)
+	add("PB", is.Publishers...)
+	add("CY", is.Places...)
+	add("ET", is.Edition)
+	add("DB", is.Database)
+	add("DP", is.DataProvider)
+	add("N1", is.Footnotes...)
+	return r
+}
+
+// MarshalText serializes the record, TY first and ER last. Tags not in the
+// default order follow in alphabetical order.
+func (r *Record) MarshalText() ([]byte, error) {
+	if len(r.Fields["TY"]) == 0 {
+		return nil, fmt.Errorf("ris: record without TY")
+	}
+	var (
+		buf  bytes.Buffer
+		seen = make(map[string]bool)
+		tags = append([]string{}, tagOrder...)
+		rest []string
+	)
+	for _, tag := range tagOrder {
+		seen[tag] = true
+	}
+	for tag := range r.Fields {
+		if !seen[tag] && tag != "ER" {
+			rest = append(rest, tag)
+		}
+	}
+	sort.Strings(rest)
+	for _, tag := range append(tags, rest...) {
+		for _, v := range r.Fields[tag] {
+			// Values span a single line.
+			fmt.Fprintf(&buf, "%s  - %s\n", tag, strings.Join(strings.Fields(v), " "))
+		}
+	}
+	buf.WriteString("ER  - \n")
+	return buf.Bytes(), nil
+}
+
+// Exporter writes documents as RIS records, separated by an empty line.
+type Exporter struct{}
+
+// Export converts a document into a RIS record.
+func (e *Exporter) Export(is finc.IntermediateSchema, _ bool) ([]byte, error) {
+	return FromIntermediateSchema(is).MarshalText()
+}
+
+// authorName returns "Last, First", as most reference managers expect.
+func authorName(a finc.Author) string {
+	switch {
+	case a.LastName != "" && a.FirstName != "":
+		return a.LastName + ", " + a.FirstName
+	case a.LastName != "":
+		return a.LastName
+	case a.Name != "":
+		return a.Name
+	}
+	return a.Corporate
+}
@@ -0,0 +1,69 @@
+package ris
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miku/span/formats"
+	"github.com/miku/span/formats/finc"
+)
+
+func TestExportRoundTrip(t *testing.T) {
+	r := &Record{source: &formats.Source{SourceID: "1"}}
+	if err := NewDecoder(strings.NewReader(sample)).Decode(r); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	want, err := r.ToIntermediateSchema()
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	b, err := new(Exporter).Export(*want, false)
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if !strings.HasPrefix(string(b), "TY  - JOUR\n") || !strings.HasSuffix(string(b), "ER  - \n") {
+		t.Errorf("unexpected record framing: %q", b)
+	}
+	exported := &Record{source: &formats.Source{SourceID: "1"}}
+	if err := NewDecoder(strings.NewReader(string(b))).Decode(exported); err != nil {
+		t.Fatalf("cannot decode exported record: %v", err)
+	}
+	got, err := exported.ToIntermediateSchema()
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestExportDate(t *testing.T) {
+	var cases = []struct {
+		about   string
+		date    time.Time
+		rawDate string
+		want    []string
+	}{
+		{"date", time.Date(2019, 5, 2, 0, 0, 0, 0, time.UTC), "2019-05-02", []string{"PY  - 2019\n", "DA  - 2019/05/02/\n"}},
+		{"month", time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC), "2019-05", []string{"PY  - 2019\n", "DA  - 2019/05//\n"}},
+		{"year", time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), "2019-01-01", []string{"PY  - 2019\n"}},
+	}
+	for _, c := range cases {
+		is := finc.IntermediateSchema{RefType: "JOUR", Date: c.date, RawDate: c.rawDate}
+		b, err := new(Exporter).Export(is, false)
+		if err != nil {
+			t.Fatalf("%s: got %v, want nil", c.about, err)
+		}
+		var got []string
+		for _, line := range strings.SplitAfter(string(b), "\n") {
+			if strings.HasPrefix(line, "PY") || strings.HasPrefix(line, "DA") {
+				got = append(got, line)
+			}
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: got %q, want %q", c.about, got, c.want)
+		}
+	}
+}
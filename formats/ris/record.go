@@ -0,0 +1,250 @@
+// Package ris implements RIS, a tagged citation format, as exported by most
+// reference managers and library catalogs: https://en.wikipedia.org/wiki/RIS_(file_format)
+//
+//	TY  - JOUR
+//	AU  - Doe, Jane
+//	TI  - On Tags
+//	PY  - 2019
+//	ER  -
+package ris
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/miku/span"
+	"github.com/miku/span/assetutil"
+	"github.com/miku/span/formats"
+	"github.com/miku/span/formats/finc"
+)
+
+const (
+	// DefaultGenre is used for unknown reference types.
+	DefaultGenre = "unknown"
+	// DefaultFormat is used for unknown reference types.
+	DefaultFormat = "ElectronicArticle"
+)
+
+var (
+	// Genres maps reference types to rft.genre.
+	Genres = assetutil.MustLoadStringMap("assets/ris/genres.json")
+	// Formats maps reference types to finc.format.
+	Formats = assetutil.MustLoadStringMap("assets/ris/formats.json")
+
+	datePattern = regexp.MustCompile(`^([12][0-9]{3})(?:[/-]([0-9]{1,2}))?(?:[/-]([0-9]{1,2}))?`)
+	issnPattern = regexp.MustCompile(`^[0-9]{4}-?[0-9]{3}[0-9xX]$`)
+	isbnPattern = regexp.MustCompile(`^(?:[0-9]{9}[0-9xX]|[0-9]{13})$`)
+)
+
+// Record is a single RIS record, values are kept per tag in input order.
+type Record struct {
+	Fields map[string][]string
+	source *formats.Source
+}
+
+// NewRecord creates a record from tags and values, e.g. to convert other
+// citation formats via RIS.
+func NewRecord(fields map[string][]string, source *formats.Source) *Record {
+	return &Record{Fields: fields, source: source}
+}
+
+// Add appends a value to a tag.
+func (r *Record) Add(tag, value string) {
+	if r.Fields == nil {
+		r.Fields = make(map[string][]string)
+	}
+	r.Fields[tag] = append(r.Fields[tag], value)
+}
+
+// First returns the first non-empty value of the first tag found.
+func (r *Record) First(tags ...string) string {
+	for _, tag := range tags {
+		for _, v := range r.Fields[tag] {
+			if v = strings.TrimSpace(v); v != "" {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
+// All returns all non-empty values of the given tags.
+func (r *Record) All(tags ...string) (result []string) {
+	for _, tag := range tags {
+		for _, v := range r.Fields[tag] {
+			if v = strings.TrimSpace(v); v != "" {
+				result = append(result, v)
+			}
+		}
+	}
+	return result
+}
+
+// Type returns the reference type, e.g. JOUR.
+func (r *Record) Type() string {
+	return strings.ToUpper(r.First("TY"))
+}
+
+// Date parses a RIS date, like "2019", "2019/05/02" or "2019/05//". PY often
+// is the year only, then a more precise DA of the same year is used.
+func (r *Record) Date() (time.Time, error) {
+	t, n, err := parseDate(r.First("PY", "Y1", "DA"))
+	if err != nil {
+		return t, err
+	}
+	if da, m, err := parseDate(r.First("DA")); err == nil && m > n && da.Year() == t.Year() {
+		return da, nil
+	}
+	return t, nil
+}
+
+// parseDate parses a RIS date and returns the number of date parts found.
+func parseDate(s string) (time.Time, int, error) {
+	m := datePattern.FindStringSubmatch(s)
+	if m == nil {
+		return time.Time{}, 0, fmt.Errorf("no date found in %q", s)
+	}
+	layout, value, n := "2006", m[1], 1
+	if m[2] != "" {
+		layout, value, n = layout+"-1", value+"-"+m[2], 2
+		if m[3] != "" {
+			layout, value, n = layout+"-2", value+"-"+m[3], 3
+		}
+	}
+	t, err := time.Parse(layout, value)
+	return t, n, err
+}
+
+// Authors parses primary authors, given as "Last, First" or "First Last".
+func (r *Record) Authors() (authors []finc.Author) {
+	for _, v := range r.All("AU", "A1") {
+		authors = append(authors, ParseAuthor(v))
+	}
+	return authors
+}
+
+// ParseAuthor splits a name into first and last name, if possible.
+func ParseAuthor(s string) finc.Author {
+	s = strings.TrimSpace(s)
+	if i := strings.Index(s, ","); i > 0 {
+		return finc.Author{
+			LastName:  strings.TrimSpace(s[:i]),
+			FirstName: strings.TrimSpace(s[i+1:]),
+		}
+	}
+	if i := strings.LastIndex(s, " "); i > 0 {
+		return finc.Author{
+			FirstName: strings.TrimSpace(s[:i]),
+			LastName:  strings.TrimSpace(s[i+1:]),
+		}
+	}
+	return finc.Author{Name: s}
+}
+
+// StandardNumbers sorts SN values into ISSN and ISBN.
+func (r *Record) StandardNumbers() (issn, isbn []string) {
+	for _, v := range r.All("SN") {
+		for _, f := range strings.FieldsFunc(v, func(c rune) bool {
+			return c == ';' || c == ',' || c == ' '
+		}) {
+			switch {
+			case issnPattern.MatchString(f):
+				f = strings.ToUpper(strings.Replace(f, "-", "", -1))
+				issn = append(issn, f[:4]+"-"+f[4:])
+			case isbnPattern.MatchString(strings.Replace(f, "-", "", -1)):
+				isbn = append(isbn, f)
+			}
+		}
+	}
+	return issn, isbn
+}
+
+// DOI returns the DOI without resolver prefix.
+func (r *Record) DOI() string {
+	return formats.TrimDOI(r.First("DO"))
+}
+
+// ToIntermediateSchema converts a record. The record id is taken from ID or
+// AN, the DOI or the URL, in that order.
+func (r *Record) ToIntermediateSchema() (*finc.IntermediateSchema, error) {
+	output := finc.NewIntermediateSchema()
+	ty := r.Type()
+	output.RefType = ty
+	output.Genre = Genres.Lookup(ty, DefaultGenre)
+	output.Format = Formats.Lookup(ty, DefaultFormat)
+
+	output.DOI = r.DOI()
+	output.RecordID = r.First("ID", "AN")
+	if output.RecordID == "" {
+		output.RecordID = output.DOI
+	}
+	if output.RecordID == "" {
+		output.RecordID = r.First("UR")
+	}
+	if output.RecordID == "" {
+		return output, span.Skip{Code: span.SkipMissingID, Field: "ID"}
+	}
+	if err := r.source.Apply(output, output.RecordID); err != nil {
+		return output, err
+	}
+
+	output.ArticleTitle = r.First("TI", "T1")
+	if output.ArticleTitle == "" {
+		return output, span.Skip{Code: span.SkipMissingTitle, RecordID: output.RecordID, Field: "TI"}
+	}
+	switch output.Genre {
+	case "article", "journal", "preprint":
+		output.JournalTitle = r.First("JF", "JO", "T2", "JA", "J2")
+	case "bookitem", "proceeding":
+		output.BookTitle = r.First("BT", "T2")
+	case "book":
+		output.BookTitle = r.First("BT", "TI", "T1")
+	}
+	output.ShortTitle = r.First("ST")
+	output.Series = r.First("T3")
+
+	date, err := r.Date()
+	if err != nil {
+		return output, span.Skip{
+			Code:     span.SkipMissingDate,
+			RecordID: output.RecordID,
+			Field:    "PY",
+			Reason:   err.Error(),
+		}
+	}
+	output.Date = date
+	output.RawDate = date.Format("2006-01-02")
+
+	output.Authors = r.Authors()
+	output.Volume = r.First("VL")
+	output.Issue = r.First("IS", "CP")
+	output.StartPage = r.First("SP")
+	output.EndPage = r.First("EP")
+	if i := strings.Index(output.StartPage, "-"); i > 0 && output.EndPage == "" {
+		output.StartPage, output.EndPage = output.StartPage[:i], output.StartPage[i+1:]
+	}
+	if output.StartPage != "" && output.EndPage != "" {
+		output.Pages = fmt.Sprintf("%s-%s", output.StartPage, output.EndPage)
+	}
+	output.ISSN, output.ISBN = r.StandardNumbers()
+	output.Edition = r.First("ET")
+	output.Abstract = r.First("AB", "N2")
+	output.Subjects = r.All("KW")
+	output.Publishers = r.All("PB")
+	output.Places = r.All("CY", "PP")
+	output.Database = r.First("DB")
+	output.DataProvider = r.First("DP")
+	output.URL = r.All("UR", "LK")
+	if len(output.URL) == 0 && output.DOI != "" {
+		output.URL = []string{"https://doi.org/" + output.DOI}
+	}
+	output.Footnotes = r.All("N1")
+	for _, v := range r.All("LA") {
+		if code := formats.LanguageCode(v); code != "" {
+			output.Languages = append(output.Languages, code)
+		}
+	}
+	return output, nil
+}
@@ -0,0 +1,107 @@
+package ris
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/miku/span"
+	"github.com/miku/span/formats"
+	"github.com/miku/span/formats/finc"
+)
+
+const sample = "\ufeffTY  - JOUR\r\n" +
+	"ID  - 42\r\n" +
+	"AU  - Doe, Jane\r\n" +
+	"AU  - John Smith\r\n" +
+	"TI  - On Tags\r\n" +
+	"T2  - Journal of Tags\r\n" +
+	"AB  - A first line\r\n" +
+	"  continued on the second.\r\n" +
+	"KW  - tags\r\n" +
+	"KW  - formats\r\n" +
+	"PY  - 2019/05/02/\r\n" +
+	"SP  - 12-34\r\n" +
+	"SN  - 12345678; 978-3-16-148410-0\r\n" +
+	"DO  - https://doi.org/10.1/x\r\n" +
+	"LA  - English\r\n" +
+	"ER  - \r\n" +
+	"\r\n" +
+	"TY  - BOOK\r\n" +
+	"TI  - Without End\r\n" +
+	"TY  - CHAP\r\n" +
+	"TI  - No Date\r\n" +
+	"UR  - http://example.org/1\r\n" +
+	"ER  -\r\n" +
+	"TY  - BOOK\r\n" +
+	"TI  - Last\r\n" +
+	"ID  - 43\r\n" +
+	"PY  - 2001\r\n"
+
+func TestDecoder(t *testing.T) {
+	var (
+		dec     = NewDecoder(strings.NewReader(sample))
+		records []*Record
+		errs    int
+	)
+	for {
+		r := &Record{source: &formats.Source{SourceID: "1"}}
+		err := dec.Decode(r)
+		if err == io.EOF {
+			break
+		}
+		var re *formats.RecordError
+		if errors.As(err, &re) {
+			errs++
+			continue
+		}
+		if err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+		records = append(records, r)
+	}
+	if len(records) != 3 || errs != 1 {
+		t.Fatalf("got %d records and %d errors, want 3 and 1", len(records), errs)
+	}
+	output, err := records[0].ToIntermediateSchema()
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	want := &finc.IntermediateSchema{
+		Format:       "ElectronicArticle",
+		ID:           "ai-1-NDI",
+		RecordID:     "42",
+		SourceID:     "1",
+		RefType:      "JOUR",
+		ArticleTitle: "On Tags",
+		Genre:        "article",
+		ISBN:         []string{"978-3-16-148410-0"},
+		ISSN:         []string{"1234-5678"},
+		JournalTitle: "Journal of Tags",
+		RawDate:      "2019-05-02",
+		Date:         output.Date,
+		StartPage:    "12",
+		EndPage:      "34",
+		Pages:        "12-34",
+		Abstract:     "A first line continued on the second.",
+		Authors:      []finc.Author{{LastName: "Doe", FirstName: "Jane"}, {FirstName: "John", LastName: "Smith"}},
+		DOI:          "10.1/x",
+		Languages:    []string{"eng"},
+		URL:          []string{"https://doi.org/10.1/x"},
+		Version:      finc.IntermediateSchemaVersion,
+		Subjects:     []string{"tags", "formats"},
+	}
+	if !reflect.DeepEqual(output, want) {
+		t.Errorf("got %+v, want %+v", output, want)
+	}
+	_, err = records[1].ToIntermediateSchema()
+	if skip, ok := err.(span.Skip); !ok || skip.Code != span.SkipMissingDate {
+		t.Errorf("got %v, want skip with missing date", err)
+	}
+	output, err = records[2].ToIntermediateSchema()
+	if err != nil || output.Genre != "book" || output.BookTitle != "Last" {
+		t.Errorf("got %+v, %v, want book", output, err)
+	}
+}
@@ -0,0 +1,33 @@
+package ris
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/miku/span/formats"
+)
+
+// isRIS checks for a leading TY tag.
+func isRIS(p []byte) bool {
+	p = bytes.TrimLeft(bytes.TrimPrefix(p, []byte("\xef\xbb\xbf")), " \t\r\n")
+	line := bytes.TrimRight(bytes.SplitN(p, []byte("\n"), 2)[0], "\r")
+	return bytes.HasPrefix(line, []byte("TY ")) && tagLine.Match(line)
+}
+
+func init() {
+	formats.Register(formats.Format{
+		Name:            "ris",
+		Framing:         formats.FramingStream,
+		New:             func() interface{} { return &Record{} },
+		NewDecoder:      func(r io.Reader) formats.Decoder { return NewDecoder(r) },
+		Signature:       &formats.Signature{Match: isRIS},
+		RequiresMapping: true,
+		Configure: func(r io.Reader) (formats.Factory, error) {
+			s, err := formats.ReadSource(r)
+			if err != nil {
+				return nil, err
+			}
+			return func() interface{} { return &Record{source: s} }, nil
+		},
+	})
+}
@@ -0,0 +1,130 @@
+package formats
+
+import (
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/miku/span"
+	"github.com/miku/span/formats/finc"
+	"github.com/segmentio/encoding/json"
+	"golang.org/x/text/language"
+)
+
+// ErrMissingSourceID is returned for source settings without source id, which
+// every finc.id is derived from.
+var ErrMissingSourceID = errors.New("formats: source_id is required")
+
+// doiResolvers are prefixes, that are not part of a DOI.
+var doiResolvers = []string{
+	"https://doi.org/",
+	"http://doi.org/",
+	"https://dx.doi.org/",
+	"http://dx.doi.org/",
+	"doi:",
+}
+
+// Source holds settings for a collection, for formats, whose records carry no
+// information about where they come from, like RIS, DataCite or PubMed.
+// Formats with more settings embed it.
+//
+//	{"source_id": "1234", "mega_collections": ["Some Collection"]}
+type Source struct {
+	SourceID        string   `json:"source_id"`
+	MegaCollections []string `json:"mega_collections"`
+}
+
+// Validate checks for a source id.
+func (s *Source) Validate() error {
+	if s == nil || strings.TrimSpace(s.SourceID) == "" {
+		return ErrMissingSourceID
+	}
+	return nil
+}
+
+// ReadSource reads and validates source settings from JSON.
+func ReadSource(r io.Reader) (*Source, error) {
+	var s Source
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, err
+	}
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// ID returns the finc.id for a key, usually the record id or a link, or the
+// empty string, if no source id is configured.
+func (s *Source) ID(key string) string {
+	if s == nil || s.SourceID == "" {
+		return ""
+	}
+	return span.GenFincID(s.SourceID, key)
+}
+
+// Apply sets source id, collections and the finc.id, derived from key, on a
+// document. Without source id, e.g. if the format was not configured, the
+// record is skipped, since it could neither be updated nor deleted later.
+func (s *Source) Apply(is *finc.IntermediateSchema, key string) error {
+	if err := s.Validate(); err != nil {
+		return MissingSourceID(is.RecordID)
+	}
+	is.SourceID = s.SourceID
+	is.MegaCollections = s.MegaCollections
+	is.ID = s.ID(key)
+	if len(is.ID) > span.KeyLengthLimit {
+		return span.Skip{Code: span.SkipIDTooLong, RecordID: is.RecordID}
+	}
+	return nil
+}
+
+// Tombstone returns a deletion of a record, the finc.id is derived from key,
+// like in Apply.
+func (s *Source) Tombstone(recordID, key string) (*finc.IntermediateSchema, error) {
+	if err := s.Validate(); err != nil {
+		return nil, MissingSourceID(recordID)
+	}
+	return finc.NewTombstone(s.ID(key), s.SourceID, recordID), nil
+}
+
+// MissingSourceID returns the skip for a record, that cannot get a finc.id,
+// since no source id is configured.
+func MissingSourceID(recordID string) span.Skip {
+	return span.Skip{
+		Code:     span.SkipMissingID,
+		RecordID: recordID,
+		Field:    "finc.source_id",
+		Reason:   ErrMissingSourceID.Error(),
+	}
+}
+
+// TrimDOI removes whitespace and a resolver or scheme prefix from a DOI, like
+// "https://doi.org/" or "doi:".
+func TrimDOI(doi string) string {
+	doi = strings.TrimSpace(doi)
+	for _, p := range doiResolvers {
+		if len(doi) >= len(p) && strings.EqualFold(doi[:len(p)], p) {
+			return doi[len(p):]
+		}
+	}
+	return doi
+}
+
+// LanguageCode returns a three letter code for a language name or code, like
+// "English", "en", "en-US" or "eng", or the empty string.
+func LanguageCode(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return ""
+	}
+	if code := span.LanguageIdentifier(s); code != "" {
+		return code
+	}
+	if tag, err := language.Parse(s); err == nil {
+		if base, conf := tag.Base(); conf != language.No {
+			return base.ISO3()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,59 @@
+package formats
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestReadSource(t *testing.T) {
+	var cases = []struct {
+		s   string
+		err error
+	}{
+		{`{"source_id": "1", "mega_collections": ["A"]}`, nil},
+		{`{"mega_collections": ["A"]}`, ErrMissingSourceID},
+		{`{"source_id": " "}`, ErrMissingSourceID},
+	}
+	for _, c := range cases {
+		if _, err := ReadSource(strings.NewReader(c.s)); !errors.Is(err, c.err) {
+			t.Errorf("%s: got %v, want %v", c.s, err, c.err)
+		}
+	}
+}
+
+func TestTrimDOI(t *testing.T) {
+	var cases = []struct {
+		s      string
+		result string
+	}{
+		{"10.1/1", "10.1/1"},
+		{" https://doi.org/10.1/1", "10.1/1"},
+		{"HTTP://DX.DOI.ORG/10.1/1", "10.1/1"},
+		{"doi:10.1/1", "10.1/1"},
+	}
+	for _, c := range cases {
+		if result := TrimDOI(c.s); result != c.result {
+			t.Errorf("TrimDOI(%q): got %q, want %q", c.s, result, c.result)
+		}
+	}
+}
+
+func TestLanguageCode(t *testing.T) {
+	var cases = []struct {
+		s      string
+		result string
+	}{
+		{"en", "eng"},
+		{"en-US", "eng"},
+		{"ger", "deu"},
+		{"German", "deu"},
+		{"", ""},
+		{"not a language", ""},
+	}
+	for _, c := range cases {
+		if result := LanguageCode(c.s); result != c.result {
+			t.Errorf("LanguageCode(%q): got %q, want %q", c.s, result, c.result)
+		}
+	}
+}
@@ -190,10 +190,16 @@ func (r Record) ToIntermediateSchema() (*finc.IntermediateSchema, error) {
 		return output, err
 	}
 
+	if r.Header.Status == "deleted" {
+		return finc.NewTombstone(fmt.Sprintf("ai-30-%s", id), "30", id), nil
+	}
 	if t, ok := r.HasEmbargo(); ok {
-		msg := fmt.Sprintf("embargo restriction for %s", id)
 		log.Printf("embargo for %s expires on %s", id, t.Format("2006-01-02"))
-		return output, span.Skip{Reason: msg}
+		return output, span.Skip{
+			Code:     span.SkipEmbargo,
+			RecordID: id,
+			Reason:   fmt.Sprintf("expires on %s", t.Format("2006-01-02")),
+		}
 	}
 
 	output.RecordID = id
@@ -0,0 +1,21 @@
+package ssoar
+
+import (
+	"regexp"
+
+	"github.com/miku/span/formats"
+)
+
+func init() {
+	formats.Register(formats.Format{
+		Name:    "ssoar",
+		Framing: formats.FramingXML,
+		New:     func() interface{} { return new(Record) },
+		Signature: &formats.Signature{
+			Elements:   []string{"record"},
+			Namespaces: []string{formats.NamespaceMARCXML},
+			// Links to the repository are in 856.u.
+			Text: map[string]*regexp.Regexp{"subfield": regexp.MustCompile(`^https?://(www\.)?ssoar\.info/`)},
+		},
+	})
+}
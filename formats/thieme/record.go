@@ -228,13 +228,21 @@ func (record Record) Date() (time.Time, error) {
 func (record Record) ToIntermediateSchema() (*finc.IntermediateSchema, error) {
 	output := finc.NewIntermediateSchema()
 	if len(record.Metadata.Article) == 0 {
-		return nil, span.Skip{Reason: "no article found"}
+		return nil, span.Skip{
+			Code:     span.SkipMissingArticle,
+			RecordID: record.Header.Identifier.Text,
+		}
 	}
 	article := record.Metadata.Article[0]
 
 	date, err := record.Date()
 	if err != nil {
-		return output, span.Skip{Reason: err.Error()}
+		return output, span.Skip{
+			Code:     span.SkipInvalidDate,
+			RecordID: record.Header.Identifier.Text,
+			Field:    "date",
+			Reason:   err.Error(),
+		}
 	}
 	output.Date = date
 	output.RawDate = date.Format("2006-01-02")
@@ -274,7 +282,11 @@ func (record Record) ToIntermediateSchema() (*finc.IntermediateSchema, error) {
 		}
 	}
 	if publisher == "" {
-		return output, span.Skip{Reason: "empty publisher string"}
+		return output, span.Skip{
+			Code:     span.SkipMissingPublisher,
+			RecordID: record.Header.Identifier.Text,
+			Field:    "publisher",
+		}
 	}
 	output.Publishers = append(output.Publishers, publisher)
 
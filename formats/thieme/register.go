@@ -0,0 +1,20 @@
+package thieme
+
+import (
+	"regexp"
+
+	"github.com/miku/span/formats"
+)
+
+func init() {
+	formats.Register(formats.Format{
+		Name:    "thieme-nlm",
+		Framing: formats.FramingXML,
+		New:     func() interface{} { return new(Record) },
+		Signature: &formats.Signature{
+			Elements: []string{"record", "article"},
+			// A Thieme DOI in the OAI header, not just a citation.
+			Text: map[string]*regexp.Regexp{"identifier": regexp.MustCompile(`^10\.1055[-/]`)},
+		},
+	})
+}
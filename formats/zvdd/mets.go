@@ -231,7 +231,12 @@ func (r *MetsRecord) ToIntermediateSchema() (output *finc.IntermediateSchema, er
 		output.RawDate = dates[0]
 		output.Date, err = parseDate(output.RawDate)
 		if err != nil {
-			return output, span.Skip{Reason: fmt.Sprintf("Unparsed date: %s", output.RawDate)}
+			return output, span.Skip{
+				Code:     span.SkipInvalidDate,
+				RecordID: output.RecordID,
+				Field:    "date",
+				Value:    output.RawDate,
+			}
 		}
 	}
 
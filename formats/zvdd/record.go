@@ -59,6 +59,9 @@ func (r DublicCoreRecord) ToIntermediateSchema() (*finc.IntermediateSchema, erro
 	output.ID = fmt.Sprintf("ai-%s-%s", SourceIdentifier, encodedIdentifier)
 	output.RecordID = urn
 	output.SourceID = SourceIdentifier
+	if r.Header.Status == "deleted" {
+		return finc.NewTombstone(output.ID, output.SourceID, output.RecordID), nil
+	}
 	output.Genre = Genre
 	output.Format = Format
 	output.RefType = DefaultRefType
@@ -0,0 +1,26 @@
+package zvdd
+
+import "github.com/miku/span/formats"
+
+func init() {
+	formats.Register(formats.Format{
+		Name:    "zvdd",
+		Framing: formats.FramingXML,
+		New:     func() interface{} { return new(DublicCoreRecord) },
+		Signature: &formats.Signature{
+			Elements:   []string{"record"},
+			Namespaces: []string{formats.NamespaceOAIDC},
+			Contains:   []string{"oai:www.zvdd.de"},
+		},
+	})
+	formats.Register(formats.Format{
+		Name:    "zvdd-mets",
+		Framing: formats.FramingXML,
+		New:     func() interface{} { return new(MetsRecord) },
+		Signature: &formats.Signature{
+			Elements:   []string{"record"},
+			Namespaces: []string{formats.NamespaceMETS},
+			Contains:   []string{"oai:www.zvdd.de"},
+		},
+	})
+}
@@ -11,6 +11,17 @@ func DetectLang3(text string) (string, error) {
 	return whatlanggo.LangToString(whatlanggo.Detect(text).Lang), nil
 }
 
+// DetectLanguage returns the best guess 3-letter language code for a given
+// text together with a confidence between 0 and 1. The code is empty, if the
+// script of the text is unknown.
+func DetectLanguage(text string) (string, float64) {
+	info := whatlanggo.Detect(text)
+	if info.Script == nil {
+		return "", 0
+	}
+	return whatlanggo.LangToString(info.Lang), info.Confidence
+}
+
 // LanguageIdentifier returns the three letter identifier from a variety of
 // language name notations. Returns the empty string, if nothing matches. All
 // data from http://www-01.sil.org/iso639-3/codes.asp.
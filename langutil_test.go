@@ -34,6 +34,28 @@ func TestDetectLang3(t *testing.T) {
 	}
 }
 
+func TestDetectLanguage(t *testing.T) {
+	var cases = []struct {
+		in       string
+		out      string
+		reliable bool
+	}{
+		{"", "", false},
+		{"123", "", false},
+		{"in Hoffnung den Grund und die rechte Tieffe darinnen zu finden", "deu", true},
+		{"Hello World", "nld", false},
+	}
+	for _, c := range cases {
+		result, confidence := DetectLanguage(c.in)
+		if result != c.out {
+			t.Errorf("%q: got %v, want %v", c.in, result, c.out)
+		}
+		if reliable := confidence >= 0.8; reliable != c.reliable {
+			t.Errorf("%q: got confidence %v, want reliable %v", c.in, confidence, c.reliable)
+		}
+	}
+}
+
 func TestLanguageIdentifier(t *testing.T) {
 	var cases = []struct {
 		in  string
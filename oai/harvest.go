@@ -0,0 +1,353 @@
+// Package oai implements a minimal OAI-PMH harvester, which writes raw
+// records as a stream of XML elements, suitable for span-import. Each record
+// carries the namespace declarations it needs.
+//
+// Only ListRecords is used. Resumption tokens are followed, responses with
+// noRecordsMatch count as empty, deleted records are kept, unless requested
+// otherwise.
+package oai
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultGranularity is day granularity, which every repository supports.
+const DefaultGranularity = "2006-01-02"
+
+// ErrTooManyRetries is returned, when a request fails repeatedly.
+var ErrTooManyRetries = errors.New("oai: too many retries")
+
+// Doer abstracts https://pkg.go.dev/net/http#Client.Do.
+type Doer interface {
+	Do(*http.Request) (*http.Response, error)
+}
+
+// Error is an OAI-PMH protocol error.
+type Error struct {
+	Code    string
+	Message string
+}
+
+// Error returns code and message.
+func (e Error) Error() string {
+	return fmt.Sprintf("oai: %s: %s", e.Code, e.Message)
+}
+
+// Stats summarizes a harvest.
+type Stats struct {
+	Requests int
+	Records  int
+	Deleted  int
+}
+
+// Harvester fetches records from an OAI-PMH endpoint.
+type Harvester struct {
+	Endpoint       string
+	MetadataPrefix string
+	Set            string
+	// Granularity is a time layout for from and until.
+	Granularity string
+	UserAgent   string
+	Client      Doer
+	// MaxRetries and Backoff control retries of failed requests, the wait
+	// time doubles with each attempt.
+	MaxRetries  int
+	Backoff     time.Duration
+	SkipDeleted bool
+	Verbose     bool
+}
+
+// page is the result of a single ListRecords request.
+type page struct {
+	records [][]byte
+	deleted int
+	token   string
+}
+
+// Harvest writes all records in the window from - until to w, as raw record
+// elements, each followed by a newline. Records may span lines. Namespaces
+// declared outside of a record, e.g. on the OAI-PMH element, are added to it.
+func (h *Harvester) Harvest(w io.Writer, from, until time.Time) (Stats, error) {
+	var (
+		stats  Stats
+		layout = h.Granularity
+	)
+	if layout == "" {
+		layout = DefaultGranularity
+	}
+	vs := url.Values{}
+	vs.Set("verb", "ListRecords")
+	vs.Set("metadataPrefix", h.MetadataPrefix)
+	if h.Set != "" {
+		vs.Set("set", h.Set)
+	}
+	if !from.IsZero() {
+		vs.Set("from", formatDatestamp(from, layout))
+	}
+	if !until.IsZero() {
+		vs.Set("until", formatDatestamp(until, layout))
+	}
+	for {
+		p, err := h.fetch(vs)
+		stats.Requests++
+		if e, ok := err.(Error); ok && e.Code == "noRecordsMatch" {
+			return stats, nil
+		}
+		if err != nil {
+			return stats, err
+		}
+		for _, record := range p.records {
+			if _, err := w.Write(record); err != nil {
+				return stats, err
+			}
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return stats, err
+			}
+		}
+		stats.Records += len(p.records)
+		stats.Deleted += p.deleted
+		if p.token == "" {
+			return stats, nil
+		}
+		vs = url.Values{}
+		vs.Set("verb", "ListRecords")
+		vs.Set("resumptionToken", p.token)
+	}
+}
+
+// formatDatestamp formats a time as OAI datestamp. Day granularity keeps the
+// calendar date, finer granularities are expressed in UTC, as required by the
+// protocol.
+func formatDatestamp(t time.Time, layout string) string {
+	if layout == DefaultGranularity {
+		return t.Format(layout)
+	}
+	return t.UTC().Format(layout)
+}
+
+// fetch requests and parses a single page, with retries.
+func (h *Harvester) fetch(vs url.Values) (*page, error) {
+	link := fmt.Sprintf("%s?%s", h.Endpoint, vs.Encode())
+	backoff := h.Backoff
+	for i := 0; i <= h.MaxRetries; i++ {
+		if i > 0 {
+			if h.Verbose {
+				log.Printf("[%d] retrying in %s", i, backoff)
+			}
+			time.Sleep(backoff)
+			backoff = backoff * 2
+		}
+		if h.Verbose {
+			log.Println(link)
+		}
+		b, err := h.get(link)
+		if err != nil {
+			log.Printf("request: %v", err)
+			continue
+		}
+		p, err := h.parse(b)
+		if _, ok := err.(Error); ok {
+			return nil, err
+		}
+		if err != nil {
+			log.Printf("parse: %v", err)
+			continue
+		}
+		return p, nil
+	}
+	return nil, ErrTooManyRetries
+}
+
+// get returns the response body.
+func (h *Harvester) get(link string) ([]byte, error) {
+	req, err := http.NewRequest("GET", link, nil)
+	if err != nil {
+		return nil, err
+	}
+	if h.UserAgent != "" {
+		req.Header.Set("User-Agent", h.UserAgent)
+	}
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// parse extracts raw records, the resumption token and protocol errors from
+// a response body.
+func (h *Harvester) parse(b []byte) (*page, error) {
+	var (
+		p   = &page{}
+		dec = xml.NewDecoder(bytes.NewReader(b))
+		// scopes are the namespace declarations of the open elements,
+		// e.g. on OAI-PMH, which records need, once they are cut out.
+		scopes [][]xml.Attr
+	)
+	// OAI-PMH requires UTF-8, so offsets refer to the original bytes.
+	dec.Strict = false
+	for {
+		offset := dec.InputOffset()
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return p, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		var se xml.StartElement
+		switch t := tok.(type) {
+		case xml.StartElement:
+			se = t
+		case xml.EndElement:
+			if len(scopes) > 0 {
+				scopes = scopes[:len(scopes)-1]
+			}
+			continue
+		default:
+			continue
+		}
+		switch se.Name.Local {
+		case "error":
+			var e struct {
+				Code    string `xml:"code,attr"`
+				Message string `xml:",chardata"`
+			}
+			if err := dec.DecodeElement(&e, &se); err != nil {
+				return nil, err
+			}
+			return nil, Error{Code: e.Code, Message: strings.TrimSpace(e.Message)}
+		case "resumptionToken":
+			var token string
+			if err := dec.DecodeElement(&token, &se); err != nil {
+				return nil, err
+			}
+			p.token = strings.TrimSpace(token)
+		case "record":
+			deleted, err := skipRecord(dec)
+			if err != nil {
+				return nil, err
+			}
+			if deleted {
+				p.deleted++
+				if h.SkipDeleted {
+					continue
+				}
+			}
+			raw := withNamespaces(b[offset:dec.InputOffset()], se, scopes)
+			p.records = append(p.records, raw)
+		default:
+			var decls []xml.Attr
+			for _, attr := range se.Attr {
+				if isNamespaceDecl(attr) {
+					decls = append(decls, attr)
+				}
+			}
+			scopes = append(scopes, decls)
+		}
+	}
+}
+
+// isNamespaceDecl returns true for xmlns and xmlns:prefix attributes.
+func isNamespaceDecl(attr xml.Attr) bool {
+	return attr.Name.Space == "xmlns" || (attr.Name.Space == "" && attr.Name.Local == "xmlns")
+}
+
+// withNamespaces adds the namespace declarations in scope, that a raw record
+// element does not make itself, to its start tag, so the record can be parsed
+// on its own.
+func withNamespaces(raw []byte, se xml.StartElement, scopes [][]xml.Attr) []byte {
+	var (
+		inScope  = make(map[string]string) // prefix, "" for the default, to URI
+		prefixes []string
+	)
+	for _, decls := range scopes {
+		for _, attr := range decls {
+			prefix := attr.Name.Local
+			if attr.Name.Space == "" {
+				prefix = ""
+			}
+			if _, ok := inScope[prefix]; !ok {
+				prefixes = append(prefixes, prefix)
+			}
+			inScope[prefix] = attr.Value
+		}
+	}
+	for _, attr := range se.Attr {
+		if attr.Name.Space == "xmlns" {
+			delete(inScope, attr.Name.Local)
+		} else if attr.Name.Space == "" && attr.Name.Local == "xmlns" {
+			delete(inScope, "")
+		}
+	}
+	if len(inScope) == 0 {
+		return raw
+	}
+	var buf bytes.Buffer
+	for _, prefix := range prefixes {
+		uri, ok := inScope[prefix]
+		if !ok {
+			continue
+		}
+		buf.WriteString(" xmlns")
+		if prefix != "" {
+			buf.WriteString(":" + prefix)
+		}
+		buf.WriteString(`="`)
+		xml.EscapeText(&buf, []byte(uri))
+		buf.WriteString(`"`)
+	}
+	// The name of the start tag ends at whitespace, / or >.
+	end := bytes.IndexAny(raw, " \t\r\n/>")
+	if end == -1 {
+		return raw
+	}
+	result := make([]byte, 0, len(raw)+buf.Len())
+	result = append(result, raw[:end]...)
+	result = append(result, buf.Bytes()...)
+	return append(result, raw[end:]...)
+}
+
+// skipRecord consumes a record element and reports, whether its header has
+// a deleted status.
+func skipRecord(dec *xml.Decoder) (deleted bool, err error) {
+	depth := 1
+	for depth > 0 {
+		tok, err := dec.Token()
+		if err != nil {
+			return false, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			if t.Name.Local != "header" {
+				continue
+			}
+			for _, attr := range t.Attr {
+				if attr.Name.Local == "status" && attr.Value == "deleted" {
+					deleted = true
+				}
+			}
+		case xml.EndElement:
+			depth--
+		}
+	}
+	return deleted, nil
+}
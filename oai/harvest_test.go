@@ -0,0 +1,174 @@
+package oai
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const responseTemplate = `<?xml version="1.0" encoding="UTF-8"?>
+<OAI-PMH xmlns="http://www.openarchives.org/OAI/2.0/">
+  <responseDate>2024-01-01T00:00:00Z</responseDate>
+  <request verb="ListRecords">http://localhost/oai</request>
+  %s
+</OAI-PMH>`
+
+var (
+	page1 = `<ListRecords>
+    <record><header><identifier>oai:x:1</identifier></header><metadata><dc>A</dc></metadata></record>
+    <record><header status="deleted"><identifier>oai:x:2</identifier></header></record>
+    <resumptionToken cursor="0">token-1</resumptionToken>
+  </ListRecords>`
+	page2 = `<ListRecords>
+    <record><header><identifier>oai:x:3</identifier></header><metadata><dc>B</dc></metadata></record>
+    <resumptionToken cursor="2"/>
+  </ListRecords>`
+	noRecords = `<error code="noRecordsMatch">No matching records.</error>`
+	badToken  = `<error code="badResumptionToken">Expired.</error>`
+)
+
+// stub serves two pages, linked by a resumption token. The first request
+// fails with an internal server error.
+func stub(t *testing.T) (*httptest.Server, *int32) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			http.Error(w, "try again", http.StatusInternalServerError)
+			return
+		}
+		q := r.URL.Query()
+		var body string
+		switch {
+		case q.Get("resumptionToken") == "token-1":
+			body = page2
+		case q.Get("resumptionToken") != "":
+			body = badToken
+		case q.Get("set") == "empty":
+			body = noRecords
+		case q.Get("metadataPrefix") != "oai_dc" || q.Get("from") != "2024-01-01" || q.Get("until") != "2024-01-31":
+			t.Errorf("unexpected query: %v", q)
+			body = badToken
+		default:
+			body = page1
+		}
+		fmt.Fprintf(w, responseTemplate, body)
+	}))
+	return ts, &requests
+}
+
+func TestHarvest(t *testing.T) {
+	ts, requests := stub(t)
+	defer ts.Close()
+	var (
+		from  = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		until = time.Date(2024, 1, 31, 23, 59, 59, 0, time.UTC)
+	)
+	var cases = []struct {
+		about       string
+		set         string
+		skipDeleted bool
+		records     int
+		deleted     int
+		contains    []string
+	}{
+		{"two pages", "", false, 3, 1, []string{"oai:x:1", "oai:x:2", "oai:x:3"}},
+		{"skip deleted", "", true, 2, 1, []string{"oai:x:1", "oai:x:3"}},
+		{"no records match", "empty", false, 0, 0, nil},
+	}
+	for _, c := range cases {
+		var (
+			buf bytes.Buffer
+			h   = Harvester{
+				Endpoint:       ts.URL,
+				MetadataPrefix: "oai_dc",
+				Set:            c.set,
+				MaxRetries:     3,
+				Backoff:        time.Millisecond,
+				SkipDeleted:    c.skipDeleted,
+			}
+		)
+		stats, err := h.Harvest(&buf, from, until)
+		if err != nil {
+			t.Fatalf("%s: got %v, want nil", c.about, err)
+		}
+		if stats.Records != c.records || stats.Deleted != c.deleted {
+			t.Errorf("%s: got %+v, want %d records, %d deleted", c.about, stats, c.records, c.deleted)
+		}
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		for i, s := range c.contains {
+			if !strings.HasPrefix(lines[i], `<record xmlns="http://www.openarchives.org/OAI/2.0/">`) || !strings.Contains(lines[i], s) {
+				t.Errorf("%s: got %q, want record with %s", c.about, lines[i], s)
+			}
+		}
+	}
+	if *requests != 6 {
+		t.Errorf("got %d requests, want 6", *requests)
+	}
+}
+
+func TestParseNamespaces(t *testing.T) {
+	body := `<OAI-PMH xmlns="http://www.openarchives.org/OAI/2.0/" xmlns:dc="http://purl.org/dc/elements/1.1/">
+  <ListRecords xmlns:oai_dc="http://www.openarchives.org/OAI/2.0/oai_dc/">
+    <record><header><identifier>oai:x:1</identifier></header>
+      <metadata><oai_dc:dc><dc:title>A</dc:title></oai_dc:dc></metadata>
+    </record>
+    <record xmlns:dc="http://example.org/dc"><metadata><dc:title>B</dc:title></metadata></record>
+  </ListRecords>
+</OAI-PMH>`
+	p, err := new(Harvester).parse([]byte(body))
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if len(p.records) != 2 {
+		t.Fatalf("got %d records, want 2", len(p.records))
+	}
+	// Cut out records must resolve their prefixes on their own.
+	for i, want := range []string{"http://purl.org/dc/elements/1.1/", "http://example.org/dc"} {
+		if got := elementSpace(t, p.records[i], "title"); got != want {
+			t.Errorf("record %d: got title in %q, want %q", i, got, want)
+		}
+		if got := elementSpace(t, p.records[i], "record"); got != "http://www.openarchives.org/OAI/2.0/" {
+			t.Errorf("record %d: got record in %q, want OAI namespace", i, got)
+		}
+	}
+}
+
+// elementSpace returns the namespace of the first element with a given name.
+func elementSpace(t *testing.T, b []byte, name string) string {
+	dec := xml.NewDecoder(bytes.NewReader(b))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			t.Fatalf("%s: got %v, want element %s", b, err, name)
+		}
+		if se, ok := tok.(xml.StartElement); ok && se.Name.Local == name {
+			return se.Name.Space
+		}
+	}
+}
+
+func TestHarvestError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, responseTemplate, badToken)
+	}))
+	defer ts.Close()
+	h := Harvester{Endpoint: ts.URL, MetadataPrefix: "oai_dc"}
+	_, err := h.Harvest(&bytes.Buffer{}, time.Time{}, time.Time{})
+	if e, ok := err.(Error); !ok || e.Code != "badResumptionToken" {
+		t.Errorf("got %v, want badResumptionToken", err)
+	}
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+	h = Harvester{Endpoint: ts.URL, MetadataPrefix: "oai_dc", MaxRetries: 2, Backoff: time.Millisecond}
+	if _, err := h.Harvest(&bytes.Buffer{}, time.Time{}, time.Time{}); err != ErrTooManyRetries {
+		t.Errorf("got %v, want %v", err, ErrTooManyRetries)
+	}
+}
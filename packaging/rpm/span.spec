@@ -34,11 +34,14 @@ install -m 755 span-freeze $RPM_BUILD_ROOT/usr/local/bin
 install -m 755 span-hcov $RPM_BUILD_ROOT/usr/local/bin
 install -m 755 span-import $RPM_BUILD_ROOT/usr/local/bin
 install -m 755 span-local-data $RPM_BUILD_ROOT/usr/local/bin
+install -m 755 span-migrate $RPM_BUILD_ROOT/usr/local/bin
 install -m 755 span-oa-filter $RPM_BUILD_ROOT/usr/local/bin
+install -m 755 span-oai-harvest $RPM_BUILD_ROOT/usr/local/bin
 install -m 755 span-redact $RPM_BUILD_ROOT/usr/local/bin
 install -m 755 span-report $RPM_BUILD_ROOT/usr/local/bin
 install -m 755 span-tag $RPM_BUILD_ROOT/usr/local/bin
 install -m 755 span-update-labels $RPM_BUILD_ROOT/usr/local/bin
+install -m 755 span-validate $RPM_BUILD_ROOT/usr/local/bin
 
 
 mkdir -p $RPM_BUILD_ROOT/usr/local/share/man/man1
@@ -74,11 +77,14 @@ rm -rf %{_topdir}/BUILD/%{name}
 /usr/local/bin/span-hcov
 /usr/local/bin/span-import
 /usr/local/bin/span-local-data
+/usr/local/bin/span-migrate
 /usr/local/bin/span-oa-filter
+/usr/local/bin/span-oai-harvest
 /usr/local/bin/span-redact
 /usr/local/bin/span-report
 /usr/local/bin/span-tag
 /usr/local/bin/span-update-labels
+/usr/local/bin/span-validate
 
 %attr(0644, daemon, daemon) /var/log/span-webhookd.log
 
@@ -0,0 +1,8 @@
+// Package schema embeds the JSON schemas of all intermediate schema versions,
+// named is-<version>.json.
+package schema
+
+import "embed"
+
+//go:embed is-*.json
+var FS embed.FS